@@ -0,0 +1,83 @@
+// Package analysis defines the interface between modular static
+// checks of clite programs and the driver that runs them, modelled
+// on golang.org/x/tools/go/analysis.
+//
+// An Analyzer declares its name, the analyzers it depends on and a
+// Run function. Run is handed a Pass holding the program, its typing
+// and the results of the required analyzers, and reports Diagnostics
+// through it.
+package analysis
+
+import (
+	"fmt"
+
+	"github.com/mentalpumkins/clite-go/ast"
+	"github.com/mentalpumkins/clite-go/token"
+	"github.com/mentalpumkins/clite-go/types"
+)
+
+// An Analyzer describes an analysis function and its options.
+type Analyzer struct {
+	// Name identifies the analyzer; it must be unique among the
+	// analyzers run together.
+	Name string
+	// Doc is a short description of what the analyzer checks.
+	Doc string
+	// Requires lists the analyzers whose results this one uses.
+	// They are run first and their results are available in
+	// Pass.ResultOf.
+	Requires []*Analyzer
+	// Run applies the analyzer to a program. The returned result is
+	// made available to the analyzers that require this one.
+	Run func(*Pass) (interface{}, error)
+}
+
+func (a *Analyzer) String() string { return a.Name }
+
+// A Pass provides information to the Run function of one Analyzer
+// applied to one program.
+type Pass struct {
+	Analyzer *Analyzer
+	Prog     *ast.Program
+	Types    *types.TypeMap
+
+	// ResultOf holds the results of the required analyzers.
+	ResultOf map[*Analyzer]interface{}
+
+	// Report records a diagnostic.
+	Report func(Diagnostic)
+}
+
+// Reportf records a diagnostic at pos with a formatted message.
+func (p *Pass) Reportf(pos token.Position, format string, args ...interface{}) {
+	p.Report(Diagnostic{Pos: pos, Message: fmt.Sprintf(format, args...)})
+}
+
+// A Diagnostic is a message associated with a source position,
+// possibly carrying fixes that would resolve it.
+type Diagnostic struct {
+	Pos      token.Position
+	Category string // name of the reporting analyzer; set by the driver
+	Message  string
+
+	SuggestedFixes []SuggestedFix
+}
+
+func (d Diagnostic) String() string {
+	return fmt.Sprintf("%s: [%s] %s", d.Pos, d.Category, d.Message)
+}
+
+// A SuggestedFix is a set of edits to the program that resolves a
+// Diagnostic.
+type SuggestedFix struct {
+	Message string
+	Edits   []Edit
+}
+
+// An Edit replaces the node Old by New. Old must be a statement or a
+// pointer expression (*ast.Binary, *ast.Unary, *ast.Call, *ast.Index,
+// *ast.Ternary or *ast.Selector) of the program, so that it can be
+// identified unambiguously.
+type Edit struct {
+	Old, New ast.Node
+}
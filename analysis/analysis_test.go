@@ -0,0 +1,100 @@
+package analysis
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/mentalpumkins/clite-go/ast"
+	"github.com/mentalpumkins/clite-go/parser"
+	"github.com/mentalpumkins/clite-go/print"
+)
+
+// count results in the number of assignments of the program. Its
+// diagnostics are dropped unless it is run directly.
+var count = &Analyzer{
+	Name: "count",
+	Run: func(pass *Pass) (interface{}, error) {
+		n := 0
+		ast.Inspect(pass.Prog, func(node ast.Node) bool {
+			if _, ok := node.(*ast.Assignment); ok {
+				n++
+			}
+			return node != nil
+		})
+		pass.Reportf(pass.Prog.Body[0].(*ast.Assignment).Pos, "not reported")
+		return n, nil
+	},
+}
+
+// findAssign reports each assignment of the program and suggests
+// replacing it with a skip.
+var findAssign = &Analyzer{
+	Name:     "findassign",
+	Requires: []*Analyzer{count},
+	Run: func(pass *Pass) (interface{}, error) {
+		n := pass.ResultOf[count].(int)
+		ast.Inspect(pass.Prog, func(node ast.Node) bool {
+			if a, ok := node.(*ast.Assignment); ok {
+				pass.Report(Diagnostic{
+					Pos:     a.Pos,
+					Message: "assignment to " + string(a.Target) + " of " + string(rune('0'+n)),
+					SuggestedFixes: []SuggestedFix{{
+						Message: "remove it",
+						Edits:   []Edit{{Old: a, New: &ast.Skip{}}},
+					}},
+				})
+			}
+			return node != nil
+		})
+		return nil, nil
+	},
+}
+
+func TestRun(t *testing.T) {
+	prog := parser.MustParse(`int main() { int a, b; a = 1; if (a < 2) { b = a; } }`)
+	diags, err := Run(prog, []*Analyzer{findAssign})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(diags) != 2 {
+		t.Fatalf("diagnostics %v", diags)
+	}
+	if diags[0].Category != "findassign" || diags[0].Message != "assignment to a of 2" ||
+		diags[1].Message != "assignment to b of 2" {
+		t.Errorf("diagnostics %v", diags)
+	}
+
+	var buf bytes.Buffer
+	Fprint(&buf, diags[:1])
+	if want := "[findassign] assignment to a of 2\n\tfix: remove it\n\t\ta = 1; => ;\n"; !strings.HasSuffix(buf.String(), want) {
+		t.Errorf("printed %q, want suffix %q", buf.String(), want)
+	}
+
+	var fixes []SuggestedFix
+	for _, d := range diags {
+		fixes = append(fixes, d.SuggestedFixes...)
+	}
+	if n := ApplyFixes(prog, fixes); n != 2 {
+		t.Errorf("applied %d edits", n)
+	}
+	if got := print.String(prog.Body[1]); got != "if (a < 2) {\n    ;\n}" {
+		t.Errorf("after fixes: %q", got)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	a := &Analyzer{Name: "a", Run: count.Run}
+	b := &Analyzer{Name: "b", Run: count.Run, Requires: []*Analyzer{a}}
+	a.Requires = []*Analyzer{b}
+	if err := Validate([]*Analyzer{a}); err == nil {
+		t.Error("cycle not detected")
+	}
+	dup := &Analyzer{Name: "count", Run: count.Run}
+	if err := Validate([]*Analyzer{findAssign, dup}); err == nil {
+		t.Error("duplicate name not detected")
+	}
+	if err := Validate([]*Analyzer{findAssign, count}); err != nil {
+		t.Error(err)
+	}
+}
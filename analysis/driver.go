@@ -0,0 +1,220 @@
+package analysis
+
+import (
+	"fmt"
+	"io"
+	"sort"
+
+	"github.com/mentalpumkins/clite-go/ast"
+	"github.com/mentalpumkins/clite-go/print"
+	"github.com/mentalpumkins/clite-go/types"
+)
+
+// Validate reports an error if the analyzers, together with the
+// analyzers they require, have duplicate names or cyclic requirements.
+func Validate(analyzers []*Analyzer) error {
+	names := make(map[string]*Analyzer)
+	const (
+		unvisited = iota
+		visiting
+		done
+	)
+	state := make(map[*Analyzer]int)
+	var visit func(a *Analyzer) error
+	visit = func(a *Analyzer) error {
+		switch state[a] {
+		case visiting:
+			return fmt.Errorf("cycle in requirements of analyzer %s", a)
+		case done:
+			return nil
+		}
+		state[a] = visiting
+		if a.Name == "" || a.Run == nil {
+			return fmt.Errorf("analyzer %q lacks a name or run function", a.Name)
+		}
+		if prev, ok := names[a.Name]; ok && prev != a {
+			return fmt.Errorf("duplicate analyzer name %s", a.Name)
+		}
+		names[a.Name] = a
+		for _, req := range a.Requires {
+			if err := visit(req); err != nil {
+				return err
+			}
+		}
+		state[a] = done
+		return nil
+	}
+	for _, a := range analyzers {
+		if err := visit(a); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Run applies the analyzers to prog, running each required analyzer
+// once before the analyzers depending on it. It returns the
+// diagnostics of the given analyzers, sorted by position; diagnostics
+// of analyzers only run as requirements are dropped.
+func Run(prog *ast.Program, analyzers []*Analyzer) ([]Diagnostic, error) {
+	if err := Validate(analyzers); err != nil {
+		return nil, err
+	}
+	tm, err := types.Typing(prog)
+	if err != nil {
+		return nil, err
+	}
+
+	root := make(map[*Analyzer]bool)
+	for _, a := range analyzers {
+		root[a] = true
+	}
+	results := make(map[*Analyzer]interface{})
+	var diags []Diagnostic
+	var run func(a *Analyzer) error
+	run = func(a *Analyzer) error {
+		if _, ok := results[a]; ok {
+			return nil
+		}
+		pass := &Pass{
+			Analyzer: a,
+			Prog:     prog,
+			Types:    tm,
+			ResultOf: make(map[*Analyzer]interface{}),
+			Report: func(d Diagnostic) {
+				if root[a] {
+					d.Category = a.Name
+					diags = append(diags, d)
+				}
+			},
+		}
+		for _, req := range a.Requires {
+			if err := run(req); err != nil {
+				return err
+			}
+			pass.ResultOf[req] = results[req]
+		}
+		res, err := a.Run(pass)
+		if err != nil {
+			return fmt.Errorf("%s: %v", a.Name, err)
+		}
+		results[a] = res
+		return nil
+	}
+	for _, a := range analyzers {
+		if err := run(a); err != nil {
+			return nil, err
+		}
+	}
+	sort.SliceStable(diags, func(i, j int) bool {
+		return diags[i].Pos.Offset < diags[j].Pos.Offset
+	})
+	return diags, nil
+}
+
+// Fprint writes the diagnostics to w, one per line, each followed by
+// the description of its suggested fixes.
+func Fprint(w io.Writer, diags []Diagnostic) {
+	for _, d := range diags {
+		fmt.Fprintln(w, d)
+		for _, fix := range d.SuggestedFixes {
+			fmt.Fprintf(w, "\tfix: %s\n", fix.Message)
+			for _, e := range fix.Edits {
+				fmt.Fprintf(w, "\t\t%s => %s\n", print.String(e.Old), print.String(e.New))
+			}
+		}
+	}
+}
+
+// ApplyFixes rewrites prog in place according to the edits of fixes.
+// It returns the number of edits whose Old node was found.
+func ApplyFixes(prog *ast.Program, fixes []SuggestedFix) int {
+	repl := make(map[ast.Node]ast.Node)
+	for _, fix := range fixes {
+		for _, e := range fix.Edits {
+			repl[e.Old] = e.New
+		}
+	}
+	r := rewriter{repl: repl}
+	for i, s := range prog.Body {
+		prog.Body[i] = r.stmt(s)
+	}
+	return r.count
+}
+
+type rewriter struct {
+	repl  map[ast.Node]ast.Node
+	count int
+}
+
+// lookup returns the replacement of n, if any. Only pointer nodes
+// are looked up as other nodes compare equal by value.
+func (r *rewriter) lookup(n ast.Node) (ast.Node, bool) {
+	switch n.(type) {
+	case *ast.Binary, *ast.Unary, *ast.Call, *ast.Index, *ast.Ternary, *ast.Selector, ast.Stmt:
+		if m, ok := r.repl[n]; ok {
+			r.count++
+			return m, true
+		}
+	}
+	return nil, false
+}
+
+func (r *rewriter) stmt(s ast.Stmt) ast.Stmt {
+	if s == nil {
+		return nil
+	}
+	if m, ok := r.lookup(s); ok {
+		return m.(ast.Stmt)
+	}
+	switch n := s.(type) {
+	case *ast.Block:
+		for i, m := range n.Members {
+			n.Members[i] = r.stmt(m)
+		}
+	case *ast.Conditional:
+		n.Test = r.expr(n.Test)
+		n.Body = r.stmt(n.Body)
+		n.Else = r.stmt(n.Else)
+	case *ast.Loop:
+		n.Test = r.expr(n.Test)
+		n.Body = r.stmt(n.Body)
+	case *ast.Switch:
+		n.Tag = r.expr(n.Tag)
+		for _, c := range n.Cases {
+			for i, m := range c.Body {
+				c.Body[i] = r.stmt(m)
+			}
+		}
+	case *ast.Assignment:
+		n.Source = r.expr(n.Source)
+	}
+	return s
+}
+
+func (r *rewriter) expr(e ast.Expr) ast.Expr {
+	if m, ok := r.lookup(e); ok {
+		return m.(ast.Expr)
+	}
+	switch n := e.(type) {
+	case *ast.Binary:
+		n.Term1 = r.expr(n.Term1)
+		n.Term2 = r.expr(n.Term2)
+	case *ast.Unary:
+		n.Term = r.expr(n.Term)
+	case *ast.Call:
+		for i, x := range n.Args {
+			n.Args[i] = r.expr(x)
+		}
+	case *ast.Index:
+		n.X = r.expr(n.X)
+		n.Index = r.expr(n.Index)
+	case *ast.Ternary:
+		n.Test = r.expr(n.Test)
+		n.Then = r.expr(n.Then)
+		n.Else = r.expr(n.Else)
+	case *ast.Selector:
+		n.X = r.expr(n.X)
+	}
+	return e
+}
@@ -0,0 +1,224 @@
+// Package passes provides a set of analyzers for common mistakes in
+// clite programs.
+package passes
+
+import (
+	"github.com/mentalpumkins/clite-go/analysis"
+	"github.com/mentalpumkins/clite-go/ast"
+	"github.com/mentalpumkins/clite-go/cfg"
+	"github.com/mentalpumkins/clite-go/dataflow"
+	"github.com/mentalpumkins/clite-go/token"
+)
+
+// All lists the analyzers of this package that report diagnostics.
+var All = []*analysis.Analyzer{
+	EmptyBody,
+	BoolCompare,
+	Nesting,
+	Uninitialized,
+}
+
+// CFG builds the control-flow graph of the program; its result is a
+// *cfg.Graph. It reports nothing itself.
+var CFG = &analysis.Analyzer{
+	Name: "cfg",
+	Doc:  "build the control-flow graph of the program",
+	Run: func(pass *analysis.Pass) (interface{}, error) {
+		return cfg.New(pass.Prog), nil
+	},
+}
+
+// EmptyBody reports loops and if statements whose body does nothing.
+var EmptyBody = &analysis.Analyzer{
+	Name: "emptybody",
+	Doc:  "report loops and if statements with an empty body",
+	Run: func(pass *analysis.Pass) (interface{}, error) {
+		inspectStmts(pass.Prog, func(s ast.Stmt, depth int) {
+			switch s := s.(type) {
+			case *ast.Loop:
+				if isEmpty(s.Body) {
+					pass.Reportf(s.Pos, "empty loop body")
+				}
+			case *ast.Conditional:
+				if isEmpty(s.Body) && s.Else == nil {
+					pass.Reportf(s.Pos, "empty if body")
+				}
+			}
+		})
+		return nil, nil
+	},
+}
+
+func isEmpty(s ast.Stmt) bool {
+	switch s := s.(type) {
+	case *ast.Skip:
+		return true
+	case *ast.Block:
+		for _, m := range s.Members {
+			if !isEmpty(m) {
+				return false
+			}
+		}
+		return true
+	}
+	return false
+}
+
+// BoolCompare reports comparisons of a boolean against a literal
+// true or false, such as if (x == true), and suggests the simpler
+// equivalent expression.
+var BoolCompare = &analysis.Analyzer{
+	Name: "boolcompare",
+	Doc:  "report redundant comparisons with true or false",
+	Run: func(pass *analysis.Pass) (interface{}, error) {
+		inspectStmts(pass.Prog, func(s ast.Stmt, depth int) {
+			for _, e := range stmtExprs(s) {
+				ast.Inspect(e, func(n ast.Node) bool {
+					b, ok := n.(*ast.Binary)
+					if !ok || (b.Op != "==" && b.Op != "!=") {
+						return n != nil
+					}
+					x, lit := b.Term1, b.Term2
+					if _, ok := x.(ast.BoolVal); ok {
+						x, lit = lit, x
+					}
+					val, ok := lit.(ast.BoolVal)
+					if !ok || pass.Types.TypeOf(x) != ast.BOOL_TYPE {
+						return true
+					}
+					var repl ast.Expr = x
+					if bool(val) != (b.Op == "==") {
+						repl = &ast.Unary{Op: "!", Term: x}
+					}
+					pass.Report(analysis.Diagnostic{
+						Pos:     stmtPos(s),
+						Message: "redundant comparison with " + val.String(),
+						SuggestedFixes: []analysis.SuggestedFix{{
+							Message: "simplify the comparison",
+							Edits:   []analysis.Edit{{Old: b, New: repl}},
+						}},
+					})
+					return true
+				})
+			}
+		})
+		return nil, nil
+	},
+}
+
+// MaxNesting is the deepest nesting of if, while and switch
+// statements accepted by Nesting.
+var MaxNesting = 3
+
+// Nesting reports if, while and switch statements nested more than
+// MaxNesting deep.
+var Nesting = &analysis.Analyzer{
+	Name: "nesting",
+	Doc:  "report control statements nested too deeply",
+	Run: func(pass *analysis.Pass) (interface{}, error) {
+		inspectStmts(pass.Prog, func(s ast.Stmt, depth int) {
+			switch s.(type) {
+			case *ast.Loop, *ast.Conditional, *ast.Switch:
+				if depth > MaxNesting {
+					pass.Reportf(stmtPos(s), "nesting depth %d exceeds %d", depth, MaxNesting)
+				}
+			}
+		})
+		return nil, nil
+	},
+}
+
+// Uninitialized reports reads of variables that may not have been
+// assigned on every path leading to them.
+var Uninitialized = &analysis.Analyzer{
+	Name:     "uninitialized",
+	Doc:      "report possibly uninitialized variables",
+	Requires: []*analysis.Analyzer{CFG},
+	Run: func(pass *analysis.Pass) (interface{}, error) {
+		g := pass.ResultOf[CFG].(*cfg.Graph)
+		vars := dataflow.NewVars(pass.Prog)
+		assigned := dataflow.DefiniteAssignment(g, vars)
+		for _, b := range g.Blocks {
+			for _, n := range dataflow.Nodes(b) {
+				seen := make(map[ast.Variable]bool)
+				for _, v := range dataflow.Uses(n) {
+					if !seen[v] && vars.Index(v) >= 0 && !assigned.AssignedBefore(n, v) {
+						pass.Reportf(stmtPos(n), "possibly uninitialized variable %s", v)
+					}
+					seen[v] = true
+				}
+			}
+		}
+		return nil, nil
+	},
+}
+
+// inspectStmts calls f for each statement of prog along with the
+// number of if, while and switch statements enclosing it, itself
+// included.
+func inspectStmts(prog *ast.Program, f func(s ast.Stmt, depth int)) {
+	var visit func(s ast.Stmt, depth int)
+	visit = func(s ast.Stmt, depth int) {
+		switch n := s.(type) {
+		case nil:
+			return
+		case *ast.Block:
+			f(s, depth)
+			for _, m := range n.Members {
+				visit(m, depth)
+			}
+		case *ast.Conditional:
+			f(s, depth+1)
+			visit(n.Body, depth+1)
+			visit(n.Else, depth+1)
+		case *ast.Loop:
+			f(s, depth+1)
+			visit(n.Body, depth+1)
+		case *ast.Switch:
+			f(s, depth+1)
+			for _, c := range n.Cases {
+				for _, m := range c.Body {
+					visit(m, depth+1)
+				}
+			}
+		default:
+			f(s, depth)
+		}
+	}
+	for _, s := range prog.Body {
+		visit(s, 0)
+	}
+}
+
+// stmtExprs returns the expressions directly held by s.
+func stmtExprs(s ast.Stmt) []ast.Expr {
+	switch s := s.(type) {
+	case *ast.Assignment:
+		return []ast.Expr{s.Source}
+	case *ast.Conditional:
+		return []ast.Expr{s.Test}
+	case *ast.Loop:
+		return []ast.Expr{s.Test}
+	case *ast.Switch:
+		return []ast.Expr{s.Tag}
+	}
+	return nil
+}
+
+func stmtPos(s ast.Stmt) token.Position {
+	switch s := s.(type) {
+	case *ast.Assignment:
+		return s.Pos
+	case *ast.Conditional:
+		return s.Pos
+	case *ast.Loop:
+		return s.Pos
+	case *ast.Switch:
+		return s.Pos
+	case *ast.Block:
+		return s.Pos
+	case *ast.Skip:
+		return s.Pos
+	}
+	return token.Position{}
+}
@@ -0,0 +1,73 @@
+package passes
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/mentalpumkins/clite-go/analysis"
+	"github.com/mentalpumkins/clite-go/ast"
+	"github.com/mentalpumkins/clite-go/parser"
+	"github.com/mentalpumkins/clite-go/print"
+)
+
+const src = `int main() {
+	int i;
+	bool done;
+	done = false;
+	while (done == false) {
+		i = i + 1;
+		if (i > 10) {
+			while (true) {
+				if (i > 20) {
+					if (done != true) done = true;
+				}
+			}
+		}
+	}
+	while (i > 0) { }
+	if (done) ;
+}`
+
+func TestAll(t *testing.T) {
+	prog := parser.MustParse(src)
+	diags, err := analysis.Run(prog, All)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []struct {
+		line     int
+		category string
+		msg      string
+	}{
+		{5, "boolcompare", "redundant comparison with false"},
+		{6, "uninitialized", "possibly uninitialized variable i"},
+		{9, "nesting", "nesting depth 4 exceeds 3"},
+		{10, "boolcompare", "redundant comparison with true"},
+		{10, "nesting", "nesting depth 5 exceeds 3"},
+		{15, "emptybody", "empty loop body"},
+		{15, "uninitialized", "possibly uninitialized variable i"},
+		{16, "emptybody", "empty if body"},
+	}
+	if len(diags) != len(want) {
+		t.Fatalf("diagnostics %v", diags)
+	}
+	for i, d := range diags {
+		w := want[i]
+		if d.Pos.Line != w.line || d.Category != w.category || d.Message != w.msg {
+			t.Errorf("diagnostic %d: %s, want %d: [%s] %s", i, d, w.line, w.category, w.msg)
+		}
+	}
+
+	var fixes []analysis.SuggestedFix
+	for _, d := range diags {
+		fixes = append(fixes, d.SuggestedFixes...)
+	}
+	analysis.ApplyFixes(prog, fixes)
+	if got := print.String(prog.Body[1].(*ast.Loop).Test); got != "!done" {
+		t.Errorf("fixed loop test %q", got)
+	}
+	inner := prog.Body[1].(*ast.Loop).Body.(*ast.Block).Members[1]
+	if got := print.String(inner); !strings.Contains(got, "if (!done) done = true;") {
+		t.Errorf("fixed inner test:\n%s", got)
+	}
+}
@@ -1,24 +1,27 @@
 package ast
 
-import "github.com/mentalpumkins/clite-go/ast/operators"
+import (
+	"github.com/mentalpumkins/clite-go/ast/operators"
+	"github.com/mentalpumkins/clite-go/token"
+)
 
 type Node interface {
 	//	node()
 }
 
-// Expression = VariableRef | Value | Binary | Unary
+// Expression = VariableRef | Value | Binary | Unary | Call | Index | Ternary | Selector
 type Expr interface {
 	Node
 	exprNode()
 }
 
-// Statement = Skip | Block | Assignment | Conditional | Loop
+// Statement = Skip | Block | Assignment | Conditional | Loop | Switch
 type Stmt interface {
 	Node
 	stmtNode()
 }
 
-// Declaration = VariableDecl | ArrayDecl
+// Declaration = VariableDecl | ConstDecl | StructDecl
 type Decl interface {
 	Node
 	declNode()
@@ -47,58 +50,183 @@ type (
 		Op   operators.Operator
 		Term Expr
 	}
+	// Call = Identifier ( [ Expression { , Expression } ] )
+	//
+	// A call of a function provided by the host running the
+	// program.
+	Call struct {
+		Name string
+		Args []Expr
+	}
+	// Index = Primary [ Expression ]
+	//
+	// The char at an index of a string, counting from 0.
+	Index struct {
+		X     Expr
+		Index Expr
+	}
+	// Ternary = Expression ? Expression : Expression
+	//
+	// The value of Then if Test is true, else that of Else; only
+	// the branch chosen is evaluated.
+	Ternary struct {
+		Test Expr
+		Then Expr
+		Else Expr
+	}
+	// Selector = Primary . Identifier
+	//
+	// The field named Field of the struct X.
+	Selector struct {
+		X     Expr
+		Field string
+	}
 )
 
-func (n Variable) node() {}
-func (n *Binary) node()  {}
-func (n *Unary) node()   {}
+func (n Variable) node()  {}
+func (n *Binary) node()   {}
+func (n *Unary) node()    {}
+func (n *Call) node()     {}
+func (n *Index) node()    {}
+func (n *Ternary) node()  {}
+func (n *Selector) node() {}
 
-func (n Variable) exprNode() {}
-func (n *Binary) exprNode()  {}
-func (n *Unary) exprNode()   {}
+func (n Variable) exprNode()  {}
+func (n *Binary) exprNode()   {}
+func (n *Unary) exprNode()    {}
+func (n *Call) exprNode()     {}
+func (n *Index) exprNode()    {}
+func (n *Ternary) exprNode()  {}
+func (n *Selector) exprNode() {}
 
 // Statements
+//
+// The Pos of a statement is the position of its first token.
 type (
 	Conditional struct {
 		Test Expr
 		Body Stmt
 		Else Stmt
+		Pos  token.Position
 	}
 	Loop struct {
 		Test Expr
 		Body Stmt
+		Pos  token.Position
 	}
+	// Assignment = Lvalue ( = | += | -= | *= | /= | %= ) Expression ;
+	//            | Lvalue ( ++ | -- ) ;
+	// Lvalue = Identifier { . Identifier }
+	//
+	// The other forms are parsed into the equivalent x = y: x op= y
+	// into x = x op y, x++ into x = x + 1 and x-- into x = x - 1. Op
+	// records the form written, op, "++" or "--", and is empty for
+	// x = y. Fields, when not empty, select the field of the struct
+	// Target assigned, through nested structs: p.a.x = y has Target
+	// p and Fields a and x.
 	Assignment struct {
 		Target Variable
 		Source Expr
+		Pos    token.Position
+		Op     operators.Operator
+		Fields []string
 	}
 	Block struct {
 		Members []Stmt
+		Pos     token.Position
+	}
+	Skip struct {
+		Pos token.Position
+	}
+	// Switch = switch ( Expression ) { { Case } }
+	//
+	// The tag of a switch is an int or a char, and its cases are
+	// tried in order.
+	Switch struct {
+		Tag   Expr
+		Cases []*Case
+		Pos   token.Position
 	}
-	Skip struct{}
 )
 
+// Lvalue returns the expression assigned by n: its Target, or the
+// Selector of its Fields.
+func (n *Assignment) Lvalue() Expr {
+	var x Expr = n.Target
+	for _, f := range n.Fields {
+		x = &Selector{X: x, Field: f}
+	}
+	return x
+}
+
+// Case = ( case CaseLabel { , CaseLabel } | default ) : { Statement } [ fallthrough ; ]
+//
+// The Body of a case runs when the tag of its switch matches one of
+// its Labels or, for the default case, which has none, when no other
+// case matches. The switch is then done, unless the case ends with
+// fallthrough, in which case the Body of the next case runs.
+type Case struct {
+	Labels      []*CaseLabel
+	Body        []Stmt
+	Fallthrough bool
+	Pos         token.Position
+}
+
+// CaseLabel = Expression [ ... Expression ]
+//
+// A label matches the constant Lo, or the range from Lo to Hi,
+// inclusive, unless Hi is nil.
+type CaseLabel struct {
+	Lo, Hi Expr
+}
+
+func (n *Case) node()      {}
+func (n *CaseLabel) node() {}
+
 func (n *Conditional) node() {}
 func (n *Loop) node()        {}
 func (n *Assignment) node()  {}
 func (n *Block) node()       {}
 func (n *Skip) node()        {}
+func (n *Switch) node()      {}
 
 func (n *Conditional) stmtNode() {}
 func (n *Loop) stmtNode()        {}
 func (n *Assignment) stmtNode()  {}
 func (n *Block) stmtNode()       {}
 func (n *Skip) stmtNode()        {}
+func (n *Switch) stmtNode()      {}
 
 // Declerations
 type (
-	// Declaration = VariableDecl | ArrayDecl
+	// Declaration = VariableDecl | ConstDecl | StructDecl
 	// VariableDecl = Variable Type
 	VariableDecl struct {
 		Var Variable
 		T   Type
+		Pos token.Position
+	}
+	// ConstDecl = Variable Type Expression
+	// Value must be a constant expression, over literals and the
+	// constants declared before.
+	ConstDecl struct {
+		Var   Variable
+		T     Type
+		Value Expr
+		Pos   token.Position
+	}
+	// StructDecl = struct Identifier { { Type Identifier { , Identifier } ; } } ;
+	// Struct types are named struct Name, as in C, in the
+	// declarations after that of the type.
+	StructDecl struct {
+		T   *Struct
+		Pos token.Position
 	}
 )
 
 func (n *VariableDecl) node()     {}
 func (n *VariableDecl) declNode() {}
+func (n *ConstDecl) node()        {}
+func (n *ConstDecl) declNode()    {}
+func (n *StructDecl) node()       {}
+func (n *StructDecl) declNode()   {}
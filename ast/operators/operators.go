@@ -30,18 +30,37 @@ const (
 	MINUS              = "-"
 	TIMES              = "*"
 	DIV                = "/"
+	MOD                = "%"
+)
+
+/* Bitwise, on ints and chars */
+type BitwiseOp Operator
+
+const (
+	BITAND BitwiseOp = "&"
+	BITOR            = "|"
+	XOR              = "^"
+	SHL              = "<<"
+	SHR              = ">>"
 )
 
 /* Unary */
 const (
-	NOT Operator = "!"
-	NEG          = "-"
+	NOT   Operator = "!"
+	NEG            = "-"
+	COMPL          = "~"
 )
 
 /* Cast */
 const (
-	INT   Operator = "int"
-	FLOAT          = "float"
-	CHAR           = "char"
-	BOOL           = "bool"
+	INT    Operator = "int"
+	FLOAT           = "float"
+	CHAR            = "char"
+	BOOL            = "bool"
+	STRING          = "string"
+)
+
+/* Builtin */
+const (
+	LEN Operator = "len"
 )
@@ -13,33 +13,69 @@ type Value interface {
 	GetValue() interface{}
 }
 
-/* Value = Inval | CharVal | FloatVal | BoolVal */
+/* Value = Inval | CharVal | FloatVal | BoolVal | StringVal | StructVal */
+// A CharVal holds a code point from U+0000 to U+00FF, the Latin-1
+// characters, so that a char fits in 8 bits; char literals outside
+// that range are rejected by the lexer. A StringVal is a sequence of
+// chars, held one per byte.
 type (
-	IntVal   int
-	CharVal  rune
-	FloatVal float64
-	BoolVal  bool
+	IntVal    int
+	CharVal   rune
+	FloatVal  float64
+	BoolVal   bool
+	StringVal string
 )
 
-type Type int
+// A StructVal holds a value for each field of a struct of type T, in
+// the order of the fields. It is the value of a struct variable, which
+// no literal denotes.
+type StructVal struct {
+	T      *Struct
+	Fields []Value
+}
+
+// StringOf returns the StringVal of the characters of the UTF-8 text
+// s, and whether they all are chars.
+func StringOf(s string) (StringVal, bool) {
+	b := make([]byte, 0, len(s))
+	for _, r := range s {
+		if r > 0xFF {
+			return "", false
+		}
+		b = append(b, byte(r))
+	}
+	return StringVal(b), true
+}
+
+// A Type is the type of a value: a BasicType or a *Struct.
+type Type interface {
+	Node
+	String() string
+	typeNode()
+}
+
+// A BasicType is one of the types of the values of literals.
+type BasicType int
 
 const (
-	INT_TYPE Type = iota
+	INT_TYPE BasicType = iota
 	CHAR_TYPE
 	FLOAT_TYPE
 	BOOL_TYPE
+	STRING_TYPE
 )
 
 var typeNameLiterals = [...]string{
-	INT_TYPE:   "int",
-	CHAR_TYPE:  "char",
-	FLOAT_TYPE: "float",
-	BOOL_TYPE:  "bool",
+	INT_TYPE:    "int",
+	CHAR_TYPE:   "char",
+	FLOAT_TYPE:  "float",
+	BOOL_TYPE:   "bool",
+	STRING_TYPE: "string",
 }
 
-func (t Type) String() string {
+func (t BasicType) String() string {
 	s := ""
-	if 0 <= t && t < Type(len(typeNameLiterals)) {
+	if 0 <= t && t < BasicType(len(typeNameLiterals)) {
 		s = typeNameLiterals[t]
 	}
 	if s == "" {
@@ -48,31 +84,98 @@ func (t Type) String() string {
 	return s
 }
 
-func (n Type) node() {}
+// A Struct is a struct type, holding a value of the type of each of
+// its Fields. Each StructDecl declares a distinct struct type, even
+// when the fields of two of them are the same.
+type Struct struct {
+	Name   string
+	Fields []*Field
+}
+
+// A Field is a field of a struct.
+type Field struct {
+	Name string
+	T    Type
+}
+
+// Field returns the index in s.Fields of the field name, or -1.
+func (s *Struct) Field(name string) int {
+	for i, f := range s.Fields {
+		if f.Name == name {
+			return i
+		}
+	}
+	return -1
+}
+
+func (s *Struct) String() string { return "struct " + s.Name }
+
+func (n BasicType) node()     {}
+func (n *Struct) node()       {}
+func (n BasicType) typeNode() {}
+func (n *Struct) typeNode()   {}
 
-func (i IntVal) GetType() Type   { return INT_TYPE }
-func (c CharVal) GetType() Type  { return CHAR_TYPE }
-func (f FloatVal) GetType() Type { return FLOAT_TYPE }
-func (b BoolVal) GetType() Type  { return BOOL_TYPE }
+func (i IntVal) GetType() Type     { return INT_TYPE }
+func (c CharVal) GetType() Type    { return CHAR_TYPE }
+func (f FloatVal) GetType() Type   { return FLOAT_TYPE }
+func (b BoolVal) GetType() Type    { return BOOL_TYPE }
+func (s StringVal) GetType() Type  { return STRING_TYPE }
+func (s *StructVal) GetType() Type { return s.T }
 
-func (i IntVal) GetValue() interface{}   { return int(i) }
-func (c CharVal) GetValue() interface{}  { return rune(c) }
-func (f FloatVal) GetValue() interface{} { return float64(f) }
-func (b BoolVal) GetValue() interface{}  { return bool(b) }
+func (i IntVal) GetValue() interface{}    { return int(i) }
+func (c CharVal) GetValue() interface{}   { return rune(c) }
+func (f FloatVal) GetValue() interface{}  { return float64(f) }
+func (b BoolVal) GetValue() interface{}   { return bool(b) }
+func (s StringVal) GetValue() interface{} { return s.String() }
+
+// GetValue returns the values of the fields of s by name.
+func (s *StructVal) GetValue() interface{} {
+	m := make(map[string]interface{}, len(s.Fields))
+	for i, f := range s.T.Fields {
+		m[f.Name] = s.Fields[i].GetValue()
+	}
+	return m
+}
 
 func (i IntVal) String() string   { return fmt.Sprintf("%d", i) }
 func (c CharVal) String() string  { return fmt.Sprintf("%c", c) }
 func (f FloatVal) String() string { return fmt.Sprintf("%f", f) }
-func (b BoolVal) String() string  { return fmt.Sprintf("%s", bool(b)) }
+func (b BoolVal) String() string  { return fmt.Sprintf("%t", bool(b)) }
+
+// String returns the chars of s as UTF-8 text.
+func (s StringVal) String() string {
+	r := make([]rune, len(s))
+	for i := range r {
+		r[i] = rune(s[i])
+	}
+	return string(r)
+}
+
+// String returns the fields of s with their values, as in
+// {x: 1, y: 2.500000}.
+func (s *StructVal) String() string {
+	b := []byte("{")
+	for i, f := range s.T.Fields {
+		if i > 0 {
+			b = append(b, ", "...)
+		}
+		b = append(b, fmt.Sprintf("%s: %v", f.Name, s.Fields[i])...)
+	}
+	return string(append(b, '}'))
+}
 
 // Values are nodes
-func (n IntVal) node()   {}
-func (n CharVal) node()  {}
-func (n FloatVal) node() {}
-func (n BoolVal) node()  {}
+func (n IntVal) node()     {}
+func (n CharVal) node()    {}
+func (n FloatVal) node()   {}
+func (n BoolVal) node()    {}
+func (n StringVal) node()  {}
+func (n *StructVal) node() {}
 
 // Values are Expressions
-func (n IntVal) exprNode()   {}
-func (n CharVal) exprNode()  {}
-func (n FloatVal) exprNode() {}
-func (n BoolVal) exprNode()  {}
+func (n IntVal) exprNode()     {}
+func (n CharVal) exprNode()    {}
+func (n FloatVal) exprNode()   {}
+func (n BoolVal) exprNode()    {}
+func (n StringVal) exprNode()  {}
+func (n *StructVal) exprNode() {}
@@ -12,6 +12,7 @@ var tests = [...]struct {
 	{CharVal('q'), 'q'},
 	{BoolVal(true), true},
 	{FloatVal(3.14159), 3.14159},
+	{StringVal("caf\xe9"), "café"},
 }
 
 func TestValues(t *testing.T) {
@@ -23,3 +24,12 @@ func TestValues(t *testing.T) {
 		}
 	}
 }
+
+func TestStringOf(t *testing.T) {
+	if s, ok := StringOf("café"); !ok || s != "caf\xe9" || s.String() != "café" {
+		t.Errorf("StringOf(café) = %q, %v", string(s), ok)
+	}
+	if _, ok := StringOf("π"); ok {
+		t.Error("StringOf(π) holds a char")
+	}
+}
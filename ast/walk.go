@@ -46,6 +46,21 @@ func Walk(v Visitor, node Node) {
 	case *Loop:
 		Walk(v, n.Test)
 		Walk(v, n.Body)
+	case *Switch:
+		Walk(v, n.Tag)
+		for _, c := range n.Cases {
+			Walk(v, c)
+		}
+	case *Case:
+		for _, l := range n.Labels {
+			Walk(v, l)
+		}
+		walkStmtList(v, n.Body)
+	case *CaseLabel:
+		Walk(v, n.Lo)
+		if n.Hi != nil {
+			Walk(v, n.Hi)
+		}
 	case *Assignment:
 		Walk(v, n.Target)
 		Walk(v, n.Source)
@@ -54,11 +69,28 @@ func Walk(v Visitor, node Node) {
 		Walk(v, n.Term2)
 	case *Unary:
 		Walk(v, n.Term)
+	case *Call:
+		walkExprList(v, n.Args)
+	case *Index:
+		Walk(v, n.X)
+		Walk(v, n.Index)
+	case *Ternary:
+		Walk(v, n.Test)
+		Walk(v, n.Then)
+		Walk(v, n.Else)
+	case *Selector:
+		Walk(v, n.X)
 	case Variable, *Skip:
 		// do nothing
 	case *VariableDecl:
 		Walk(v, n.Var)
 		Walk(v, n.T)
+	case *ConstDecl:
+		Walk(v, n.Var)
+		Walk(v, n.T)
+		Walk(v, n.Value)
+	case *StructDecl:
+		Walk(v, n.T)
 	}
 }
 
@@ -0,0 +1,175 @@
+// Package cfg constructs control-flow graphs for clite programs.
+//
+// A Graph partitions the statements of an ast.Program body into basic
+// blocks. Straight-line statements (assignments and skips) are kept in
+// order within a block; a block whose Cond is non-nil ends in a two way
+// branch on that expression, Succs[0] being taken when it is true and
+// Succs[1] when it is false, unless its Stmt is an ast.Switch: Cond
+// is then the tag of the switch and Succs[i] is the block of its i-th
+// case, followed by the block after the switch when it has no default.
+// An ast.Loop gives rise to a header block holding the loop test and a
+// back edge from the end of the loop body to that header.
+package cfg
+
+import (
+	"fmt"
+
+	"github.com/mentalpumkins/clite-go/ast"
+)
+
+// A Block is a basic block of a Graph.
+type Block struct {
+	Index int        // index of the block within Graph.Blocks
+	Kind  string     // human readable role, e.g. "entry" or "loop.body"
+	Stmts []ast.Stmt // straight-line statements, executed in order
+	Cond  ast.Expr   // if non-nil the block ends by branching on Cond
+	Stmt  ast.Stmt   // the Conditional, Loop or Switch that owns Cond, if any
+	Succs []*Block
+	Preds []*Block
+}
+
+func (b *Block) String() string {
+	return fmt.Sprintf("b%d (%s)", b.Index, b.Kind)
+}
+
+// A Graph is the control-flow graph of a program body. Entry is
+// always Blocks[0]; Exit is an empty block reached when the program
+// body completes.
+type Graph struct {
+	Blocks []*Block
+	Entry  *Block
+	Exit   *Block
+}
+
+// New builds the control-flow graph of the body of prog.
+func New(prog *ast.Program) *Graph {
+	b := builder{g: new(Graph)}
+	b.g.Entry = b.newBlock("entry")
+	b.cur = b.g.Entry
+	b.stmtList(prog.Body)
+	b.g.Exit = b.newBlock("exit")
+	addEdge(b.cur, b.g.Exit)
+	return b.g
+}
+
+// BackEdges returns the edges of g whose target dominates their
+// source, as pairs of {from, to}. In a graph built by New these are
+// exactly the edges from the end of a loop body to its header.
+func (g *Graph) BackEdges() [][2]*Block {
+	dom := g.Dominators()
+	var edges [][2]*Block
+	for _, b := range g.Blocks {
+		for _, s := range b.Succs {
+			if dom.Dominates(s, b) {
+				edges = append(edges, [2]*Block{b, s})
+			}
+		}
+	}
+	return edges
+}
+
+type builder struct {
+	g   *Graph
+	cur *Block
+}
+
+func (b *builder) newBlock(kind string) *Block {
+	blk := &Block{Index: len(b.g.Blocks), Kind: kind}
+	b.g.Blocks = append(b.g.Blocks, blk)
+	return blk
+}
+
+func addEdge(from, to *Block) {
+	from.Succs = append(from.Succs, to)
+	to.Preds = append(to.Preds, from)
+}
+
+func (b *builder) stmtList(list []ast.Stmt) {
+	for _, s := range list {
+		b.stmt(s)
+	}
+}
+
+func (b *builder) stmt(s ast.Stmt) {
+	switch s := s.(type) {
+	case nil:
+		// nothing to do
+	case *ast.Block:
+		b.stmtList(s.Members)
+	case *ast.Conditional:
+		cond := b.cur
+		cond.Cond, cond.Stmt = s.Test, s
+
+		then := b.newBlock("if.then")
+		addEdge(cond, then)
+		b.cur = then
+		b.stmt(s.Body)
+		thenEnd := b.cur
+
+		var elseEnd *Block
+		if s.Else != nil {
+			els := b.newBlock("if.else")
+			addEdge(cond, els)
+			b.cur = els
+			b.stmt(s.Else)
+			elseEnd = b.cur
+		}
+
+		done := b.newBlock("if.done")
+		addEdge(thenEnd, done)
+		if elseEnd != nil {
+			addEdge(elseEnd, done)
+		} else {
+			addEdge(cond, done)
+		}
+		b.cur = done
+	case *ast.Loop:
+		head := b.newBlock("loop.head")
+		addEdge(b.cur, head)
+		head.Cond, head.Stmt = s.Test, s
+
+		body := b.newBlock("loop.body")
+		addEdge(head, body)
+		b.cur = body
+		b.stmt(s.Body)
+		addEdge(b.cur, head) // back edge
+
+		done := b.newBlock("loop.done")
+		addEdge(head, done)
+		b.cur = done
+	case *ast.Switch:
+		tag := b.cur
+		tag.Cond, tag.Stmt = s.Tag, s
+
+		bodies := make([]*Block, len(s.Cases))
+		hasDefault := false
+		for i, c := range s.Cases {
+			bodies[i] = b.newBlock("switch.case")
+			addEdge(tag, bodies[i])
+			hasDefault = hasDefault || len(c.Labels) == 0
+		}
+		var ends []*Block
+		for i, c := range s.Cases {
+			b.cur = bodies[i]
+			b.stmtList(c.Body)
+			if c.Fallthrough {
+				addEdge(b.cur, bodies[i+1])
+			} else {
+				ends = append(ends, b.cur)
+			}
+		}
+
+		done := b.newBlock("switch.done")
+		for _, end := range ends {
+			addEdge(end, done)
+		}
+		if !hasDefault {
+			addEdge(tag, done)
+		}
+		b.cur = done
+	default:
+		// assignments, skips and anything else without
+		// control flow of its own
+		b.cur.Stmts = append(b.cur.Stmts, s)
+	}
+}
@@ -0,0 +1,155 @@
+package cfg
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/mentalpumkins/clite-go/ast"
+	"github.com/mentalpumkins/clite-go/parser"
+)
+
+const loopSrc = `int main() {
+	int i, s;
+	i = 0;
+	s = 0;
+	while (i < 10) {
+		if (s > 5) s = s - 1; else s = s + i;
+		i = i + 1;
+	}
+	s = s * 2;
+}`
+
+func TestBlocks(t *testing.T) {
+	g := New(parser.MustParse(loopSrc))
+	var kinds []string
+	for _, b := range g.Blocks {
+		kinds = append(kinds, b.Kind)
+	}
+	want := "entry loop.head loop.body if.then if.else if.done loop.done exit"
+	if got := strings.Join(kinds, " "); got != want {
+		t.Fatalf("blocks %q, want %q", got, want)
+	}
+	if g.Entry != g.Blocks[0] || g.Exit != g.Blocks[len(g.Blocks)-1] {
+		t.Error("entry or exit misplaced")
+	}
+	if len(g.Entry.Stmts) != 2 {
+		t.Errorf("entry has %d statements, want 2", len(g.Entry.Stmts))
+	}
+
+	head := g.Blocks[1]
+	if head.Cond == nil || len(head.Succs) != 2 || len(head.Preds) != 2 {
+		t.Fatalf("bad loop header %v: succs %v preds %v", head, head.Succs, head.Preds)
+	}
+	if head.Succs[0].Kind != "loop.body" || head.Succs[1].Kind != "loop.done" {
+		t.Errorf("loop header branches to %v", head.Succs)
+	}
+	if _, ok := head.Stmt.(*ast.Loop); !ok {
+		t.Errorf("loop header owned by %T", head.Stmt)
+	}
+
+	back := g.BackEdges()
+	if len(back) != 1 || back[0][0].Kind != "if.done" || back[0][1] != head {
+		t.Errorf("back edges %v", back)
+	}
+}
+
+func TestIfWithoutElse(t *testing.T) {
+	g := New(parser.MustParse(`int main() { int a; if (a < 1) a = 2; a = 3; }`))
+	entry := g.Entry
+	if len(entry.Succs) != 2 {
+		t.Fatalf("entry succs %v", entry.Succs)
+	}
+	then, done := entry.Succs[0], entry.Succs[1]
+	if then.Kind != "if.then" || done.Kind != "if.done" {
+		t.Errorf("branches %v", entry.Succs)
+	}
+	if len(done.Preds) != 2 || len(done.Stmts) != 1 {
+		t.Errorf("join block %v preds %v stmts %d", done, done.Preds, len(done.Stmts))
+	}
+}
+
+func TestSwitch(t *testing.T) {
+	g := New(parser.MustParse(`int main() {
+	int a;
+	switch (a) {
+	case 1: a = 2; fallthrough;
+	case 2 ... 4: a = 3;
+	}
+	a = 4;
+}`))
+	entry := g.Entry
+	if _, ok := entry.Stmt.(*ast.Switch); !ok || entry.Cond != ast.Expr(ast.Variable("a")) {
+		t.Fatalf("entry branches on %v owned by %T", entry.Cond, entry.Stmt)
+	}
+	if len(entry.Succs) != 3 {
+		t.Fatalf("entry succs %v", entry.Succs)
+	}
+	one, two, done := entry.Succs[0], entry.Succs[1], entry.Succs[2]
+	if one.Kind != "switch.case" || two.Kind != "switch.case" || done.Kind != "switch.done" {
+		t.Errorf("branches %v", entry.Succs)
+	}
+	// the first case falls through into the second
+	if len(one.Succs) != 1 || one.Succs[0] != two || len(two.Preds) != 2 {
+		t.Errorf("case %v succs %v, case %v preds %v", one, one.Succs, two, two.Preds)
+	}
+	if len(done.Preds) != 2 || len(done.Stmts) != 1 {
+		t.Errorf("join block %v preds %v stmts %d", done, done.Preds, len(done.Stmts))
+	}
+}
+
+func TestDominators(t *testing.T) {
+	g := New(parser.MustParse(loopSrc))
+	dom := g.Dominators()
+	b := g.Blocks
+	// entry, head, body, then, else, done, exit
+	tests := []struct {
+		a, b *Block
+		want bool
+	}{
+		{b[0], b[7], true},
+		{b[1], b[5], true},
+		{b[2], b[5], true},
+		{b[3], b[5], false},
+		{b[4], b[5], false},
+		{b[2], b[1], false},
+		{b[5], b[5], true},
+		{b[2], b[6], false},
+	}
+	for _, test := range tests {
+		if got := dom.Dominates(test.a, test.b); got != test.want {
+			t.Errorf("Dominates(%v, %v) = %t", test.a, test.b, got)
+		}
+	}
+	if dom.Idom(b[5]) != b[2] || dom.Idom(b[6]) != b[1] || dom.Idom(b[0]) != nil {
+		t.Errorf("idoms %v %v %v", dom.Idom(b[5]), dom.Idom(b[6]), dom.Idom(b[0]))
+	}
+
+	df := dom.Frontier(g)
+	if len(df[3]) != 1 || df[3][0] != b[5] {
+		t.Errorf("frontier of then %v", df[3])
+	}
+	if len(df[2]) != 1 || df[2][0] != b[1] {
+		t.Errorf("frontier of body %v", df[2])
+	}
+}
+
+func TestDot(t *testing.T) {
+	g := New(parser.MustParse(`int main() { int a; while (a < 3) a = a + 1; }`))
+	var buf bytes.Buffer
+	if err := g.Dot(&buf); err != nil {
+		t.Fatal(err)
+	}
+	out := buf.String()
+	for _, want := range []string{
+		`b1 [label="1: loop.head\lif a < 3\l"];`,
+		`b2 [label="2: loop.body\la = a + 1;\l"];`,
+		"b1 -> b2 [label=T];",
+		"b1 -> b3 [label=F];",
+		"b2 -> b1;",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("missing %q in\n%s", want, out)
+		}
+	}
+}
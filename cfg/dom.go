@@ -0,0 +1,150 @@
+package cfg
+
+// DomTree is the dominator tree of a Graph. A block a dominates b if
+// every path from the entry to b passes through a.
+type DomTree struct {
+	idom     []*Block
+	children [][]*Block
+	pre      []int // preorder number in the dominator tree
+	post     []int // postorder number in the dominator tree
+}
+
+// Dominators computes the dominator tree of g using the iterative
+// algorithm of Cooper, Harvey and Kennedy.
+func (g *Graph) Dominators() *DomTree {
+	n := len(g.Blocks)
+	order := g.reversePostorder()
+	rpo := make([]int, n)
+	for i := range rpo {
+		rpo[i] = -1
+	}
+	for i, b := range order {
+		rpo[b.Index] = i
+	}
+
+	idom := make([]*Block, n)
+	idom[g.Entry.Index] = g.Entry
+	intersect := func(a, b *Block) *Block {
+		for a != b {
+			for rpo[a.Index] > rpo[b.Index] {
+				a = idom[a.Index]
+			}
+			for rpo[b.Index] > rpo[a.Index] {
+				b = idom[b.Index]
+			}
+		}
+		return a
+	}
+	for changed := true; changed; {
+		changed = false
+		for _, b := range order[1:] {
+			var d *Block
+			for _, p := range b.Preds {
+				if idom[p.Index] == nil {
+					continue
+				}
+				if d == nil {
+					d = p
+				} else {
+					d = intersect(p, d)
+				}
+			}
+			if idom[b.Index] != d {
+				idom[b.Index] = d
+				changed = true
+			}
+		}
+	}
+
+	t := &DomTree{
+		idom:     make([]*Block, n),
+		children: make([][]*Block, n),
+		pre:      make([]int, n),
+		post:     make([]int, n),
+	}
+	for _, b := range order[1:] {
+		t.idom[b.Index] = idom[b.Index]
+		t.children[idom[b.Index].Index] = append(t.children[idom[b.Index].Index], b)
+	}
+	var pre, post int
+	var number func(b *Block)
+	number = func(b *Block) {
+		pre++
+		t.pre[b.Index] = pre
+		for _, c := range t.children[b.Index] {
+			number(c)
+		}
+		post++
+		t.post[b.Index] = post
+	}
+	number(g.Entry)
+	return t
+}
+
+// Idom returns the immediate dominator of b, or nil if b is the entry
+// block or is unreachable.
+func (t *DomTree) Idom(b *Block) *Block { return t.idom[b.Index] }
+
+// Children returns the blocks immediately dominated by b.
+func (t *DomTree) Children(b *Block) []*Block { return t.children[b.Index] }
+
+// Dominates reports whether a dominates b. Every block dominates
+// itself.
+func (t *DomTree) Dominates(a, b *Block) bool {
+	if t.pre[a.Index] == 0 || t.pre[b.Index] == 0 {
+		// unreachable
+		return a == b
+	}
+	return t.pre[a.Index] <= t.pre[b.Index] && t.post[b.Index] <= t.post[a.Index]
+}
+
+// Frontier returns the dominance frontier of every block of g, indexed
+// by Block.Index: the blocks where the dominance of that block ends.
+func (t *DomTree) Frontier(g *Graph) [][]*Block {
+	df := make([][]*Block, len(g.Blocks))
+	for _, b := range g.Blocks {
+		if len(b.Preds) < 2 || t.pre[b.Index] == 0 {
+			continue
+		}
+		for _, p := range b.Preds {
+			for r := p; r != nil && r != t.idom[b.Index]; r = t.idom[r.Index] {
+				if t.pre[r.Index] == 0 || contains(df[r.Index], b) {
+					break
+				}
+				df[r.Index] = append(df[r.Index], b)
+			}
+		}
+	}
+	return df
+}
+
+func contains(list []*Block, b *Block) bool {
+	for _, x := range list {
+		if x == b {
+			return true
+		}
+	}
+	return false
+}
+
+// reversePostorder returns the blocks reachable from the entry in
+// reverse postorder of a depth-first search.
+func (g *Graph) reversePostorder() []*Block {
+	seen := make([]bool, len(g.Blocks))
+	var post []*Block
+	var visit func(b *Block)
+	visit = func(b *Block) {
+		seen[b.Index] = true
+		for _, s := range b.Succs {
+			if !seen[s.Index] {
+				visit(s)
+			}
+		}
+		post = append(post, b)
+	}
+	visit(g.Entry)
+	for i, j := 0, len(post)-1; i < j; i, j = i+1, j-1 {
+		post[i], post[j] = post[j], post[i]
+	}
+	return post
+}
@@ -0,0 +1,57 @@
+package cfg
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/mentalpumkins/clite-go/ast"
+	"github.com/mentalpumkins/clite-go/print"
+)
+
+// Dot writes g to w in the graphviz DOT language. Each block is
+// labelled with its statements and branch condition; the edges out of
+// a conditional block are labelled T and F, and those out of a switch
+// with the index of their case, or default.
+func (g *Graph) Dot(w io.Writer) error {
+	bw := bufio.NewWriter(w)
+	fmt.Fprintf(bw, "digraph cfg {\n")
+	fmt.Fprintf(bw, "\tnode [shape=box fontname=monospace];\n")
+	for _, b := range g.Blocks {
+		var label strings.Builder
+		fmt.Fprintf(&label, "%d: %s\\l", b.Index, b.Kind)
+		for _, s := range b.Stmts {
+			fmt.Fprintf(&label, "%s\\l", escape(print.String(s)))
+		}
+		if _, ok := b.Stmt.(*ast.Switch); ok {
+			fmt.Fprintf(&label, "switch %s\\l", escape(print.String(b.Cond)))
+		} else if b.Cond != nil {
+			fmt.Fprintf(&label, "if %s\\l", escape(print.String(b.Cond)))
+		}
+		fmt.Fprintf(bw, "\tb%d [label=\"%s\"];\n", b.Index, label.String())
+	}
+	for _, b := range g.Blocks {
+		for i, s := range b.Succs {
+			attr := ""
+			if sw, ok := b.Stmt.(*ast.Switch); ok {
+				attr = " [label=default]"
+				if i < len(sw.Cases) && len(sw.Cases[i].Labels) > 0 {
+					attr = fmt.Sprintf(" [label=%d]", i)
+				}
+			} else if b.Cond != nil {
+				attr = " [label=T]"
+				if i == 1 {
+					attr = " [label=F]"
+				}
+			}
+			fmt.Fprintf(bw, "\tb%d -> b%d%s;\n", b.Index, s.Index, attr)
+		}
+	}
+	fmt.Fprintf(bw, "}\n")
+	return bw.Flush()
+}
+
+func escape(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\l`).Replace(s)
+}
@@ -0,0 +1,258 @@
+// Package clite runs clite programs from Go programs.
+//
+// Run parses, type checks and runs a program in one call. The host
+// may give the variables of the program initial values and reads their
+// final values from the result:
+//
+//	vals, err := clite.Run(src, &clite.Options{
+//		Inputs: map[string]interface{}{"n": 10},
+//	})
+//
+// The packages under this one implement the parts of the language:
+// lexer and parser read programs, types checks them and interp runs
+// them.
+package clite
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"math"
+	"reflect"
+	"strings"
+
+	"github.com/mentalpumkins/clite-go/ast"
+	"github.com/mentalpumkins/clite-go/eval"
+	"github.com/mentalpumkins/clite-go/interp"
+	"github.com/mentalpumkins/clite-go/parser"
+	"github.com/mentalpumkins/clite-go/types"
+)
+
+// Options control Run. A nil *Options is the same as the zero Options.
+type Options struct {
+	// Context, when not nil, stops the program when it is done.
+	Context context.Context
+	// Inputs gives initial values to variables of the program, by
+	// name, as converted by Value to the types of the variables.
+	// The other variables are initially zero.
+	Inputs map[string]interface{}
+	// MaxSteps and MaxMemory limit the run as those of
+	// interp.Options do, when positive.
+	MaxSteps, MaxMemory int64
+	// Builtins are the functions provided by the host that the
+	// program may call. They are used by this run only.
+	Builtins interp.Builtins
+	// Trace, when not nil, receives the trace of the run as JSON
+	// Lines, as described by interp.Event.
+	Trace io.Writer
+}
+
+// A TypeError lists the type errors of a program.
+type TypeError []string
+
+func (e TypeError) Error() string {
+	return "clite: type errors: " + strings.Join(e, "; ")
+}
+
+// Run parses, type checks and runs the clite program src and returns
+// the final values of its variables by name. A syntax error is
+// returned as a *parser.Error, type errors as a TypeError, and errors
+// stopping the program as an *interp.Error or an *interp.LimitError.
+func Run(src []byte, opts *Options) (map[string]ast.Value, error) {
+	if opts == nil {
+		opts = new(Options)
+	}
+	prog, err := parser.Parse(src)
+	if err != nil {
+		return nil, err
+	}
+	if err := check(prog, opts.Builtins); err != nil {
+		return nil, err
+	}
+	p, err := interp.CompileBuiltins(prog, opts.Builtins)
+	if err != nil {
+		return nil, err
+	}
+
+	iopts := interp.Options{
+		Inputs:    make(map[ast.Variable]ast.Value),
+		MaxSteps:  opts.MaxSteps,
+		MaxMemory: opts.MaxMemory,
+		Trace:     opts.Trace,
+	}
+	for name, x := range opts.Inputs {
+		t, ok := typeOf(p, name)
+		if !ok {
+			return nil, fmt.Errorf("clite: input %s is not a variable of the program", name)
+		}
+		v, err := Value(x, t)
+		if err != nil {
+			return nil, fmt.Errorf("clite: input %s: %v", name, err)
+		}
+		iopts.Inputs[ast.Variable(name)] = v
+	}
+	ctx := opts.Context
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	vals, err := p.RunContext(ctx, iopts)
+	if err != nil {
+		return nil, err
+	}
+	state := make(map[string]ast.Value, len(vals))
+	for i, v := range p.Vars() {
+		state[string(v.Name)] = vals[i]
+	}
+	return state, nil
+}
+
+// check type checks prog, which may call the builtins b, collecting
+// the errors instead of printing them.
+func check(prog *ast.Program, b interp.Builtins) error {
+	funcs := make(types.Funcs)
+	for name, f := range b {
+		funcs[name] = f.Signature
+	}
+	tc := new(types.TypeChecker)
+	if err := tc.InitFuncs(prog, funcs); err != nil {
+		return err
+	}
+	var errs TypeError
+	tc.SetErrorHandler(func(format string, args ...interface{}) {
+		errs = append(errs, strings.TrimSpace(fmt.Sprintf(format, args...)))
+	})
+	ast.Walk(tc, prog)
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+func typeOf(p *interp.Program, name string) (ast.Type, bool) {
+	for _, v := range p.Vars() {
+		if string(v.Name) == name {
+			return v.Type, true
+		}
+	}
+	return nil, false
+}
+
+// Value converts the Go value x to a value of type t. x may be an
+// ast.Value of type t, or a Go value of the matching kind: an integer
+// in the range of int for int, an integer in [0, 255] for char, a
+// float or an integer for float, a bool for bool, a string of
+// characters in the range of char, as UTF-8, for string and, for a
+// struct, a map[string]interface{} of values so converted to the types
+// of its fields, by name, the fields missing being zero.
+func Value(x interface{}, t ast.Type) (ast.Value, error) {
+	if v, ok := x.(ast.Value); ok {
+		if err := validValue(v); err != nil {
+			return nil, err
+		}
+		if v.GetType() != t {
+			return nil, fmt.Errorf("cannot use %s value as %s", v.GetType(), t)
+		}
+		return v, nil
+	}
+	if st, ok := t.(*ast.Struct); ok {
+		return structValue(x, st)
+	}
+
+	rv := reflect.ValueOf(x)
+	var (
+		i     int64
+		isInt = true
+	)
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		i = rv.Int()
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		u := rv.Uint()
+		i = int64(u)
+		if u > math.MaxInt64 {
+			i = math.MaxInt64 // out of range of all types
+		}
+	default:
+		isInt = false
+	}
+	switch {
+	case t == ast.INT_TYPE && isInt:
+		if i < math.MinInt32 || i > math.MaxInt32 {
+			return nil, fmt.Errorf("%v out of range of int", x)
+		}
+		return ast.IntVal(i), nil
+	case t == ast.CHAR_TYPE && isInt:
+		if i < 0 || i > math.MaxUint8 {
+			return nil, fmt.Errorf("%v out of range of char", x)
+		}
+		return ast.CharVal(i), nil
+	case t == ast.FLOAT_TYPE && isInt:
+		return ast.FloatVal(i), nil
+	case t == ast.FLOAT_TYPE && (rv.Kind() == reflect.Float32 || rv.Kind() == reflect.Float64):
+		return ast.FloatVal(rv.Float()), nil
+	case t == ast.BOOL_TYPE && rv.Kind() == reflect.Bool:
+		return ast.BoolVal(rv.Bool()), nil
+	case t == ast.STRING_TYPE && rv.Kind() == reflect.String:
+		s, ok := ast.StringOf(rv.String())
+		if !ok {
+			return nil, fmt.Errorf("%q has characters out of range of char", x)
+		}
+		return s, nil
+	}
+	return nil, fmt.Errorf("cannot use %T as %s", x, t)
+}
+
+// validValue reports an error if the ast.Value v is out of the range
+// of its type or, for a struct, does not hold a valid value of the
+// type of each of its fields.
+func validValue(v ast.Value) error {
+	switch v := v.(type) {
+	case ast.IntVal:
+		if v < math.MinInt32 || v > math.MaxInt32 {
+			return fmt.Errorf("%d out of range of int", v)
+		}
+	case ast.CharVal:
+		if v < 0 || v > math.MaxUint8 {
+			return fmt.Errorf("%d out of range of char", v)
+		}
+	case *ast.StructVal:
+		if v == nil || v.T == nil {
+			return fmt.Errorf("nil struct value")
+		}
+		if len(v.Fields) != len(v.T.Fields) {
+			return fmt.Errorf("%s value has %d fields, want %d", v.T, len(v.Fields), len(v.T.Fields))
+		}
+		for i, f := range v.T.Fields {
+			if v.Fields[i] == nil {
+				return fmt.Errorf("field %s: missing value", f.Name)
+			}
+			if err := validValue(v.Fields[i]); err != nil {
+				return fmt.Errorf("field %s: %v", f.Name, err)
+			}
+			if t := v.Fields[i].GetType(); t != f.T {
+				return fmt.Errorf("field %s: cannot use %s value as %s", f.Name, t, f.T)
+			}
+		}
+	}
+	return nil
+}
+
+func structValue(x interface{}, t *ast.Struct) (ast.Value, error) {
+	m, ok := x.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("cannot use %T as %s", x, t)
+	}
+	v := eval.Zero(t).(*ast.StructVal)
+	for name, y := range m {
+		i := t.Field(name)
+		if i < 0 {
+			return nil, fmt.Errorf("%s has no field %s", t, name)
+		}
+		f, err := Value(y, t.Fields[i].T)
+		if err != nil {
+			return nil, fmt.Errorf("field %s: %v", name, err)
+		}
+		v.Fields[i] = f
+	}
+	return v, nil
+}
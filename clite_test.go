@@ -0,0 +1,274 @@
+package clite
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/mentalpumkins/clite-go/ast"
+	"github.com/mentalpumkins/clite-go/eval"
+	"github.com/mentalpumkins/clite-go/interp"
+	"github.com/mentalpumkins/clite-go/parser"
+	"github.com/mentalpumkins/clite-go/types"
+)
+
+const fib = `int main() {
+	int n, a, b, t;
+	char c;
+	float x;
+	bool big;
+	b = 1;
+	while (n > 0) {
+		t = a + b;
+		a = b;
+		b = t;
+		n = n - 1;
+	}
+	big = a > 1000;
+	x = x * float(a);
+	c = char(int(c) + 1);
+}`
+
+func TestRun(t *testing.T) {
+	vals, err := Run([]byte(fib), &Options{
+		Inputs: map[string]interface{}{
+			"n":   uint8(20),
+			"x":   1.5,
+			"c":   'a',
+			"big": ast.BoolVal(true),
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]ast.Value{
+		"n":   ast.IntVal(0),
+		"a":   ast.IntVal(6765),
+		"b":   ast.IntVal(10946),
+		"t":   ast.IntVal(10946),
+		"c":   ast.CharVal('b'),
+		"x":   ast.FloatVal(10147.5),
+		"big": ast.BoolVal(true),
+	}
+	if !reflect.DeepEqual(vals, want) {
+		t.Errorf("got %v, want %v", vals, want)
+	}
+
+	vals, err = Run([]byte(fib), nil)
+	if err != nil || vals["a"] != ast.IntVal(0) || vals["b"] != ast.IntVal(1) {
+		t.Errorf("got %v, %v without inputs", vals, err)
+	}
+}
+
+func TestErrors(t *testing.T) {
+	run := func(src string, opts *Options) error {
+		_, err := Run([]byte(src), opts)
+		return err
+	}
+	input := func(name string, x interface{}) *Options {
+		return &Options{Inputs: map[string]interface{}{name: x}}
+	}
+
+	err := run("int main() { int x; x = ; }", nil)
+	if e, ok := err.(*parser.Error); !ok || e.Pos.Line != 1 {
+		t.Errorf("got %v, want a syntax error", err)
+	}
+	err = run("int main() { int x; x = true; y = 1; }", nil)
+	if e, ok := err.(TypeError); !ok || len(e) != 2 || e[0] != "Bad typeing for x = true;" {
+		t.Errorf("got %v, want two type errors", err)
+	}
+	err = run("int main() { int x; x = 1 / x; }", nil)
+	if _, ok := err.(*interp.Error); !ok || !errors.Is(err, eval.ErrDivByZero) {
+		t.Errorf("got %v, want a division by zero", err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	err = run("int main() { while (true) ; }", &Options{Context: ctx})
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("got %v, want a deadline error", err)
+	}
+	err = run("int main() { while (true) ; }", &Options{MaxSteps: 100})
+	if !errors.Is(err, interp.ErrStepLimit) {
+		t.Errorf("got %v, want a step limit error", err)
+	}
+
+	for _, test := range []struct {
+		name string
+		x    interface{}
+		msg  string
+	}{
+		{"y", 1, "clite: input y is not a variable of the program"},
+		{"n", 1.0, "clite: input n: cannot use float64 as int"},
+		{"n", int64(1) << 31, "clite: input n: 2147483648 out of range of int"},
+		{"n", uint64(1) << 63, "clite: input n: 9223372036854775808 out of range of int"},
+		{"c", 256, "clite: input c: 256 out of range of char"},
+		{"c", "a", "clite: input c: cannot use string as char"},
+		{"big", 1, "clite: input big: cannot use int as bool"},
+		{"x", ast.IntVal(1), "clite: input x: cannot use int value as float"},
+		{"x", nil, "clite: input x: cannot use <nil> as float"},
+		{"n", ast.IntVal(1) << 31, "clite: input n: 2147483648 out of range of int"},
+		{"c", ast.CharVal(256), "clite: input c: 256 out of range of char"},
+	} {
+		err := run(fib, input(test.name, test.x))
+		if err == nil || err.Error() != test.msg {
+			t.Errorf("input %s = %#v: got error %v, want %s", test.name, test.x, err, test.msg)
+		}
+	}
+}
+
+func TestStringInput(t *testing.T) {
+	src := []byte(`int main() {
+	string s;
+	int n;
+	n = len(s);
+	s = s + "!";
+}`)
+	vals, err := Run(src, &Options{Inputs: map[string]interface{}{"s": "héllo"}})
+	if err != nil || vals["n"] != ast.IntVal(5) || vals["s"] != ast.StringVal("h\xe9llo!") {
+		t.Errorf("got %v, %v", vals, err)
+	}
+	_, err = Run(src, &Options{Inputs: map[string]interface{}{"s": "Āb"}})
+	if want := `clite: input s: "Āb" has characters out of range of char`; err == nil || err.Error() != want {
+		t.Errorf("got error %v, want %s", err, want)
+	}
+}
+
+func TestStructInput(t *testing.T) {
+	src := []byte(`int main() {
+	struct Point {
+		int x;
+		float y;
+	};
+	struct Point p;
+	float d;
+	d = p.x + p.y;
+	p.x++;
+}`)
+	p := map[string]interface{}{"x": 2, "y": 0.5}
+	vals, err := Run(src, &Options{Inputs: map[string]interface{}{"p": p}})
+	if err != nil || vals["d"] != ast.FloatVal(2.5) {
+		t.Fatalf("got %v, %v", vals, err)
+	}
+	if got, want := vals["p"].GetValue(), map[string]interface{}{"x": 3, "y": 0.5}; !reflect.DeepEqual(got, want) {
+		t.Errorf("p = %v, want %v", got, want)
+	}
+
+	for _, test := range []struct {
+		x   interface{}
+		msg string
+	}{
+		{map[string]interface{}{"z": 1}, "clite: input p: struct Point has no field z"},
+		{map[string]interface{}{"x": 1.5}, "clite: input p: field x: cannot use float64 as int"},
+		{1, "clite: input p: cannot use int as struct Point"},
+	} {
+		_, err := Run(src, &Options{Inputs: map[string]interface{}{"p": test.x}})
+		if err == nil || err.Error() != test.msg {
+			t.Errorf("input p = %v: got error %v, want %s", test.x, err, test.msg)
+		}
+	}
+
+	// struct values are checked field by field
+	pt := &ast.Struct{Name: "Point", Fields: []*ast.Field{{Name: "x", T: ast.INT_TYPE}, {Name: "c", T: ast.CHAR_TYPE}}}
+	for _, test := range []struct {
+		v   *ast.StructVal
+		msg string
+	}{
+		{&ast.StructVal{T: pt, Fields: []ast.Value{ast.IntVal(1)}}, "struct Point value has 1 fields, want 2"},
+		{&ast.StructVal{T: pt, Fields: []ast.Value{ast.IntVal(1), nil}}, "field c: missing value"},
+		{&ast.StructVal{T: pt, Fields: []ast.Value{ast.IntVal(1), ast.CharVal(-1)}}, "field c: -1 out of range of char"},
+		{&ast.StructVal{T: pt, Fields: []ast.Value{ast.IntVal(1), ast.IntVal(2)}}, "field c: cannot use int value as char"},
+	} {
+		if _, err := Value(test.v, pt); err == nil || err.Error() != test.msg {
+			t.Errorf("%v: got error %v, want %s", test.v.Fields, err, test.msg)
+		}
+	}
+	if v, err := Value(&ast.StructVal{T: pt, Fields: []ast.Value{ast.IntVal(1), ast.CharVal('a')}}, pt); err != nil {
+		t.Errorf("got %v, %v", v, err)
+	}
+}
+
+// counter returns a builtin counting its calls, with a count of its
+// own.
+func counter() interp.Builtins {
+	n := 0
+	return interp.Builtins{"next": {
+		types.Signature{Result: ast.INT_TYPE},
+		func([]ast.Value) (ast.Value, error) {
+			n++
+			return ast.IntVal(n), nil
+		},
+	}}
+}
+
+func TestBuiltins(t *testing.T) {
+	src := []byte(`int main() {
+	int i, s;
+	while (i < 1000) {
+		s = s + next();
+		i = i + 1;
+	}
+}`)
+	// concurrent runs do not share the state of their builtins
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			vals, err := Run(src, &Options{Builtins: counter()})
+			if err != nil || vals["s"] != ast.IntVal(500500) {
+				t.Errorf("got %v, %v", vals, err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	_, err := Run([]byte("int main() { int x; x = next(1); }"), &Options{Builtins: counter()})
+	if e, ok := err.(TypeError); !ok || len(e) != 1 || e[0] != "next called with 1 arguments, want 0" {
+		t.Errorf("got %v, want an arity error", err)
+	}
+	_, err = Run([]byte("int main() { int x; x = next(); }"), nil)
+	if _, ok := err.(TypeError); !ok {
+		t.Errorf("got %v, want a type error", err)
+	}
+}
+
+func ExampleRun_trace() {
+	src := `int main() {
+	int n;
+	while (n < 2) n = n + 1;
+}`
+	if _, err := Run([]byte(src), &Options{Trace: os.Stdout}); err != nil {
+		fmt.Println(err)
+	}
+	// Output:
+	// {"step":1,"kind":"loop","line":3,"column":1,"branch":true}
+	// {"step":2,"kind":"assignment","line":3,"column":15,"changes":[{"var":"n","type":"int","old":0,"new":1}]}
+	// {"step":3,"kind":"loop","line":3,"column":1,"branch":true}
+	// {"step":4,"kind":"assignment","line":3,"column":15,"changes":[{"var":"n","type":"int","old":1,"new":2}]}
+	// {"step":5,"kind":"loop","line":3,"column":1,"branch":false}
+}
+
+func ExampleRun() {
+	src := `int main() {
+	int n, f;
+	f = 1;
+	while (n > 1) {
+		f = f * n;
+		n = n - 1;
+	}
+}`
+	vals, err := Run([]byte(src), &Options{
+		Inputs: map[string]interface{}{"n": 10},
+	})
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	fmt.Println(vals["f"])
+	// Output: 3628800
+}
@@ -0,0 +1,121 @@
+// Command clite compiles clite programs.
+//
+// Usage:
+//
+//	clite build [-o output] [-S] file.cl
+//
+// build compiles the program to a native x86-64 Linux executable,
+// using the system C compiler to assemble and link it. With -S the
+// assembly is written to the output instead.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/mentalpumkins/clite-go/ast"
+	"github.com/mentalpumkins/clite-go/codegen/amd64"
+	"github.com/mentalpumkins/clite-go/lexer"
+	"github.com/mentalpumkins/clite-go/parser"
+)
+
+func usage() {
+	fmt.Fprintf(os.Stderr, "usage: clite build [-o output] [-S] file.cl\n")
+	os.Exit(2)
+}
+
+func main() {
+	if len(os.Args) < 2 {
+		usage()
+	}
+	switch os.Args[1] {
+	case "build":
+		build(os.Args[2:])
+	default:
+		usage()
+	}
+}
+
+func fatal(err error) {
+	fmt.Fprintf(os.Stderr, "clite: %v\n", err)
+	os.Exit(1)
+}
+
+func parse(file string) *ast.Program {
+	src, err := ioutil.ReadFile(file)
+	if err != nil {
+		fatal(err)
+	}
+	var l lexer.Lexer
+	l.Init(src)
+	var p parser.Parser
+	p.Init(l)
+	return p.Program()
+}
+
+func build(args []string) {
+	fs := flag.NewFlagSet("build", flag.ExitOnError)
+	out := fs.String("o", "", "write the output to `file`")
+	asm := fs.Bool("S", false, "write assembly instead of an executable")
+	fs.Parse(args)
+	if fs.NArg() != 1 {
+		usage()
+	}
+	file := fs.Arg(0)
+	prog := parse(file)
+
+	base := strings.TrimSuffix(filepath.Base(file), filepath.Ext(file))
+	if *out == "" {
+		*out = base
+		if *asm {
+			*out += ".s"
+		}
+	}
+	var err error
+	if *asm {
+		err = writeAsm(*out, prog)
+	} else {
+		err = link(*out, base, prog)
+	}
+	if err != nil {
+		fatal(err)
+	}
+}
+
+func writeAsm(file string, prog *ast.Program) error {
+	f, err := os.Create(file)
+	if err != nil {
+		return err
+	}
+	if err := amd64.Generate(f, prog); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// link assembles and links prog into the executable out using the C
+// compiler named by $CC, or cc.
+func link(out, base string, prog *ast.Program) error {
+	dir, err := ioutil.TempDir("", "clite")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(dir)
+	s := filepath.Join(dir, base+".s")
+	if err := writeAsm(s, prog); err != nil {
+		return err
+	}
+	cc := os.Getenv("CC")
+	if cc == "" {
+		cc = "cc"
+	}
+	cmd := exec.Command(cc, "-o", out, s)
+	cmd.Stdout, cmd.Stderr = os.Stdout, os.Stderr
+	return cmd.Run()
+}
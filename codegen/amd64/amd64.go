@@ -0,0 +1,517 @@
+// Package amd64 generates x86-64 assembly for clite programs.
+//
+// The output is GNU assembler text for Linux which, once assembled
+// and linked against the C library (for printf), runs the program and
+// prints its final state as described in package codegen.
+//
+// Variables live in the data section. Expressions are evaluated into
+// %eax, for int, char and bool values, or into %xmm0, for floats,
+// using the stack for intermediate results.
+package amd64
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+
+	"github.com/mentalpumkins/clite-go/ast"
+	"github.com/mentalpumkins/clite-go/codegen"
+	"github.com/mentalpumkins/clite-go/eval"
+	"github.com/mentalpumkins/clite-go/types"
+)
+
+// Generate type checks prog and writes its assembly to w.
+func Generate(w io.Writer, prog *ast.Program) error {
+	tm, consts, vars, err := codegen.Check(prog)
+	if err != nil {
+		return err
+	}
+	if codegen.UsesStrings(prog, vars) {
+		return fmt.Errorf("amd64: strings are not supported")
+	}
+	if codegen.UsesStructs(vars) {
+		return fmt.Errorf("amd64: structs are not supported")
+	}
+	g := &gen{
+		w:      bufio.NewWriter(w),
+		tm:     tm,
+		consts: consts,
+		index:  make(map[ast.Variable]int),
+	}
+	for i, v := range vars {
+		g.index[v.Name] = i
+	}
+
+	g.emit(".text")
+	g.emit(".globl main")
+	g.emit(".type main, @function")
+	g.label("main")
+	g.emit("pushq %%rbp")
+	g.emit("movq %%rsp, %%rbp")
+	for _, s := range prog.Body {
+		g.stmt(s)
+	}
+	for i, v := range vars {
+		g.dump(i, v)
+	}
+	g.emit("xorl %%eax, %%eax")
+	g.emit("popq %%rbp")
+	g.emit("ret")
+	g.emit(".size main, .-main")
+
+	g.emit(".data")
+	g.emit(".align 8")
+	for i, v := range vars {
+		g.label(".Lvar%d", i)
+		g.emit(".zero 8 # %s %s", v.Type, v.Name)
+	}
+	g.emit(".section .rodata")
+	g.emit(".align 16")
+	g.label(".Lsignmask")
+	g.emit(".quad 0x8000000000000000, 0")
+	g.label(".Linf")
+	g.emit(".quad 0x7FF0000000000000")
+	g.label(".Lneginf")
+	g.emit(".quad 0xFFF0000000000000")
+	for i, v := range vars {
+		g.label(".Lfmt%d", i)
+		g.emit(`.asciz "%s = %s\n"`, v.Name, codegen.Format(v.Type))
+		switch v.Type {
+		case ast.CHAR_TYPE:
+			g.label(".Lutf%d", i)
+			g.emit(`.asciz "%s = %%c%%c\n"`, v.Name)
+		case ast.FLOAT_TYPE:
+			g.label(".Lstr%d", i)
+			g.emit(`.asciz "%s = %%s\n"`, v.Name)
+		}
+	}
+	g.label(".Lposinfstr")
+	g.emit(`.asciz "+Inf"`)
+	g.label(".Lneginfstr")
+	g.emit(`.asciz "-Inf"`)
+	g.label(".Lnanstr")
+	g.emit(`.asciz "NaN"`)
+	g.label(".Ltrue")
+	g.emit(`.asciz "true"`)
+	g.label(".Lfalse")
+	g.emit(`.asciz "false"`)
+	g.emit(`.section .note.GNU-stack,"",@progbits`)
+
+	if g.err != nil {
+		return g.err
+	}
+	return g.w.Flush()
+}
+
+type gen struct {
+	w      *bufio.Writer
+	tm     *types.TypeMap
+	consts types.Consts
+	index  map[ast.Variable]int
+	labels int
+	err    error
+}
+
+// emit writes one instruction or directive.
+func (g *gen) emit(format string, args ...interface{}) {
+	fmt.Fprintf(g.w, "\t"+format+"\n", args...)
+}
+
+func (g *gen) label(format string, args ...interface{}) {
+	fmt.Fprintf(g.w, format+":\n", args...)
+}
+
+func (g *gen) newLabel() string {
+	g.labels++
+	return fmt.Sprintf(".L%d", g.labels)
+}
+
+func (g *gen) errorf(format string, args ...interface{}) {
+	if g.err == nil {
+		g.err = fmt.Errorf(format, args...)
+	}
+}
+
+func (g *gen) variable(v ast.Variable) string {
+	return fmt.Sprintf(".Lvar%d(%%rip)", g.index[v])
+}
+
+func (g *gen) stmt(s ast.Stmt) {
+	switch s := s.(type) {
+	case nil, *ast.Skip:
+	case *ast.Block:
+		for _, m := range s.Members {
+			g.stmt(m)
+		}
+	case *ast.Assignment:
+		t := g.tm.TypeOf(s.Target)
+		g.exprAs(s.Source, t)
+		if t == ast.FLOAT_TYPE {
+			g.emit("movsd %%xmm0, %s", g.variable(s.Target))
+		} else {
+			g.emit("movl %%eax, %s", g.variable(s.Target))
+		}
+	case *ast.Conditional:
+		els, done := g.newLabel(), g.newLabel()
+		g.expr(s.Test)
+		g.emit("testl %%eax, %%eax")
+		g.emit("je %s", els)
+		g.stmt(s.Body)
+		g.emit("jmp %s", done)
+		g.label(els)
+		g.stmt(s.Else)
+		g.label(done)
+	case *ast.Loop:
+		head, done := g.newLabel(), g.newLabel()
+		g.label(head)
+		g.expr(s.Test)
+		g.emit("testl %%eax, %%eax")
+		g.emit("je %s", done)
+		g.stmt(s.Body)
+		g.emit("jmp %s", head)
+		g.label(done)
+	case *ast.Switch:
+		g.switchStmt(s)
+	default:
+		g.errorf("amd64: unsupported statement %T", s)
+	}
+}
+
+// switchStmt compares the tag of s, in eax, with the labels of each
+// case in turn and jumps to the body of the first that matches. The
+// bodies follow one another so that a fallthrough needs no jump.
+func (g *gen) switchStmt(s *ast.Switch) {
+	g.expr(s.Tag)
+	bodies := make([]string, len(s.Cases))
+	done := g.newLabel()
+	def := done
+	for i, c := range s.Cases {
+		bodies[i] = g.newLabel()
+		if len(c.Labels) == 0 {
+			def = bodies[i]
+		}
+		for _, l := range c.Labels {
+			lo, hi, _ := eval.Label(l, g.consts.Lookup)
+			if lo == hi {
+				g.emit("cmpl $%d, %%eax", int32(lo))
+				g.emit("je %s", bodies[i])
+				continue
+			}
+			// lo <= tag <= hi if and only if tag-lo <= hi-lo unsigned
+			g.emit("movl %%eax, %%ecx")
+			g.emit("subl $%d, %%ecx", int32(lo))
+			g.emit("cmpl $%d, %%ecx", int32(hi-lo))
+			g.emit("jbe %s", bodies[i])
+		}
+	}
+	g.emit("jmp %s", def)
+	for i, c := range s.Cases {
+		g.label(bodies[i])
+		for _, m := range c.Body {
+			g.stmt(m)
+		}
+		if !c.Fallthrough {
+			g.emit("jmp %s", done)
+		}
+	}
+	g.label(done)
+}
+
+// exprAs evaluates e and converts the result to type t, as when
+// assigning an int to a float.
+func (g *gen) exprAs(e ast.Expr, t ast.Type) {
+	g.expr(e)
+	g.convert(g.tm.TypeOf(e), t)
+}
+
+// convert converts the current value from type from to type to.
+func (g *gen) convert(from, to ast.Type) {
+	switch {
+	case from == to:
+	case to == ast.FLOAT_TYPE:
+		g.emit("cvtsi2sdl %%eax, %%xmm0")
+	case from == ast.FLOAT_TYPE && to == ast.BOOL_TYPE:
+		g.emit("xorpd %%xmm1, %%xmm1")
+		g.emit("ucomisd %%xmm1, %%xmm0")
+		g.emit("setne %%al")
+		g.emit("setp %%cl")
+		g.emit("orb %%cl, %%al")
+		g.emit("movzbl %%al, %%eax")
+	case from == ast.FLOAT_TYPE:
+		g.emit("cvttsd2si %%xmm0, %%eax")
+		if to == ast.CHAR_TYPE {
+			g.emit("movzbl %%al, %%eax")
+		}
+	case to == ast.CHAR_TYPE:
+		g.emit("movzbl %%al, %%eax")
+	case to == ast.BOOL_TYPE:
+		g.emit("testl %%eax, %%eax")
+		g.emit("setne %%al")
+		g.emit("movzbl %%al, %%eax")
+	}
+}
+
+func (g *gen) pushFloat() {
+	g.emit("subq $8, %%rsp")
+	g.emit("movsd %%xmm0, (%%rsp)")
+}
+
+func (g *gen) popFloat(reg string) {
+	g.emit("movsd (%%rsp), %%%s", reg)
+	g.emit("addq $8, %%rsp")
+}
+
+var intOps = map[string]string{
+	"+": "addl",
+	"-": "subl",
+	"*": "imull",
+	"&": "andl",
+	"|": "orl",
+	"^": "xorl",
+}
+
+var floatOps = map[string]string{
+	"+": "addsd",
+	"-": "subsd",
+	"*": "mulsd",
+	"/": "divsd",
+}
+
+var intCond = map[string]string{
+	"<":  "l",
+	"<=": "le",
+	">":  "g",
+	">=": "ge",
+	"==": "e",
+	"!=": "ne",
+}
+
+func (g *gen) expr(e ast.Expr) {
+	switch e := e.(type) {
+	case ast.Variable:
+		if k, ok := g.consts[e]; ok {
+			g.expr(k)
+			return
+		}
+		if g.tm.TypeOf(e) == ast.FLOAT_TYPE {
+			g.emit("movsd %s, %%xmm0", g.variable(e))
+		} else {
+			g.emit("movl %s, %%eax", g.variable(e))
+		}
+	case ast.IntVal:
+		g.emit("movl $%d, %%eax", int32(e))
+	case ast.CharVal:
+		g.emit("movl $%d, %%eax", int32(e))
+	case ast.BoolVal:
+		if e {
+			g.emit("movl $1, %%eax")
+		} else {
+			g.emit("xorl %%eax, %%eax")
+		}
+	case ast.FloatVal:
+		g.emit("movabsq $%#x, %%rax", math.Float64bits(float64(e)))
+		g.emit("movq %%rax, %%xmm0")
+	case *ast.Binary:
+		g.binary(e)
+	case *ast.Ternary:
+		els, done := g.newLabel(), g.newLabel()
+		t := g.tm.TypeOf(e)
+		g.expr(e.Test)
+		g.emit("testl %%eax, %%eax")
+		g.emit("je %s", els)
+		g.exprAs(e.Then, t)
+		g.emit("jmp %s", done)
+		g.label(els)
+		g.exprAs(e.Else, t)
+		g.label(done)
+	case *ast.Unary:
+		from := g.tm.TypeOf(e.Term)
+		g.expr(e.Term)
+		switch e.Op {
+		case "!":
+			g.emit("xorl $1, %%eax")
+		case "-":
+			if from == ast.FLOAT_TYPE {
+				g.emit("xorpd .Lsignmask(%%rip), %%xmm0")
+			} else {
+				g.emit("negl %%eax")
+			}
+		case "~":
+			// a char is held zero extended
+			g.emit("notl %%eax")
+		default:
+			g.convert(from, g.tm.TypeOf(e))
+		}
+	default:
+		g.errorf("amd64: unsupported expression %T", e)
+	}
+}
+
+func (g *gen) binary(e *ast.Binary) {
+	op := string(e.Op)
+	switch op {
+	case "&&", "||":
+		done := g.newLabel()
+		g.expr(e.Term1)
+		g.emit("testl %%eax, %%eax")
+		if op == "&&" {
+			g.emit("je %s", done)
+		} else {
+			g.emit("jne %s", done)
+		}
+		g.expr(e.Term2)
+		g.label(done)
+		return
+	}
+
+	// operand type: the promoted type for arithmetic, the common
+	// type of both operands for comparisons
+	t := g.tm.TypeOf(e)
+	if _, isCmp := intCond[op]; isCmp {
+		t = g.tm.TypeOf(e.Term1)
+		if g.tm.TypeOf(e.Term2) == ast.FLOAT_TYPE {
+			t = ast.FLOAT_TYPE
+		}
+	}
+
+	if t == ast.FLOAT_TYPE {
+		g.exprAs(e.Term1, t)
+		g.pushFloat()
+		g.exprAs(e.Term2, t)
+		g.emit("movapd %%xmm0, %%xmm1")
+		g.popFloat("xmm0")
+		if ins, ok := floatOps[op]; ok {
+			g.emit("%s %%xmm1, %%xmm0", ins)
+			return
+		}
+		// ucomisd sets the flags as an unsigned compare of its
+		// second operand with its first; unordered results set PF
+		switch op {
+		case "<":
+			g.emit("ucomisd %%xmm0, %%xmm1")
+			g.emit("seta %%al")
+		case "<=":
+			g.emit("ucomisd %%xmm0, %%xmm1")
+			g.emit("setae %%al")
+		case ">":
+			g.emit("ucomisd %%xmm1, %%xmm0")
+			g.emit("seta %%al")
+		case ">=":
+			g.emit("ucomisd %%xmm1, %%xmm0")
+			g.emit("setae %%al")
+		case "==":
+			g.emit("ucomisd %%xmm1, %%xmm0")
+			g.emit("sete %%al")
+			g.emit("setnp %%cl")
+			g.emit("andb %%cl, %%al")
+		case "!=":
+			g.emit("ucomisd %%xmm1, %%xmm0")
+			g.emit("setne %%al")
+			g.emit("setp %%cl")
+			g.emit("orb %%cl, %%al")
+		}
+		g.emit("movzbl %%al, %%eax")
+		return
+	}
+
+	g.exprAs(e.Term1, t)
+	g.emit("pushq %%rax")
+	g.exprAs(e.Term2, t)
+	g.emit("movl %%eax, %%ecx")
+	g.emit("popq %%rax")
+	if ins, ok := intOps[op]; ok {
+		g.emit("%s %%ecx, %%eax", ins)
+	} else if op == "/" || op == "%" {
+		// idivl traps on the most negative int divided by -1, which
+		// wraps around instead: x / -1 is -x and x % -1 is 0
+		div, done := g.newLabel(), g.newLabel()
+		g.emit("cmpl $-1, %%ecx")
+		g.emit("jne %s", div)
+		if op == "/" {
+			g.emit("negl %%eax")
+		} else {
+			g.emit("xorl %%eax, %%eax")
+		}
+		g.emit("jmp %s", done)
+		g.label(div)
+		g.emit("cltd")
+		g.emit("idivl %%ecx")
+		if op == "%" {
+			g.emit("movl %%edx, %%eax")
+		}
+		g.label(done)
+	} else if op == "<<" {
+		// the count is taken modulo 32
+		g.emit("sall %%cl, %%eax")
+	} else if op == ">>" {
+		g.emit("sarl %%cl, %%eax")
+	} else if cc, ok := intCond[op]; ok {
+		g.emit("cmpl %%ecx, %%eax")
+		g.emit("set%s %%al", cc)
+		g.emit("movzbl %%al, %%eax")
+	} else {
+		g.errorf("amd64: unsupported operator %s", op)
+	}
+}
+
+// dump prints the final value of the i'th variable v.
+func (g *gen) dump(i int, v codegen.Var) {
+	switch v.Type {
+	case ast.FLOAT_TYPE:
+		// infinities and NaNs are spelled as by fmt rather than as
+		// inf or -nan
+		special, finite, done := g.newLabel(), g.newLabel(), g.newLabel()
+		g.emit("movsd .Lvar%d(%%rip), %%xmm0", i)
+		g.emit("leaq .Lnanstr(%%rip), %%rsi")
+		g.emit("ucomisd %%xmm0, %%xmm0")
+		g.emit("jp %s", special)
+		g.emit("leaq .Lposinfstr(%%rip), %%rsi")
+		g.emit("ucomisd .Linf(%%rip), %%xmm0")
+		g.emit("je %s", special)
+		g.emit("leaq .Lneginfstr(%%rip), %%rsi")
+		g.emit("ucomisd .Lneginf(%%rip), %%xmm0")
+		g.emit("jne %s", finite)
+		g.label(special)
+		g.emit("leaq .Lstr%d(%%rip), %%rdi", i)
+		g.emit("xorl %%eax, %%eax")
+		g.emit("jmp %s", done)
+		g.label(finite)
+		g.emit("leaq .Lfmt%d(%%rip), %%rdi", i)
+		g.emit("movl $1, %%eax")
+		g.label(done)
+		g.emit("call printf@PLT")
+		return
+	case ast.BOOL_TYPE:
+		g.emit("leaq .Ltrue(%%rip), %%rsi")
+		g.emit("leaq .Lfalse(%%rip), %%rdx")
+		g.emit("cmpl $0, .Lvar%d(%%rip)", i)
+		g.emit("cmove %%rdx, %%rsi")
+		g.emit("xorl %%eax, %%eax")
+	case ast.CHAR_TYPE:
+		// printed byte by byte as its UTF-8 encoding, of two bytes
+		// from 0x80 on, so that char 0 is printed too
+		ascii, done := g.newLabel(), g.newLabel()
+		g.emit("movl .Lvar%d(%%rip), %%esi", i)
+		g.emit("cmpl $0x80, %%esi")
+		g.emit("jb %s", ascii)
+		g.emit("movl %%esi, %%edx")
+		g.emit("andl $0x3F, %%edx")
+		g.emit("orl $0x80, %%edx")
+		g.emit("shrl $6, %%esi")
+		g.emit("orl $0xC0, %%esi")
+		g.emit("leaq .Lutf%d(%%rip), %%rdi", i)
+		g.emit("jmp %s", done)
+		g.label(ascii)
+		g.emit("leaq .Lfmt%d(%%rip), %%rdi", i)
+		g.label(done)
+		g.emit("xorl %%eax, %%eax")
+		g.emit("call printf@PLT")
+		return
+	default:
+		g.emit("movl .Lvar%d(%%rip), %%esi", i)
+		g.emit("xorl %%eax, %%eax")
+	}
+	g.emit("leaq .Lfmt%d(%%rip), %%rdi", i)
+	g.emit("call printf@PLT")
+}
@@ -0,0 +1,84 @@
+package amd64
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/mentalpumkins/clite-go/parser"
+)
+
+// run generates, assembles and runs the program in file, returning
+// its output.
+func run(t *testing.T, cc, dir, file string) string {
+	src, err := ioutil.ReadFile(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	if err := Generate(&buf, parser.MustParse(string(src))); err != nil {
+		t.Fatal(err)
+	}
+	s, exe := filepath.Join(dir, "prog.s"), filepath.Join(dir, "prog")
+	if err := ioutil.WriteFile(s, buf.Bytes(), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if out, err := exec.Command(cc, "-o", exe, s).CombinedOutput(); err != nil {
+		t.Fatalf("%v: %s", err, out)
+	}
+	out, err := exec.Command(exe).Output()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(out)
+}
+
+func TestGenerate(t *testing.T) {
+	src, err := ioutil.ReadFile("../testdata/mixed.cl")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	if err := Generate(&buf, parser.MustParse(string(src))); err != nil {
+		t.Fatal(err)
+	}
+	asm := buf.String()
+	for _, ins := range []string{"cvtsi2sdl", "mulsd", "xorpd .Lsignmask", "cvttsd2si", "idivl", "imull", "ucomisd", "call printf@PLT"} {
+		if !strings.Contains(asm, ins) {
+			t.Errorf("assembly lacks %s", ins)
+		}
+	}
+}
+
+func TestRun(t *testing.T) {
+	cc, err := exec.LookPath("cc")
+	if err != nil {
+		t.Skip("no C compiler to assemble the output")
+	}
+	dir, err := ioutil.TempDir("", "amd64")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	files, _ := filepath.Glob("../testdata/*.cl")
+	for _, file := range files {
+		want, err := ioutil.ReadFile(strings.TrimSuffix(file, ".cl") + ".out")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := run(t, cc, dir, file); got != string(want) {
+			t.Errorf("%s printed\n%s\nwant\n%s", file, got, want)
+		}
+	}
+}
+
+func TestTypeError(t *testing.T) {
+	prog := parser.MustParse(`int main() { int a; bool b; a = b; }`)
+	if err := Generate(ioutil.Discard, prog); err == nil {
+		t.Error("generated code for an ill typed program")
+	}
+}
@@ -0,0 +1,329 @@
+// Package c translates clite programs to C.
+//
+// The translation unit holds a single main function which declares
+// the variables of the program, runs its body and prints its final
+// state as described in package codegen. Variables are renamed with a
+// v_ prefix so that they cannot clash with C keywords or the names of
+// the C library.
+package c
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/mentalpumkins/clite-go/ast"
+	"github.com/mentalpumkins/clite-go/codegen"
+	"github.com/mentalpumkins/clite-go/eval"
+	"github.com/mentalpumkins/clite-go/types"
+)
+
+// TypeName returns the C type used for values of type t.
+func TypeName(t ast.Type) string {
+	switch t {
+	case ast.INT_TYPE:
+		return "int32_t"
+	case ast.FLOAT_TYPE:
+		return "double"
+	case ast.CHAR_TYPE:
+		return "unsigned char"
+	case ast.BOOL_TYPE:
+		return "bool"
+	}
+	return "void"
+}
+
+// Generate type checks prog and writes its translation to w.
+func Generate(w io.Writer, prog *ast.Program) error {
+	tm, consts, vars, err := codegen.Check(prog)
+	if err != nil {
+		return err
+	}
+	if codegen.UsesStrings(prog, vars) {
+		return fmt.Errorf("c: strings are not supported")
+	}
+	if codegen.UsesStructs(vars) {
+		return fmt.Errorf("c: structs are not supported")
+	}
+	g := &gen{w: bufio.NewWriter(w), tm: tm, consts: consts, indent: 1}
+
+	g.printf("#include <math.h>\n")
+	g.printf("#include <stdbool.h>\n")
+	g.printf("#include <stdint.h>\n")
+	g.printf("#include <stdio.h>\n\n")
+	// the most negative int divided by -1 wraps around rather than
+	// trapping
+	g.printf("static inline int32_t div32(int32_t x, int32_t y) {\n")
+	g.printf("\treturn y == -1 ? (int32_t)(0u - (uint32_t)x) : x / y;\n")
+	g.printf("}\n\n")
+	g.printf("static inline int32_t rem32(int32_t x, int32_t y) {\n")
+	g.printf("\treturn y == -1 ? 0 : x %% y;\n")
+	g.printf("}\n\n")
+	// NaN and floats out of the range of int convert to the most
+	// negative int, where a C cast is undefined
+	g.printf("static inline int32_t f2i(double x) {\n")
+	g.printf("\treturn x != x || x <= -2147483649.0 || x >= 2147483648.0 ? INT32_MIN : (int32_t)x;\n")
+	g.printf("}\n\n")
+	g.printf("int main(void) {\n")
+	for _, v := range vars {
+		g.line("%s v_%s = 0;", TypeName(v.Type), v.Name)
+	}
+	for _, s := range prog.Body {
+		g.stmt(s)
+	}
+	for _, v := range vars {
+		format := strconv.Quote(fmt.Sprintf("%s = %s\n", v.Name, codegen.Format(v.Type)))
+		switch v.Type {
+		case ast.BOOL_TYPE:
+			g.line(`printf(%s, v_%s ? "true" : "false");`, format, v.Name)
+		case ast.CHAR_TYPE:
+			// chars from 0x80 on take two bytes in UTF-8
+			utf8 := strconv.Quote(fmt.Sprintf("%s = %%c%%c\n", v.Name))
+			g.line("if (v_%s < 0x80) {", v.Name)
+			g.line("\tprintf(%s, v_%s);", format, v.Name)
+			g.line("} else {")
+			g.line("\tprintf(%s, 0xC0 | v_%s >> 6, 0x80 | (v_%s & 0x3F));", utf8, v.Name, v.Name)
+			g.line("}")
+		case ast.FLOAT_TYPE:
+			// spelled as by fmt rather than as inf or -nan
+			str := strconv.Quote(fmt.Sprintf("%s = %%s\n", v.Name))
+			g.line("if (isfinite(v_%s)) {", v.Name)
+			g.line("\tprintf(%s, v_%s);", format, v.Name)
+			g.line("} else {")
+			g.line("\tprintf(%s, isnan(v_%s) ? \"NaN\" : v_%s > 0 ? \"+Inf\" : \"-Inf\");", str, v.Name, v.Name)
+			g.line("}")
+		default:
+			g.line("printf(%s, v_%s);", format, v.Name)
+		}
+	}
+	g.line("return 0;")
+	g.printf("}\n")
+
+	if g.err != nil {
+		return g.err
+	}
+	return g.w.Flush()
+}
+
+type gen struct {
+	w      *bufio.Writer
+	tm     *types.TypeMap
+	consts types.Consts
+	indent int
+	err    error
+}
+
+func (g *gen) printf(format string, args ...interface{}) {
+	fmt.Fprintf(g.w, format, args...)
+}
+
+// line writes an indented line.
+func (g *gen) line(format string, args ...interface{}) {
+	for i := 0; i < g.indent; i++ {
+		g.printf("\t")
+	}
+	g.printf(format+"\n", args...)
+}
+
+func (g *gen) errorf(format string, args ...interface{}) {
+	if g.err == nil {
+		g.err = fmt.Errorf(format, args...)
+	}
+}
+
+func (g *gen) stmt(s ast.Stmt) {
+	switch s := s.(type) {
+	case nil:
+	case *ast.Skip:
+		g.line(";")
+	case *ast.Block:
+		g.line("{")
+		g.indent++
+		for _, m := range s.Members {
+			g.stmt(m)
+		}
+		g.indent--
+		g.line("}")
+	case *ast.Assignment:
+		g.line("v_%s = %s;", s.Target, g.exprAs(s.Source, g.tm.TypeOf(s.Target)))
+	case *ast.Conditional:
+		g.line("if (%s) {", g.expr(s.Test))
+		g.body(s.Body)
+		if s.Else != nil {
+			g.line("} else {")
+			g.body(s.Else)
+		}
+		g.line("}")
+	case *ast.Loop:
+		g.line("while (%s) {", g.expr(s.Test))
+		g.body(s.Body)
+		g.line("}")
+	case *ast.Switch:
+		g.line("switch (%s) {", g.expr(s.Tag))
+		for _, c := range s.Cases {
+			if len(c.Labels) == 0 {
+				g.line("default:")
+			}
+			for _, l := range c.Labels {
+				lo, hi, _ := eval.Label(l, g.consts.Lookup)
+				if lo == hi {
+					g.line("case %d:", lo)
+				} else {
+					// a case range, supported by gcc and clang
+					g.line("case %d ... %d:", lo, hi)
+				}
+			}
+			g.indent++
+			for _, m := range c.Body {
+				g.stmt(m)
+			}
+			if c.Fallthrough {
+				g.line("/* fallthrough */")
+			} else {
+				g.line("break;")
+			}
+			g.indent--
+		}
+		g.line("}")
+	default:
+		g.errorf("c: unsupported statement %T", s)
+	}
+}
+
+// body writes the statement s within braces already opened.
+func (g *gen) body(s ast.Stmt) {
+	g.indent++
+	if b, ok := s.(*ast.Block); ok {
+		for _, m := range b.Members {
+			g.stmt(m)
+		}
+	} else {
+		g.stmt(s)
+	}
+	g.indent--
+}
+
+// exprAs returns e converted to type t.
+func (g *gen) exprAs(e ast.Expr, t ast.Type) string {
+	x := g.expr(e)
+	if from := g.tm.TypeOf(e); from != t {
+		x = convert(from, t, x)
+	}
+	return x
+}
+
+// convert returns x, of type from, converted to type t.
+func convert(from, t ast.Type, x string) string {
+	if from == ast.FLOAT_TYPE && (t == ast.INT_TYPE || t == ast.CHAR_TYPE) {
+		x = "f2i(" + x + ")"
+		if t == ast.INT_TYPE {
+			return x
+		}
+	}
+	return cast(t, x)
+}
+
+func cast(t ast.Type, x string) string {
+	return fmt.Sprintf("((%s)%s)", TypeName(t), x)
+}
+
+// expr returns the C expression for e. The result is always either a
+// primary expression or parenthesized.
+func (g *gen) expr(e ast.Expr) string {
+	switch e := e.(type) {
+	case ast.Variable:
+		if k, ok := g.consts[e]; ok {
+			return g.expr(k)
+		}
+		return "v_" + string(e)
+	case ast.IntVal:
+		if int32(e) == -1<<31 {
+			return "INT32_MIN"
+		}
+		return fmt.Sprintf("%d", int32(e))
+	case ast.FloatVal:
+		f := float64(e)
+		switch {
+		case math.IsInf(f, 1):
+			return "INFINITY"
+		case math.IsInf(f, -1):
+			return "(-INFINITY)"
+		case math.IsNaN(f):
+			return "NAN"
+		}
+		s := strconv.FormatFloat(f, 'g', -1, 64)
+		if !strings.ContainsAny(s, ".e") {
+			s += ".0"
+		}
+		return s
+	case ast.CharVal:
+		return fmt.Sprintf("%d", uint8(e))
+	case ast.BoolVal:
+		return strconv.FormatBool(bool(e))
+	case *ast.Binary:
+		return g.binary(e)
+	case *ast.Ternary:
+		t := g.tm.TypeOf(e)
+		return "(" + g.expr(e.Test) + " ? " + g.exprAs(e.Then, t) + " : " + g.exprAs(e.Else, t) + ")"
+	case *ast.Unary:
+		x := g.expr(e.Term)
+		switch e.Op {
+		case "!":
+			return "(!" + x + ")"
+		case "-":
+			if g.tm.TypeOf(e.Term) == ast.FLOAT_TYPE {
+				return "(-" + x + ")"
+			}
+			return "((int32_t)(0u - (uint32_t)" + x + "))"
+		case "~":
+			return "(~" + g.exprAs(e.Term, ast.INT_TYPE) + ")"
+		}
+		return convert(g.tm.TypeOf(e.Term), g.tm.TypeOf(e), x)
+	}
+	g.errorf("c: unsupported expression %T", e)
+	return "0"
+}
+
+func (g *gen) binary(e *ast.Binary) string {
+	op := string(e.Op)
+	switch op {
+	case "&&", "||":
+		return "(" + g.expr(e.Term1) + " " + op + " " + g.expr(e.Term2) + ")"
+	}
+	t := g.tm.TypeOf(e)
+	if t == ast.BOOL_TYPE {
+		// comparison: operands have the same type, or are promoted
+		// to float
+		t = g.tm.TypeOf(e.Term1)
+		if g.tm.TypeOf(e.Term2) == ast.FLOAT_TYPE {
+			t = ast.FLOAT_TYPE
+		}
+		return "(" + g.exprAs(e.Term1, t) + " " + op + " " + g.exprAs(e.Term2, t) + ")"
+	}
+	x, y := g.exprAs(e.Term1, t), g.exprAs(e.Term2, t)
+	switch op {
+	case "<<", ">>":
+		// the count is taken modulo 32; shifting a negative int left
+		// is undefined, as unsigned shifts are not, and right is
+		// implementation defined, but arithmetic in gcc and clang
+		y = "(" + y + " & 31)"
+		if op == ">>" {
+			return "(" + x + " >> " + y + ")"
+		}
+	}
+	if t == ast.INT_TYPE && op == "/" {
+		return "div32(" + x + ", " + y + ")"
+	}
+	if t == ast.INT_TYPE && op == "%" {
+		return "rem32(" + x + ", " + y + ")"
+	}
+	if t == ast.INT_TYPE {
+		// unsigned arithmetic wraps around where signed overflow
+		// would be undefined
+		return "((int32_t)((uint32_t)" + x + " " + op + " (uint32_t)" + y + "))"
+	}
+	return "(" + x + " " + op + " " + y + ")"
+}
@@ -0,0 +1,108 @@
+package c
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/mentalpumkins/clite-go/parser"
+)
+
+func TestGenerate(t *testing.T) {
+	prog := parser.MustParse(`int main() {
+	int i;
+	float x;
+	char c;
+	i = 3;
+	x = i * 2.5;
+	c = char(i);
+	if (x > 1.0) { i = -i; } else ;
+}`)
+	var buf bytes.Buffer
+	if err := Generate(&buf, prog); err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		"int32_t v_i = 0;",
+		"double v_x = 0;",
+		"unsigned char v_c = 0;",
+		"v_x = (((double)v_i) * 2.5);",
+		"v_c = ((unsigned char)v_i);",
+		"if ((v_x > 1.0)) {",
+		"\t\tv_i = ((int32_t)(0u - (uint32_t)v_i));",
+		"} else {",
+		`printf("x = %f\n", v_x);`,
+	} {
+		if !strings.Contains(buf.String(), want) {
+			t.Errorf("output lacks %q:\n%s", want, buf.String())
+		}
+	}
+
+	// folded infinities and NaN have no C literal
+	prog = parser.MustParse(`int main() {
+	const float INF = 1.0 / 0.0, NAN = 0.0 / 0.0;
+	float a, b, c;
+	a = INF;
+	b = -INF;
+	c = NAN;
+}`)
+	buf.Reset()
+	if err := Generate(&buf, prog); err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		"#include <math.h>\n",
+		"v_a = INFINITY;",
+		"v_b = (-INFINITY);",
+		"v_c = NAN;",
+	} {
+		if !strings.Contains(buf.String(), want) {
+			t.Errorf("output lacks %q:\n%s", want, buf.String())
+		}
+	}
+}
+
+func TestRun(t *testing.T) {
+	cc, err := exec.LookPath("cc")
+	if err != nil {
+		t.Skip("no C compiler")
+	}
+	dir, err := ioutil.TempDir("", "c")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	files, _ := filepath.Glob("../testdata/*.cl")
+	for _, file := range files {
+		src, err := ioutil.ReadFile(file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		want, err := ioutil.ReadFile(strings.TrimSuffix(file, ".cl") + ".out")
+		if err != nil {
+			t.Fatal(err)
+		}
+		var buf bytes.Buffer
+		if err := Generate(&buf, parser.MustParse(string(src))); err != nil {
+			t.Fatal(err)
+		}
+		c, exe := filepath.Join(dir, "prog.c"), filepath.Join(dir, "prog")
+		if err := ioutil.WriteFile(c, buf.Bytes(), 0644); err != nil {
+			t.Fatal(err)
+		}
+		if out, err := exec.Command(cc, "-std=c99", "-Wall", "-Werror", "-o", exe, c).CombinedOutput(); err != nil {
+			t.Fatalf("%s: %v: %s", file, err, out)
+		}
+		out, err := exec.Command(exe).Output()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(out) != string(want) {
+			t.Errorf("%s printed\n%s\nwant\n%s", file, out, want)
+		}
+	}
+}
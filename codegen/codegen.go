@@ -0,0 +1,118 @@
+// Package codegen holds what the code generators in its sub packages
+// have in common.
+//
+// Every generated program runs the body of the clite program with all
+// variables initially zero and, when it completes, prints the final
+// value of each declared variable in declaration order, one per line:
+//
+//	name = value
+//
+// ints are printed in decimal, floats with six decimals as by the %f
+// verb of fmt, infinities as +Inf and -Inf and NaNs, whatever their
+// sign, as NaN, chars as the character itself encoded in UTF-8, so that
+// char(233) prints as é, bools as true or false, strings as their
+// chars, likewise encoded, and structs as their fields with their
+// values, printed likewise, as in {x: 1, y: 2.500000}. Programs
+// produced by different back ends can therefore be checked against one
+// another by comparing their output. Only the golang back end supports
+// strings and structs.
+//
+// ints are 32 bit two's complement integers whose arithmetic wraps
+// around, chars are unsigned 8 bit values, the code points U+0000 to
+// U+00FF, so that converting to char keeps the low 8 bits, converting
+// a float to int truncates it, NaN and floats out of the range of int
+// giving the most negative int, and the boolean operators && and ||
+// only evaluate their second operand when needed.
+//
+// The programs under testdata, each with the output expected from it,
+// are shared by the tests of all back ends, and those under
+// testdata/strings and testdata/structs by the tests of the back ends
+// supporting strings and structs.
+package codegen
+
+import (
+	"fmt"
+
+	"github.com/mentalpumkins/clite-go/ast"
+	"github.com/mentalpumkins/clite-go/types"
+)
+
+// A Var is a declared variable of a program.
+type Var struct {
+	Name ast.Variable
+	Type ast.Type
+}
+
+// Check type checks prog, returning its typing, the values of its
+// constants and its variables in declaration order. Constants are not
+// variables: the code generated for a program uses their values.
+func Check(prog *ast.Program) (*types.TypeMap, types.Consts, []Var, error) {
+	return CheckFuncs(prog, nil)
+}
+
+// CheckFuncs is like Check for a program that may call the functions
+// described by funcs.
+func CheckFuncs(prog *ast.Program, funcs types.Funcs) (*types.TypeMap, types.Consts, []Var, error) {
+	ok, tc, err := types.CheckFuncs(prog, funcs)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	if !ok {
+		return nil, nil, nil, fmt.Errorf("program has %d type errors", tc.ErrCount)
+	}
+	var vars []Var
+	for _, d := range prog.DecPart {
+		if d, ok := d.(*ast.VariableDecl); ok {
+			vars = append(vars, Var{d.Var, d.T})
+		}
+	}
+	return tc.TypeMap(), tc.Consts(), vars, nil
+}
+
+// Format returns the printf style verb with which a value of type t
+// is printed in the final state.
+func Format(t ast.Type) string {
+	if _, ok := t.(*ast.Struct); ok {
+		// as by the String method of ast.StructVal
+		return "%s"
+	}
+	switch t {
+	case ast.FLOAT_TYPE:
+		return "%f"
+	case ast.CHAR_TYPE:
+		return "%c"
+	case ast.BOOL_TYPE, ast.STRING_TYPE:
+		return "%s"
+	}
+	return "%d"
+}
+
+// UsesStrings reports whether prog, whose variables are vars, uses
+// strings, which not all back ends support.
+func UsesStrings(prog *ast.Program, vars []Var) bool {
+	for _, v := range vars {
+		if v.Type == ast.STRING_TYPE {
+			return true
+		}
+	}
+	uses := false
+	ast.Inspect(prog, func(n ast.Node) bool {
+		if _, ok := n.(ast.StringVal); ok {
+			uses = true
+		}
+		return !uses
+	})
+	return uses
+}
+
+// UsesStructs reports whether the program whose variables are vars
+// uses structs, which not all back ends support. No literal is a
+// struct, so only a program having struct variables does.
+func UsesStructs(vars []Var) bool {
+	for _, v := range vars {
+		if _, ok := v.Type.(*ast.Struct); ok {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,439 @@
+// Package golang translates clite programs to Go.
+//
+// The output is a formatted main package whose main function runs the
+// program and prints its final state as described in package codegen.
+// clite int, float, char, bool and string map to int32, float64,
+// uint8, bool and string, a string holding a byte per char, and the
+// promotions of int operands to float made by the type checker are
+// spelled out as explicit conversions. Variables are renamed with a v_
+// prefix so that they cannot clash with Go keywords or predeclared
+// names. Each clite struct type maps to a Go struct type of its own,
+// likewise renamed with an s_ prefix, and its fields with an f_ prefix.
+//
+// Go rejects constant expressions that overflow or divide by zero,
+// where clite wraps around or fails at run time, so constant
+// subexpressions are evaluated by package eval while generating code.
+package golang
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/mentalpumkins/clite-go/ast"
+	"github.com/mentalpumkins/clite-go/codegen"
+	"github.com/mentalpumkins/clite-go/eval"
+	"github.com/mentalpumkins/clite-go/token"
+	"github.com/mentalpumkins/clite-go/types"
+)
+
+// TypeName returns the Go type used for values of type t.
+func TypeName(t ast.Type) string {
+	switch t {
+	case ast.INT_TYPE:
+		return "int32"
+	case ast.FLOAT_TYPE:
+		return "float64"
+	case ast.CHAR_TYPE:
+		return "uint8"
+	case ast.BOOL_TYPE:
+		return "bool"
+	case ast.STRING_TYPE:
+		return "string"
+	}
+	if st, ok := t.(*ast.Struct); ok {
+		return "s_" + st.Name
+	}
+	return "struct{}"
+}
+
+// Generate type checks prog and writes its translation to w. Unless
+// filename is empty, each statement is preceded by a line directive
+// giving its position in the clite source file of that name.
+func Generate(w io.Writer, prog *ast.Program, filename string) error {
+	tm, consts, vars, err := codegen.Check(prog)
+	if err != nil {
+		return err
+	}
+	g := &gen{tm: tm, consts: consts, filename: filename}
+
+	var body bytes.Buffer
+	g.buf = &body
+	for _, s := range prog.Body {
+		g.stmt(s)
+	}
+	if g.err != nil {
+		return g.err
+	}
+
+	var out bytes.Buffer
+	g.buf = &out
+	if filename != "" {
+		g.printf("// Code generated by clite-go from %s. DO NOT EDIT.\n\n", filename)
+	} else {
+		g.printf("// Code generated by clite-go. DO NOT EDIT.\n\n")
+	}
+	g.printf("package main\n\n")
+	if g.needMath {
+		g.printf("import (\n\"fmt\"\n\"math\"\n)\n\n")
+	} else {
+		g.printf("import \"fmt\"\n\n")
+	}
+	g.printf("func main() {\n")
+	for _, d := range prog.DecPart {
+		if d, ok := d.(*ast.StructDecl); ok {
+			g.printf("type %s struct {\n", TypeName(d.T))
+			for _, f := range d.T.Fields {
+				g.printf("f_%s %s\n", f.Name, TypeName(f.T))
+			}
+			g.printf("}\n")
+		}
+	}
+	if len(vars) > 0 {
+		g.printf("var (\n")
+		for _, v := range vars {
+			g.printf("v_%s %s\n", v.Name, TypeName(v.Type))
+		}
+		g.printf(")\n")
+	}
+	out.Write(body.Bytes())
+	for _, v := range vars {
+		verb, args := g.format("v_"+string(v.Name), v.Type)
+		g.printf("fmt.Printf(%q", fmt.Sprintf("%s = %s\n", v.Name, verb))
+		for _, arg := range args {
+			g.printf(", %s", arg)
+		}
+		g.printf(")\n")
+	}
+	g.printf("}\n")
+	if g.needB2i {
+		g.printf("\nfunc b2i(b bool) int32 {\nif b {\nreturn 1\n}\nreturn 0\n}\n")
+	}
+	if g.needDiv {
+		g.printf("\nfunc div(x, y int32) int32 {\nreturn x / y\n}\n")
+	}
+	if g.needRem {
+		g.printf("\nfunc rem(x, y int32) int32 {\nreturn x %% y\n}\n")
+	}
+	if g.needAt {
+		g.printf("\nfunc at(s string, i int32) uint8 {\nreturn s[i]\n}\n")
+	}
+	if g.needUTF8 {
+		g.printf("\nfunc utf8(s string) string {\nr := make([]rune, len(s))\nfor i := range r {\nr[i] = rune(s[i])\n}\nreturn string(r)\n}\n")
+	}
+
+	src, err := format.Source(out.Bytes())
+	if err != nil {
+		return fmt.Errorf("golang: formatting output: %v", err)
+	}
+	_, err = w.Write(src)
+	return err
+}
+
+// format returns the format printing the value of the Go expression x,
+// of type t, in the final state, and the arguments it formats.
+func (g *gen) format(x string, t ast.Type) (string, []string) {
+	if st, ok := t.(*ast.Struct); ok {
+		var verbs, args []string
+		for _, f := range st.Fields {
+			verb, a := g.format(x+".f_"+f.Name, f.T)
+			verbs = append(verbs, f.Name+": "+verb)
+			args = append(args, a...)
+		}
+		return "{" + strings.Join(verbs, ", ") + "}", args
+	}
+	verb := codegen.Format(t)
+	switch t {
+	case ast.BOOL_TYPE:
+		verb = "%t"
+	case ast.STRING_TYPE:
+		g.needUTF8 = true
+		x = "utf8(" + x + ")"
+	}
+	return verb, []string{x}
+}
+
+type gen struct {
+	buf      *bytes.Buffer
+	tm       *types.TypeMap
+	consts   types.Consts
+	filename string
+	err      error
+
+	// helpers used by the generated code
+	needMath, needB2i, needDiv, needRem, needAt, needUTF8 bool
+}
+
+func (g *gen) printf(format string, args ...interface{}) {
+	fmt.Fprintf(g.buf, format, args...)
+}
+
+func (g *gen) errorf(format string, args ...interface{}) {
+	if g.err == nil {
+		g.err = fmt.Errorf(format, args...)
+	}
+}
+
+// line writes a line directive for pos.
+func (g *gen) line(pos token.Position) {
+	if g.filename != "" && pos.IsValid() {
+		g.printf("/*line %s:%d*/ ", g.filename, pos.Line)
+	}
+}
+
+func (g *gen) stmt(s ast.Stmt) {
+	switch s := s.(type) {
+	case nil, *ast.Skip:
+	case *ast.Block:
+		g.printf("{\n")
+		g.stmts(s)
+		g.printf("}\n")
+	case *ast.Assignment:
+		g.line(s.Pos)
+		lv := s.Lvalue()
+		g.printf("%s = %s\n", g.expr(lv), g.exprAs(s.Source, g.tm.TypeOf(lv)))
+	case *ast.Conditional:
+		g.line(s.Pos)
+		g.printf("if %s {\n", g.expr(s.Test))
+		g.stmts(s.Body)
+		if s.Else != nil {
+			g.printf("} else {\n")
+			g.stmts(s.Else)
+		}
+		g.printf("}\n")
+	case *ast.Loop:
+		g.line(s.Pos)
+		g.printf("for %s {\n", g.expr(s.Test))
+		g.stmts(s.Body)
+		g.printf("}\n")
+	case *ast.Switch:
+		g.line(s.Pos)
+		g.switchStmt(s)
+	default:
+		g.errorf("golang: unsupported statement %T", s)
+	}
+}
+
+// switchStmt writes s as a switch without a tag, so that case ranges
+// can be tested, on the value of the clite tag evaluated once.
+func (g *gen) switchStmt(s *ast.Switch) {
+	var tests [][]string
+	used := false
+	for _, c := range s.Cases {
+		var t []string
+		for _, l := range c.Labels {
+			lo, hi, _ := eval.Label(l, g.consts.Lookup)
+			if lo == hi {
+				t = append(t, fmt.Sprintf("tag == %d", lo))
+			} else {
+				t = append(t, fmt.Sprintf("tag >= %d && tag <= %d", lo, hi))
+			}
+			used = true
+		}
+		tests = append(tests, t)
+	}
+	if used {
+		g.printf("switch tag := %s; {\n", g.expr(s.Tag))
+	} else {
+		g.printf("switch %s {\n", g.expr(s.Tag))
+	}
+	for i, c := range s.Cases {
+		if len(c.Labels) == 0 {
+			g.printf("default:\n")
+		} else {
+			g.printf("case %s:\n", strings.Join(tests[i], ", "))
+		}
+		for _, m := range c.Body {
+			g.stmt(m)
+		}
+		if c.Fallthrough {
+			g.printf("fallthrough\n")
+		}
+	}
+	g.printf("}\n")
+}
+
+// stmts writes the statements of s, or s itself if it is not a block,
+// within braces already opened.
+func (g *gen) stmts(s ast.Stmt) {
+	if b, ok := s.(*ast.Block); ok {
+		for _, m := range b.Members {
+			g.stmt(m)
+		}
+	} else {
+		g.stmt(s)
+	}
+}
+
+// constant returns the value of e if it is made of literals and
+// constants only.
+func (g *gen) constant(e ast.Expr) (ast.Value, bool) {
+	v, err := eval.Expr(e, g.consts.Lookup)
+	return v, err == nil
+}
+
+// exprAs returns e converted to type t.
+func (g *gen) exprAs(e ast.Expr, t ast.Type) string {
+	if v, ok := g.constant(e); ok {
+		return g.literal(eval.Convert(v, t))
+	}
+	return g.convert(g.expr(e), g.tm.TypeOf(e), t)
+}
+
+// convert returns the conversion of the Go expression x from type from
+// to type to.
+func (g *gen) convert(x string, from, to ast.Type) string {
+	switch {
+	case from == to:
+		return x
+	case to == ast.STRING_TYPE:
+		// of a char, not the UTF-8 encoding of a rune
+		return "string([]byte{" + x + "})"
+	case to == ast.BOOL_TYPE:
+		return "(" + x + " != 0)"
+	case from == ast.BOOL_TYPE:
+		g.needB2i = true
+		x, from = "b2i("+x+")", ast.INT_TYPE
+		if to == ast.INT_TYPE {
+			return x
+		}
+	case from == ast.FLOAT_TYPE && to == ast.CHAR_TYPE:
+		// the conversion of an out of range float to an integer type
+		// is implementation specific; convert within int32 range
+		x = "int32(" + x + ")"
+	}
+	return TypeName(to) + "(" + x + ")"
+}
+
+func (g *gen) literal(v ast.Value) string {
+	switch v := v.(type) {
+	case ast.IntVal:
+		return strconv.Itoa(int(int32(v)))
+	case ast.CharVal:
+		return strconv.Itoa(int(v))
+	case ast.BoolVal:
+		return strconv.FormatBool(bool(v))
+	case ast.StringVal:
+		return strconv.Quote(string(v))
+	case ast.FloatVal:
+		f := float64(v)
+		if f == 0 && 1/f < 0 {
+			// constants have no negative zero
+			g.needMath = true
+			return "math.Copysign(0, -1)"
+		}
+		switch {
+		case math.IsInf(f, 1):
+			g.needMath = true
+			return "math.Inf(1)"
+		case math.IsInf(f, -1):
+			g.needMath = true
+			return "math.Inf(-1)"
+		case math.IsNaN(f):
+			g.needMath = true
+			return "math.NaN()"
+		}
+		s := strconv.FormatFloat(f, 'g', -1, 64)
+		if !strings.ContainsAny(s, ".e") {
+			s += ".0"
+		}
+		return s
+	}
+	return "nil"
+}
+
+// expr returns the Go expression for e. The result is always either a
+// primary expression or parenthesized.
+func (g *gen) expr(e ast.Expr) string {
+	if v, ok := g.constant(e); ok {
+		s := g.literal(v)
+		if strings.HasPrefix(s, "-") {
+			s = "(" + s + ")"
+		}
+		return s
+	}
+	switch e := e.(type) {
+	case ast.Variable:
+		return "v_" + string(e)
+	case *ast.Binary:
+		return g.binary(e)
+	case *ast.Ternary:
+		// Go has no conditional expression
+		t := g.tm.TypeOf(e)
+		return fmt.Sprintf("func() %s {\nif %s {\nreturn %s\n}\nreturn %s\n}()",
+			TypeName(t), g.expr(e.Test), g.exprAs(e.Then, t), g.exprAs(e.Else, t))
+	case *ast.Unary:
+		switch e.Op {
+		case "!", "-":
+			return "(" + string(e.Op) + g.expr(e.Term) + ")"
+		case "~":
+			return "(^" + g.exprAs(e.Term, ast.INT_TYPE) + ")"
+		case "len":
+			return "int32(len(" + g.expr(e.Term) + "))"
+		}
+		return g.convert(g.expr(e.Term), g.tm.TypeOf(e.Term), g.tm.TypeOf(e))
+	case *ast.Index:
+		x, i := g.expr(e.X), g.expr(e.Index)
+		if v, ok := g.constant(e.Index); ok {
+			_, str := g.constant(e.X)
+			if str || eval.Convert(v, ast.INT_TYPE).(ast.IntVal) < 0 {
+				// a constant index out of range does not compile
+				g.needAt = true
+				return "at(" + x + ", " + g.exprAs(e.Index, ast.INT_TYPE) + ")"
+			}
+		}
+		return x + "[" + i + "]"
+	case *ast.Selector:
+		return g.expr(e.X) + ".f_" + e.Field
+	}
+	g.errorf("golang: unsupported expression %T", e)
+	return "nil"
+}
+
+func (g *gen) binary(e *ast.Binary) string {
+	op := string(e.Op)
+	switch op {
+	case "&&", "||":
+		return "(" + g.expr(e.Term1) + " " + op + " " + g.expr(e.Term2) + ")"
+	}
+	t := g.tm.TypeOf(e)
+	if t == ast.BOOL_TYPE {
+		// comparison: operands have the same type, or are promoted
+		// to float
+		t = g.tm.TypeOf(e.Term1)
+		if g.tm.TypeOf(e.Term2) == ast.FLOAT_TYPE {
+			t = ast.FLOAT_TYPE
+		}
+		if t == ast.BOOL_TYPE && op != "==" && op != "!=" {
+			// Go bools are not ordered
+			t = ast.INT_TYPE
+		}
+	}
+	x, y := g.exprAs(e.Term1, t), g.exprAs(e.Term2, t)
+	if (op == "/" || op == "%") && t == ast.INT_TYPE {
+		if v, ok := g.constant(e.Term2); ok && eval.Convert(v, t) == ast.IntVal(0) {
+			// a constant division by zero does not compile
+			if op == "%" {
+				g.needRem = true
+				return "rem(" + x + ", " + y + ")"
+			}
+			g.needDiv = true
+			return "div(" + x + ", " + y + ")"
+		}
+	}
+	if op == "<<" || op == ">>" {
+		// the count is taken modulo 32; an untyped constant shifted
+		// would take the type of its context
+		if v, ok := g.constant(e.Term2); ok {
+			y = strconv.Itoa(int(eval.Convert(v, t).(ast.IntVal) & 31))
+		} else {
+			y = "(uint32(" + y + ") & 31)"
+		}
+		return "(int32(" + x + ") " + op + " " + y + ")"
+	}
+	return "(" + x + " " + op + " " + y + ")"
+}
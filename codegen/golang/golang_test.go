@@ -0,0 +1,137 @@
+package golang
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/mentalpumkins/clite-go/parser"
+)
+
+func TestGenerate(t *testing.T) {
+	prog := parser.MustParse(`int main() {
+	int i;
+	float x;
+	char c;
+	bool b;
+	i = 2147483647 + 1;
+	c = char(300);
+	x = -(0.0);
+	if (i < 0)
+		i = i / (2 - 2);
+	b = x > 1.0 || b < true;
+}`)
+	var buf bytes.Buffer
+	if err := Generate(&buf, prog, "prog.cl"); err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		"// Code generated by clite-go from prog.cl. DO NOT EDIT.",
+		"\t\"math\"\n",
+		"/*line prog.cl:6*/ v_i = -2147483648\n",
+		"/*line prog.cl:7*/ v_c = 44\n",
+		"/*line prog.cl:8*/ v_x = math.Copysign(0, -1)\n",
+		"/*line prog.cl:9*/ if v_i < 0 {\n",
+		"/*line prog.cl:10*/ v_i = div(v_i, 0)\n",
+		"v_b = ((v_x > 1.0) || (b2i(v_b) < 1))\n",
+		"func b2i(b bool) int32 {",
+		"func div(x, y int32) int32 {",
+	} {
+		if !strings.Contains(buf.String(), want) {
+			t.Errorf("output lacks %q:\n%s", want, buf.String())
+		}
+	}
+
+	prog = parser.MustParse(`int main() {
+	string s;
+	char c;
+	s = "caf\xe9" + string(c);
+	c = s[len(s) - 1];
+	c = "abc"[3];
+	c = s[-1];
+}`)
+	buf.Reset()
+	if err := Generate(&buf, prog, ""); err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		"v_s string\n",
+		`v_s = ("caf\xe9" + string([]byte{v_c}))`,
+		"v_c = v_s[(int32(len(v_s)) - 1)]\n",
+		`v_c = at("abc", 3)`,
+		"v_c = at(v_s, -1)\n",
+		`fmt.Printf("s = %s\n", utf8(v_s))`,
+		"func utf8(s string) string {",
+	} {
+		if !strings.Contains(buf.String(), want) {
+			t.Errorf("output lacks %q:\n%s", want, buf.String())
+		}
+	}
+
+	// folded infinities and NaN have no Go literal
+	prog = parser.MustParse(`int main() {
+	float a, b, c;
+	a = 1.0 / 0.0;
+	b = -1.0 / 0.0;
+	c = 0.0 / 0.0;
+}`)
+	buf.Reset()
+	if err := Generate(&buf, prog, ""); err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		"\t\"math\"\n",
+		"v_a = math.Inf(1)\n",
+		"v_b = math.Inf(-1)\n",
+		"v_c = math.NaN()\n",
+	} {
+		if !strings.Contains(buf.String(), want) {
+			t.Errorf("output lacks %q:\n%s", want, buf.String())
+		}
+	}
+}
+
+func TestRun(t *testing.T) {
+	goTool, err := exec.LookPath("go")
+	if err != nil {
+		t.Skip("no go command")
+	}
+	dir, err := ioutil.TempDir("", "golang")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	files, _ := filepath.Glob("../testdata/*.cl")
+	more, _ := filepath.Glob("../testdata/strings/*.cl")
+	files = append(files, more...)
+	more, _ = filepath.Glob("../testdata/structs/*.cl")
+	for _, file := range append(files, more...) {
+		src, err := ioutil.ReadFile(file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		want, err := ioutil.ReadFile(strings.TrimSuffix(file, ".cl") + ".out")
+		if err != nil {
+			t.Fatal(err)
+		}
+		var buf bytes.Buffer
+		if err := Generate(&buf, parser.MustParse(string(src)), filepath.Base(file)); err != nil {
+			t.Fatal(err)
+		}
+		main := filepath.Join(dir, "main.go")
+		if err := ioutil.WriteFile(main, buf.Bytes(), 0644); err != nil {
+			t.Fatal(err)
+		}
+		out, err := exec.Command(goTool, "run", main).Output()
+		if err != nil {
+			t.Fatalf("%s: %v\n%s", file, err, buf.Bytes())
+		}
+		if string(out) != string(want) {
+			t.Errorf("%s printed\n%s\nwant\n%s", file, out, want)
+		}
+	}
+}
@@ -0,0 +1,608 @@
+// Package llvm translates clite programs to LLVM IR in its textual
+// form, which clang or llc compile to native code.
+//
+// The module defines a main function which allocates a stack slot for
+// each variable of the program, runs its body and prints its final
+// state with printf, as described in package codegen. clite int,
+// float, char and bool map to i32, double, i8 and i1; conditionals and
+// loops become basic blocks named after their part of the statement,
+// as in package cfg.
+//
+// Integer division and the conversion of floats to integers, whose
+// LLVM instructions have undefined results for some operands, go
+// through the helper functions div, which traps on a zero divisor,
+// and ftoi.
+package llvm
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"strings"
+
+	"github.com/mentalpumkins/clite-go/ast"
+	"github.com/mentalpumkins/clite-go/codegen"
+	"github.com/mentalpumkins/clite-go/eval"
+	"github.com/mentalpumkins/clite-go/types"
+)
+
+// TypeName returns the LLVM type used for values of type t.
+func TypeName(t ast.Type) string {
+	switch t {
+	case ast.INT_TYPE:
+		return "i32"
+	case ast.FLOAT_TYPE:
+		return "double"
+	case ast.CHAR_TYPE:
+		return "i8"
+	case ast.BOOL_TYPE:
+		return "i1"
+	}
+	return "void"
+}
+
+// Generate type checks prog and writes its translation to w.
+func Generate(w io.Writer, prog *ast.Program) error {
+	tm, consts, vars, err := codegen.Check(prog)
+	if err != nil {
+		return err
+	}
+	if codegen.UsesStrings(prog, vars) {
+		return fmt.Errorf("llvm: strings are not supported")
+	}
+	if codegen.UsesStructs(vars) {
+		return fmt.Errorf("llvm: structs are not supported")
+	}
+	g := &gen{tm: tm, consts: consts}
+	g.label("entry")
+	for _, v := range vars {
+		g.inst("%%v.%s = alloca %s", v.Name, TypeName(v.Type))
+	}
+	for _, v := range vars {
+		g.inst("store %s %s, %s* %%v.%s", TypeName(v.Type), zero(v.Type), TypeName(v.Type), v.Name)
+	}
+	for _, s := range prog.Body {
+		g.stmt(s)
+	}
+	if g.err != nil {
+		return g.err
+	}
+
+	// final state
+	type str struct{ name, s string }
+	var strs []str
+	for i, v := range vars {
+		t := TypeName(v.Type)
+		x := g.temp()
+		g.inst("%s = load %s, %s* %%v.%s", x, t, t, v.Name)
+		name := fmt.Sprint(i)
+		s := fmt.Sprintf("%s = %s\n", v.Name, codegen.Format(v.Type))
+		strs = append(strs, str{name, s})
+		format, arg := strPtr(name, len(s)+1), "i32 "+x
+		switch v.Type {
+		case ast.FLOAT_TYPE:
+			// infinities and NaNs are spelled as by fmt rather than
+			// as inf or -nan
+			u := fmt.Sprintf("%s = %%s\n", v.Name)
+			strs = append(strs, str{name + ".special", u})
+			ls := g.newLabels("print.special", "print.finite", "print.done")
+			nan, pinf, ninf := g.temp(), g.temp(), g.temp()
+			g.inst("%s = fcmp uno double %s, %s", nan, x, x)
+			g.inst("%s = fcmp oeq double %s, 0x7FF0000000000000", pinf, x)
+			g.inst("%s = fcmp oeq double %s, 0xFFF0000000000000", ninf, x)
+			inf, special := g.temp(), g.temp()
+			g.inst("%s = or i1 %s, %s", inf, pinf, ninf)
+			g.inst("%s = or i1 %s, %s", special, nan, inf)
+			g.inst("br i1 %s, label %%%s, label %%%s", special, ls[0], ls[1])
+			g.label(ls[0])
+			sign, y := g.temp(), g.temp()
+			g.inst("%s = select i1 %s, i8* %s, i8* %s", sign, pinf, strPtr("posinf", 5), strPtr("neginf", 5))
+			g.inst("%s = select i1 %s, i8* %s, i8* %s", y, nan, strPtr("nan", 4), sign)
+			g.inst("call i32 (i8*, ...) @printf(i8* %s, i8* %s)", strPtr(name+".special", len(u)+1), y)
+			g.inst("br label %%%s", ls[2])
+			g.label(ls[1])
+			g.inst("call i32 (i8*, ...) @printf(i8* %s, double %s)", format, x)
+			g.inst("br label %%%s", ls[2])
+			g.label(ls[2])
+			continue
+		case ast.CHAR_TYPE:
+			// printed byte by byte, so that char 0 is printed too;
+			// the format of a single byte ignores the second
+			u := fmt.Sprintf("%s = %%c%%c\n", v.Name)
+			strs = append(strs, str{name + ".utf8", u})
+			ascii, b0, b1 := g.utf8(x)
+			f := g.temp()
+			g.inst("%s = select i1 %s, i8* %s, i8* %s", f, ascii, format, strPtr(name+".utf8", len(u)+1))
+			format, arg = f, "i32 "+b0+", i32 "+b1
+		case ast.BOOL_TYPE:
+			y := g.temp()
+			g.inst("%s = select i1 %s, i8* %s, i8* %s", y, x, strPtr("true", 5), strPtr("false", 6))
+			arg = "i8* " + y
+		}
+		g.inst("call i32 (i8*, ...) @printf(i8* %s, %s)", format, arg)
+	}
+	g.inst("ret i32 0")
+
+	bw := bufio.NewWriter(w)
+	for _, s := range strs {
+		fmt.Fprintf(bw, "@.str.%s = private unnamed_addr constant [%d x i8] c\"%s\\00\"\n", s.name, len(s.s)+1, escape(s.s))
+	}
+	fmt.Fprintf(bw, "@.str.true = private unnamed_addr constant [5 x i8] c\"true\\00\"\n")
+	fmt.Fprintf(bw, "@.str.false = private unnamed_addr constant [6 x i8] c\"false\\00\"\n")
+	fmt.Fprintf(bw, "@.str.posinf = private unnamed_addr constant [5 x i8] c\"+Inf\\00\"\n")
+	fmt.Fprintf(bw, "@.str.neginf = private unnamed_addr constant [5 x i8] c\"-Inf\\00\"\n")
+	fmt.Fprintf(bw, "@.str.nan = private unnamed_addr constant [4 x i8] c\"NaN\\00\"\n")
+	fmt.Fprintf(bw, "\ndeclare i32 @printf(i8*, ...)\n")
+	if g.needDiv || g.needRem {
+		fmt.Fprintf(bw, "declare void @llvm.trap()\n")
+	}
+	if g.needDiv {
+		fmt.Fprintf(bw, "\n%s", divFunc)
+	}
+	if g.needRem {
+		fmt.Fprintf(bw, "\n%s", remFunc)
+	}
+	if g.needFtoi {
+		fmt.Fprintf(bw, "\n%s", ftoiFunc)
+	}
+	fmt.Fprintf(bw, "\ndefine i32 @main() {\n")
+	bw.WriteString(g.buf.String())
+	fmt.Fprintf(bw, "}\n")
+	return bw.Flush()
+}
+
+const divFunc = `define internal i32 @div(i32 %x, i32 %y) {
+entry:
+  %zero = icmp eq i32 %y, 0
+  br i1 %zero, label %trap, label %nonzero
+trap:
+  call void @llvm.trap()
+  unreachable
+nonzero:
+  %minus1 = icmp eq i32 %y, -1
+  br i1 %minus1, label %neg, label %quo
+neg:
+  ; -1<<31 / -1 overflows
+  %n = sub i32 0, %x
+  ret i32 %n
+quo:
+  %q = sdiv i32 %x, %y
+  ret i32 %q
+}
+`
+
+const remFunc = `define internal i32 @rem(i32 %x, i32 %y) {
+entry:
+  %zero = icmp eq i32 %y, 0
+  br i1 %zero, label %trap, label %nonzero
+trap:
+  call void @llvm.trap()
+  unreachable
+nonzero:
+  %minus1 = icmp eq i32 %y, -1
+  br i1 %minus1, label %none, label %rem
+none:
+  ; -1<<31 % -1 overflows
+  ret i32 0
+rem:
+  %r = srem i32 %x, %y
+  ret i32 %r
+}
+`
+
+const ftoiFunc = `define internal i32 @ftoi(double %x) {
+entry:
+  %lo = fcmp oge double %x, -2147483648.0
+  %hi = fcmp olt double %x, 2147483648.0
+  %in = and i1 %lo, %hi
+  %t = fptosi double %x to i32
+  %r = select i1 %in, i32 %t, i32 -2147483648
+  ret i32 %r
+}
+`
+
+func zero(t ast.Type) string {
+	switch t {
+	case ast.FLOAT_TYPE:
+		return "0.0"
+	case ast.BOOL_TYPE:
+		return "false"
+	}
+	return "0"
+}
+
+func strPtr(name string, n int) string {
+	return fmt.Sprintf("getelementptr inbounds ([%d x i8], [%d x i8]* @.str.%s, i64 0, i64 0)", n, n, name)
+}
+
+// escape escapes s for use in an LLVM string constant.
+func escape(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if c < ' ' || c > '~' || c == '"' || c == '\\' {
+			fmt.Fprintf(&b, "\\%02X", c)
+		} else {
+			b.WriteByte(c)
+		}
+	}
+	return b.String()
+}
+
+type gen struct {
+	buf    strings.Builder
+	tm     *types.TypeMap
+	consts types.Consts
+	err    error
+	temps  int
+	// number of the last label of each kind
+	labels map[string]int
+	// label of the block being generated
+	block string
+
+	// helpers used by the generated code
+	needDiv, needRem, needFtoi bool
+}
+
+func (g *gen) printf(format string, args ...interface{}) {
+	fmt.Fprintf(&g.buf, format, args...)
+}
+
+func (g *gen) inst(format string, args ...interface{}) {
+	g.printf("  "+format+"\n", args...)
+}
+
+// utf8 returns the bytes of the UTF-8 encoding of the char x, of two
+// bytes from 0x80 on, as i32 values, and an i1 value telling whether
+// it is a single byte.
+func (g *gen) utf8(x string) (ascii, b0, b1 string) {
+	ascii = g.temp()
+	g.inst("%s = icmp ult i8 %s, 128", ascii, x)
+	hi, lead := g.temp(), g.temp()
+	g.inst("%s = lshr i8 %s, 6", hi, x)
+	g.inst("%s = or i8 %s, 192", lead, hi)
+	lo, cont := g.temp(), g.temp()
+	g.inst("%s = and i8 %s, 63", lo, x)
+	g.inst("%s = or i8 %s, 128", cont, lo)
+	first := g.temp()
+	g.inst("%s = select i1 %s, i8 %s, i8 %s", first, ascii, x, lead)
+	b0, b1 = g.temp(), g.temp()
+	g.inst("%s = zext i8 %s to i32", b0, first)
+	g.inst("%s = zext i8 %s to i32", b1, cont)
+	return ascii, b0, b1
+}
+
+func (g *gen) errorf(format string, args ...interface{}) {
+	if g.err == nil {
+		g.err = fmt.Errorf(format, args...)
+	}
+}
+
+func (g *gen) temp() string {
+	g.temps++
+	return fmt.Sprintf("%%t%d", g.temps)
+}
+
+// newLabels returns new labels with the given kinds, all numbered alike.
+func (g *gen) newLabels(kinds ...string) []string {
+	if g.labels == nil {
+		g.labels = make(map[string]int)
+	}
+	g.labels[kinds[0]]++
+	n := g.labels[kinds[0]]
+	var ls []string
+	for _, k := range kinds {
+		ls = append(ls, fmt.Sprintf("%s%d", k, n))
+	}
+	return ls
+}
+
+// label starts the block l.
+func (g *gen) label(l string) {
+	g.printf("%s:\n", l)
+	g.block = l
+}
+
+func (g *gen) stmt(s ast.Stmt) {
+	switch s := s.(type) {
+	case nil, *ast.Skip:
+	case *ast.Block:
+		for _, m := range s.Members {
+			g.stmt(m)
+		}
+	case *ast.Assignment:
+		t := g.tm.TypeOf(s.Target)
+		x := g.exprAs(s.Source, t)
+		g.inst("store %s %s, %s* %%v.%s", TypeName(t), x, TypeName(t), s.Target)
+	case *ast.Conditional:
+		ls := g.newLabels("if.then", "if.else", "if.done")
+		then, els, done := ls[0], ls[1], ls[2]
+		if s.Else == nil {
+			els = done
+		}
+		g.inst("br i1 %s, label %%%s, label %%%s", g.expr(s.Test), then, els)
+		g.label(then)
+		g.stmt(s.Body)
+		g.inst("br label %%%s", done)
+		if s.Else != nil {
+			g.label(els)
+			g.stmt(s.Else)
+			g.inst("br label %%%s", done)
+		}
+		g.label(done)
+	case *ast.Loop:
+		ls := g.newLabels("loop.head", "loop.body", "loop.done")
+		head, body, done := ls[0], ls[1], ls[2]
+		g.inst("br label %%%s", head)
+		g.label(head)
+		g.inst("br i1 %s, label %%%s, label %%%s", g.expr(s.Test), body, done)
+		g.label(body)
+		g.stmt(s.Body)
+		g.inst("br label %%%s", head)
+		g.label(done)
+	case *ast.Switch:
+		g.switchStmt(s)
+	default:
+		g.errorf("llvm: unsupported statement %T", s)
+	}
+}
+
+// switchStmt writes s as a switch instruction on the single values of
+// its labels, whose default tests the case ranges in turn. The type
+// checker rejects overlapping labels, so the order of the tests does
+// not matter.
+func (g *gen) switchStmt(s *ast.Switch) {
+	ls := g.newLabels("switch.done", "switch.case", "switch.range")
+	done := ls[0]
+	t := g.tm.TypeOf(s.Tag)
+	typ := TypeName(t)
+	k := func(v int64) string { return g.expr(eval.Convert(ast.IntVal(v), t)) }
+	tag := g.expr(s.Tag)
+
+	type span struct {
+		lo, hi int64
+		body   string
+	}
+	var singles, ranges []span
+	bodies := make([]string, len(s.Cases))
+	def := done
+	for i, c := range s.Cases {
+		bodies[i] = fmt.Sprintf("%s.%d", ls[1], i)
+		if len(c.Labels) == 0 {
+			def = bodies[i]
+		}
+		for _, l := range c.Labels {
+			lo, hi, _ := eval.Label(l, g.consts.Lookup)
+			if lo == hi {
+				singles = append(singles, span{lo, hi, bodies[i]})
+			} else {
+				ranges = append(ranges, span{lo, hi, bodies[i]})
+			}
+		}
+	}
+
+	next := def
+	if len(ranges) > 0 {
+		next = ls[2] + ".0"
+	}
+	var table strings.Builder
+	for _, sp := range singles {
+		fmt.Fprintf(&table, " %s %s, label %%%s", typ, k(sp.lo), sp.body)
+	}
+	g.inst("switch %s %s, label %%%s [%s ]", typ, tag, next, table.String())
+	for i, sp := range ranges {
+		g.label(next)
+		next = def
+		if i+1 < len(ranges) {
+			next = fmt.Sprintf("%s.%d", ls[2], i+1)
+		}
+		// lo <= tag <= hi if and only if tag-lo <= hi-lo unsigned
+		d, in := g.temp(), g.temp()
+		g.inst("%s = sub %s %s, %s", d, typ, tag, k(sp.lo))
+		g.inst("%s = icmp ule %s %s, %s", in, typ, d, k(sp.hi-sp.lo))
+		g.inst("br i1 %s, label %%%s, label %%%s", in, sp.body, next)
+	}
+
+	for i, c := range s.Cases {
+		g.label(bodies[i])
+		for _, m := range c.Body {
+			g.stmt(m)
+		}
+		if c.Fallthrough {
+			g.inst("br label %%%s", bodies[i+1])
+		} else {
+			g.inst("br label %%%s", done)
+		}
+	}
+	g.label(done)
+}
+
+// exprAs returns the value of e converted to type t.
+func (g *gen) exprAs(e ast.Expr, t ast.Type) string {
+	return g.convert(g.expr(e), g.tm.TypeOf(e), t)
+}
+
+// convert returns the conversion of the value x from type from to type
+// to.
+func (g *gen) convert(x string, from, to ast.Type) string {
+	if from == to {
+		return x
+	}
+	r := g.temp()
+	switch {
+	case to == ast.BOOL_TYPE && from == ast.FLOAT_TYPE:
+		g.inst("%s = fcmp une double %s, 0.0", r, x)
+	case to == ast.BOOL_TYPE:
+		g.inst("%s = icmp ne %s %s, 0", r, TypeName(from), x)
+	case to == ast.FLOAT_TYPE && from == ast.INT_TYPE:
+		g.inst("%s = sitofp i32 %s to double", r, x)
+	case to == ast.FLOAT_TYPE:
+		g.inst("%s = uitofp %s %s to double", r, TypeName(from), x)
+	case from == ast.FLOAT_TYPE:
+		g.needFtoi = true
+		g.inst("%s = call i32 @ftoi(double %s)", r, x)
+		return g.convert(r, ast.INT_TYPE, to)
+	case to == ast.CHAR_TYPE && from == ast.INT_TYPE:
+		g.inst("%s = trunc i32 %s to i8", r, x)
+	default:
+		g.inst("%s = zext %s %s to %s", r, TypeName(from), x, TypeName(to))
+	}
+	return r
+}
+
+func floatLit(f float64) string {
+	// hexadecimal floats are exact
+	return fmt.Sprintf("0x%016X", math.Float64bits(f))
+}
+
+// expr returns the value of e, either a constant or a temporary.
+func (g *gen) expr(e ast.Expr) string {
+	switch e := e.(type) {
+	case ast.Variable:
+		if k, ok := g.consts[e]; ok {
+			return g.expr(k)
+		}
+		t := TypeName(g.tm.TypeOf(e))
+		r := g.temp()
+		g.inst("%s = load %s, %s* %%v.%s", r, t, t, e)
+		return r
+	case ast.IntVal:
+		return fmt.Sprint(int32(e))
+	case ast.CharVal:
+		return fmt.Sprint(int8(e))
+	case ast.BoolVal:
+		return fmt.Sprint(bool(e))
+	case ast.FloatVal:
+		return floatLit(float64(e))
+	case *ast.Binary:
+		return g.binary(e)
+	case *ast.Ternary:
+		ls := g.newLabels("cond.then", "cond.else", "cond.end")
+		then, els, end := ls[0], ls[1], ls[2]
+		t := g.tm.TypeOf(e)
+		g.inst("br i1 %s, label %%%s, label %%%s", g.expr(e.Test), then, els)
+		g.label(then)
+		x := g.exprAs(e.Then, t)
+		thenEnd := g.block
+		g.inst("br label %%%s", end)
+		g.label(els)
+		y := g.exprAs(e.Else, t)
+		elseEnd := g.block
+		g.inst("br label %%%s", end)
+		g.label(end)
+		r := g.temp()
+		g.inst("%s = phi %s [ %s, %%%s ], [ %s, %%%s ]", r, TypeName(t), x, thenEnd, y, elseEnd)
+		return r
+	case *ast.Unary:
+		from := g.tm.TypeOf(e.Term)
+		x := g.expr(e.Term)
+		switch e.Op {
+		case "!":
+			r := g.temp()
+			g.inst("%s = xor i1 %s, true", r, x)
+			return r
+		case "-":
+			r := g.temp()
+			if from == ast.FLOAT_TYPE {
+				g.inst("%s = fneg double %s", r, x)
+			} else {
+				g.inst("%s = sub i32 0, %s", r, x)
+			}
+			return r
+		case "~":
+			r := g.temp()
+			g.inst("%s = xor i32 %s, -1", r, g.convert(x, from, ast.INT_TYPE))
+			return r
+		}
+		return g.convert(x, from, g.tm.TypeOf(e))
+	}
+	g.errorf("llvm: unsupported expression %T", e)
+	return "undef"
+}
+
+var intOps = map[string]string{
+	"+": "add", "-": "sub", "*": "mul",
+	"&": "and", "|": "or", "^": "xor", "<<": "shl", ">>": "ashr",
+	"<": "icmp slt", "<=": "icmp sle", ">": "icmp sgt", ">=": "icmp sge",
+	"==": "icmp eq", "!=": "icmp ne",
+}
+
+// chars and bools are unsigned
+var unsignedOps = map[string]string{
+	"<": "icmp ult", "<=": "icmp ule", ">": "icmp ugt", ">=": "icmp uge",
+	"==": "icmp eq", "!=": "icmp ne",
+}
+
+// comparisons with NaN are false, except for !=
+var floatOps = map[string]string{
+	"+": "fadd", "-": "fsub", "*": "fmul", "/": "fdiv",
+	"<": "fcmp olt", "<=": "fcmp ole", ">": "fcmp ogt", ">=": "fcmp oge",
+	"==": "fcmp oeq", "!=": "fcmp une",
+}
+
+func (g *gen) binary(e *ast.Binary) string {
+	op := string(e.Op)
+	switch op {
+	case "&&", "||":
+		ls := g.newLabels("cond.rhs", "cond.done")
+		rhs, done := ls[0], ls[1]
+		x := g.expr(e.Term1)
+		from := g.block
+		if op == "&&" {
+			g.inst("br i1 %s, label %%%s, label %%%s", x, rhs, done)
+		} else {
+			g.inst("br i1 %s, label %%%s, label %%%s", x, done, rhs)
+		}
+		g.label(rhs)
+		y := g.expr(e.Term2)
+		rhsEnd := g.block
+		g.inst("br label %%%s", done)
+		g.label(done)
+		r := g.temp()
+		g.inst("%s = phi i1 [ %t, %%%s ], [ %s, %%%s ]", r, op == "||", from, y, rhsEnd)
+		return r
+	}
+	t := g.tm.TypeOf(e)
+	if t == ast.BOOL_TYPE {
+		// comparison: operands have the same type, or are promoted
+		// to float
+		t = g.tm.TypeOf(e.Term1)
+		if g.tm.TypeOf(e.Term2) == ast.FLOAT_TYPE {
+			t = ast.FLOAT_TYPE
+		}
+	}
+	x, y := g.exprAs(e.Term1, t), g.exprAs(e.Term2, t)
+	if op == "<<" || op == ">>" {
+		// shifting by 32 or more is poison; the count is taken
+		// modulo 32
+		n := g.temp()
+		g.inst("%s = and i32 %s, 31", n, y)
+		y = n
+	}
+	r := g.temp()
+	if op == "/" && t != ast.FLOAT_TYPE {
+		g.needDiv = true
+		g.inst("%s = call i32 @div(i32 %s, i32 %s)", r, x, y)
+		return r
+	}
+	if op == "%" {
+		g.needRem = true
+		g.inst("%s = call i32 @rem(i32 %s, i32 %s)", r, x, y)
+		return r
+	}
+	ops := intOps
+	switch t {
+	case ast.FLOAT_TYPE:
+		ops = floatOps
+	case ast.CHAR_TYPE, ast.BOOL_TYPE:
+		ops = unsignedOps
+	}
+	inst, ok := ops[op]
+	if !ok {
+		g.errorf("llvm: unsupported operator %s", op)
+		return "undef"
+	}
+	g.inst("%s = %s %s %s, %s", r, inst, TypeName(t), x, y)
+	return r
+}
@@ -0,0 +1,73 @@
+package llvm
+
+import (
+	"bytes"
+	"flag"
+	"io/ioutil"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/mentalpumkins/clite-go/parser"
+)
+
+var update = flag.Bool("update", false, "rewrite the golden files under testdata")
+
+// generate translates each program of the shared corpus, calling f
+// with its file name, its translation and its expected output.
+func generate(t *testing.T, f func(file string, ir, want []byte)) {
+	files, _ := filepath.Glob("../testdata/*.cl")
+	for _, file := range files {
+		src, err := ioutil.ReadFile(file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		want, err := ioutil.ReadFile(strings.TrimSuffix(file, ".cl") + ".out")
+		if err != nil {
+			t.Fatal(err)
+		}
+		var buf bytes.Buffer
+		if err := Generate(&buf, parser.MustParse(string(src))); err != nil {
+			t.Fatal(err)
+		}
+		f(file, buf.Bytes(), want)
+	}
+}
+
+func TestGolden(t *testing.T) {
+	generate(t, func(file string, ir, _ []byte) {
+		golden := filepath.Join("testdata", strings.TrimSuffix(filepath.Base(file), ".cl")+".ll")
+		if *update {
+			if err := ioutil.WriteFile(golden, ir, 0644); err != nil {
+				t.Fatal(err)
+			}
+			return
+		}
+		want, err := ioutil.ReadFile(golden)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(ir, want) {
+			t.Errorf("%s translates to\n%s\nwant\n%s", file, ir, want)
+		}
+	})
+}
+
+func TestRun(t *testing.T) {
+	lli, err := exec.LookPath("lli")
+	if err != nil {
+		t.Skip("no lli command")
+	}
+	generate(t, func(file string, ir, want []byte) {
+		cmd := exec.Command(lli, "-")
+		cmd.Stdin = bytes.NewReader(ir)
+		out, err := cmd.Output()
+		if err != nil {
+			t.Fatalf("%s: %v\n%s", file, err, ir)
+		}
+		if string(out) != string(want) {
+			t.Errorf("%s printed\n%s\nwant\n%s", file, out, want)
+		}
+	})
+}
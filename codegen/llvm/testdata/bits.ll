@@ -0,0 +1,162 @@
+@.str.0 = private unnamed_addr constant [8 x i8] c"i = %d\0A\00"
+@.str.1 = private unnamed_addr constant [8 x i8] c"j = %d\0A\00"
+@.str.2 = private unnamed_addr constant [8 x i8] c"r = %d\0A\00"
+@.str.3 = private unnamed_addr constant [8 x i8] c"s = %d\0A\00"
+@.str.4 = private unnamed_addr constant [8 x i8] c"m = %d\0A\00"
+@.str.5 = private unnamed_addr constant [8 x i8] c"a = %d\0A\00"
+@.str.6 = private unnamed_addr constant [8 x i8] c"o = %d\0A\00"
+@.str.7 = private unnamed_addr constant [8 x i8] c"n = %d\0A\00"
+@.str.8 = private unnamed_addr constant [8 x i8] c"l = %d\0A\00"
+@.str.9 = private unnamed_addr constant [8 x i8] c"h = %d\0A\00"
+@.str.10 = private unnamed_addr constant [8 x i8] c"k = %d\0A\00"
+@.str.11 = private unnamed_addr constant [8 x i8] c"w = %d\0A\00"
+@.str.12 = private unnamed_addr constant [8 x i8] c"c = %c\0A\00"
+@.str.12.utf8 = private unnamed_addr constant [10 x i8] c"c = %c%c\0A\00"
+@.str.true = private unnamed_addr constant [5 x i8] c"true\00"
+@.str.false = private unnamed_addr constant [6 x i8] c"false\00"
+@.str.posinf = private unnamed_addr constant [5 x i8] c"+Inf\00"
+@.str.neginf = private unnamed_addr constant [5 x i8] c"-Inf\00"
+@.str.nan = private unnamed_addr constant [4 x i8] c"NaN\00"
+
+declare i32 @printf(i8*, ...)
+declare void @llvm.trap()
+
+define internal i32 @rem(i32 %x, i32 %y) {
+entry:
+  %zero = icmp eq i32 %y, 0
+  br i1 %zero, label %trap, label %nonzero
+trap:
+  call void @llvm.trap()
+  unreachable
+nonzero:
+  %minus1 = icmp eq i32 %y, -1
+  br i1 %minus1, label %none, label %rem
+none:
+  ; -1<<31 % -1 overflows
+  ret i32 0
+rem:
+  %r = srem i32 %x, %y
+  ret i32 %r
+}
+
+define i32 @main() {
+entry:
+  %v.i = alloca i32
+  %v.j = alloca i32
+  %v.r = alloca i32
+  %v.s = alloca i32
+  %v.m = alloca i32
+  %v.a = alloca i32
+  %v.o = alloca i32
+  %v.n = alloca i32
+  %v.l = alloca i32
+  %v.h = alloca i32
+  %v.k = alloca i32
+  %v.w = alloca i32
+  %v.c = alloca i8
+  store i32 0, i32* %v.i
+  store i32 0, i32* %v.j
+  store i32 0, i32* %v.r
+  store i32 0, i32* %v.s
+  store i32 0, i32* %v.m
+  store i32 0, i32* %v.a
+  store i32 0, i32* %v.o
+  store i32 0, i32* %v.n
+  store i32 0, i32* %v.l
+  store i32 0, i32* %v.h
+  store i32 0, i32* %v.k
+  store i32 0, i32* %v.w
+  store i8 0, i8* %v.c
+  %t1 = sub i32 0, 7
+  store i32 %t1, i32* %v.i
+  store i32 3, i32* %v.j
+  %t2 = load i32, i32* %v.i
+  %t3 = load i32, i32* %v.j
+  %t4 = call i32 @rem(i32 %t2, i32 %t3)
+  store i32 %t4, i32* %v.r
+  %t5 = load i32, i32* %v.j
+  %t6 = sub i32 0, %t5
+  %t7 = call i32 @rem(i32 7, i32 %t6)
+  store i32 %t7, i32* %v.s
+  store i8 97, i8* %v.c
+  %t8 = load i8, i8* %v.c
+  %t9 = zext i8 %t8 to i32
+  %t10 = call i32 @rem(i32 %t9, i32 10)
+  store i32 %t10, i32* %v.m
+  %t11 = load i8, i8* %v.c
+  %t12 = zext i8 %t11 to i32
+  %t13 = and i32 %t12, 95
+  store i32 %t13, i32* %v.a
+  %t14 = load i32, i32* %v.j
+  %t15 = xor i32 %t14, 5
+  %t16 = or i32 12, %t15
+  store i32 %t16, i32* %v.o
+  %t17 = load i8, i8* %v.c
+  %t19 = zext i8 %t17 to i32
+  %t18 = xor i32 %t19, -1
+  store i32 %t18, i32* %v.n
+  %t20 = and i32 31, 31
+  %t21 = shl i32 1, %t20
+  store i32 %t21, i32* %v.l
+  %t22 = load i32, i32* %v.l
+  %t23 = load i32, i32* %v.j
+  %t24 = add i32 %t23, 1
+  %t25 = and i32 %t24, 31
+  %t26 = ashr i32 %t22, %t25
+  store i32 %t26, i32* %v.h
+  %t27 = load i32, i32* %v.j
+  %t28 = load i32, i32* %v.i
+  %t29 = mul i32 %t28, 5
+  %t30 = add i32 33, %t29
+  %t31 = and i32 %t30, 31
+  %t32 = shl i32 %t27, %t31
+  store i32 %t32, i32* %v.k
+  %t33 = load i32, i32* %v.i
+  %t34 = sub i32 0, 1
+  %t35 = and i32 %t34, 31
+  %t36 = ashr i32 %t33, %t35
+  store i32 %t36, i32* %v.w
+  %t37 = load i8, i8* %v.c
+  %t38 = zext i8 %t37 to i32
+  %t39 = load i32, i32* %v.i
+  %t40 = xor i32 %t39, -1
+  %t41 = xor i32 %t38, %t40
+  %t42 = trunc i32 %t41 to i8
+  store i8 %t42, i8* %v.c
+  %t43 = load i32, i32* %v.i
+  call i32 (i8*, ...) @printf(i8* getelementptr inbounds ([8 x i8], [8 x i8]* @.str.0, i64 0, i64 0), i32 %t43)
+  %t44 = load i32, i32* %v.j
+  call i32 (i8*, ...) @printf(i8* getelementptr inbounds ([8 x i8], [8 x i8]* @.str.1, i64 0, i64 0), i32 %t44)
+  %t45 = load i32, i32* %v.r
+  call i32 (i8*, ...) @printf(i8* getelementptr inbounds ([8 x i8], [8 x i8]* @.str.2, i64 0, i64 0), i32 %t45)
+  %t46 = load i32, i32* %v.s
+  call i32 (i8*, ...) @printf(i8* getelementptr inbounds ([8 x i8], [8 x i8]* @.str.3, i64 0, i64 0), i32 %t46)
+  %t47 = load i32, i32* %v.m
+  call i32 (i8*, ...) @printf(i8* getelementptr inbounds ([8 x i8], [8 x i8]* @.str.4, i64 0, i64 0), i32 %t47)
+  %t48 = load i32, i32* %v.a
+  call i32 (i8*, ...) @printf(i8* getelementptr inbounds ([8 x i8], [8 x i8]* @.str.5, i64 0, i64 0), i32 %t48)
+  %t49 = load i32, i32* %v.o
+  call i32 (i8*, ...) @printf(i8* getelementptr inbounds ([8 x i8], [8 x i8]* @.str.6, i64 0, i64 0), i32 %t49)
+  %t50 = load i32, i32* %v.n
+  call i32 (i8*, ...) @printf(i8* getelementptr inbounds ([8 x i8], [8 x i8]* @.str.7, i64 0, i64 0), i32 %t50)
+  %t51 = load i32, i32* %v.l
+  call i32 (i8*, ...) @printf(i8* getelementptr inbounds ([8 x i8], [8 x i8]* @.str.8, i64 0, i64 0), i32 %t51)
+  %t52 = load i32, i32* %v.h
+  call i32 (i8*, ...) @printf(i8* getelementptr inbounds ([8 x i8], [8 x i8]* @.str.9, i64 0, i64 0), i32 %t52)
+  %t53 = load i32, i32* %v.k
+  call i32 (i8*, ...) @printf(i8* getelementptr inbounds ([8 x i8], [8 x i8]* @.str.10, i64 0, i64 0), i32 %t53)
+  %t54 = load i32, i32* %v.w
+  call i32 (i8*, ...) @printf(i8* getelementptr inbounds ([8 x i8], [8 x i8]* @.str.11, i64 0, i64 0), i32 %t54)
+  %t55 = load i8, i8* %v.c
+  %t56 = icmp ult i8 %t55, 128
+  %t57 = lshr i8 %t55, 6
+  %t58 = or i8 %t57, 192
+  %t59 = and i8 %t55, 63
+  %t60 = or i8 %t59, 128
+  %t61 = select i1 %t56, i8 %t55, i8 %t58
+  %t62 = zext i8 %t61 to i32
+  %t63 = zext i8 %t60 to i32
+  %t64 = select i1 %t56, i8* getelementptr inbounds ([8 x i8], [8 x i8]* @.str.12, i64 0, i64 0), i8* getelementptr inbounds ([10 x i8], [10 x i8]* @.str.12.utf8, i64 0, i64 0)
+  call i32 (i8*, ...) @printf(i8* %t64, i32 %t62, i32 %t63)
+  ret i32 0
+}
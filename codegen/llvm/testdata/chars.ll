@@ -0,0 +1,157 @@
+@.str.0 = private unnamed_addr constant [8 x i8] c"c = %c\0A\00"
+@.str.0.utf8 = private unnamed_addr constant [10 x i8] c"c = %c%c\0A\00"
+@.str.1 = private unnamed_addr constant [8 x i8] c"d = %c\0A\00"
+@.str.1.utf8 = private unnamed_addr constant [10 x i8] c"d = %c%c\0A\00"
+@.str.2 = private unnamed_addr constant [8 x i8] c"e = %c\0A\00"
+@.str.2.utf8 = private unnamed_addr constant [10 x i8] c"e = %c%c\0A\00"
+@.str.3 = private unnamed_addr constant [8 x i8] c"a = %c\0A\00"
+@.str.3.utf8 = private unnamed_addr constant [10 x i8] c"a = %c%c\0A\00"
+@.str.4 = private unnamed_addr constant [8 x i8] c"i = %d\0A\00"
+@.str.5 = private unnamed_addr constant [8 x i8] c"q = %c\0A\00"
+@.str.5.utf8 = private unnamed_addr constant [10 x i8] c"q = %c%c\0A\00"
+@.str.6 = private unnamed_addr constant [8 x i8] c"b = %c\0A\00"
+@.str.6.utf8 = private unnamed_addr constant [10 x i8] c"b = %c%c\0A\00"
+@.str.7 = private unnamed_addr constant [8 x i8] c"h = %c\0A\00"
+@.str.7.utf8 = private unnamed_addr constant [10 x i8] c"h = %c%c\0A\00"
+@.str.8 = private unnamed_addr constant [8 x i8] c"z = %c\0A\00"
+@.str.8.utf8 = private unnamed_addr constant [10 x i8] c"z = %c%c\0A\00"
+@.str.true = private unnamed_addr constant [5 x i8] c"true\00"
+@.str.false = private unnamed_addr constant [6 x i8] c"false\00"
+@.str.posinf = private unnamed_addr constant [5 x i8] c"+Inf\00"
+@.str.neginf = private unnamed_addr constant [5 x i8] c"-Inf\00"
+@.str.nan = private unnamed_addr constant [4 x i8] c"NaN\00"
+
+declare i32 @printf(i8*, ...)
+
+define i32 @main() {
+entry:
+  %v.c = alloca i8
+  %v.d = alloca i8
+  %v.e = alloca i8
+  %v.a = alloca i8
+  %v.i = alloca i32
+  %v.q = alloca i8
+  %v.b = alloca i8
+  %v.h = alloca i8
+  %v.z = alloca i8
+  store i8 0, i8* %v.c
+  store i8 0, i8* %v.d
+  store i8 0, i8* %v.e
+  store i8 0, i8* %v.a
+  store i32 0, i32* %v.i
+  store i8 0, i8* %v.q
+  store i8 0, i8* %v.b
+  store i8 0, i8* %v.h
+  store i8 0, i8* %v.z
+  store i8 -23, i8* %v.c
+  %t1 = load i8, i8* %v.c
+  %t2 = zext i8 %t1 to i32
+  %t3 = add i32 %t2, 1
+  %t4 = trunc i32 %t3 to i8
+  store i8 %t4, i8* %v.d
+  %t5 = trunc i32 255 to i8
+  store i8 %t5, i8* %v.e
+  %t6 = load i8, i8* %v.e
+  %t7 = zext i8 %t6 to i32
+  %t8 = add i32 %t7, 66
+  %t9 = trunc i32 %t8 to i8
+  store i8 %t9, i8* %v.a
+  %t10 = load i8, i8* %v.c
+  %t11 = zext i8 %t10 to i32
+  store i32 %t11, i32* %v.i
+  store i8 39, i8* %v.q
+  store i8 92, i8* %v.b
+  store i8 -24, i8* %v.h
+  store i8 0, i8* %v.z
+  %t12 = load i8, i8* %v.c
+  %t13 = icmp ult i8 %t12, 128
+  %t14 = lshr i8 %t12, 6
+  %t15 = or i8 %t14, 192
+  %t16 = and i8 %t12, 63
+  %t17 = or i8 %t16, 128
+  %t18 = select i1 %t13, i8 %t12, i8 %t15
+  %t19 = zext i8 %t18 to i32
+  %t20 = zext i8 %t17 to i32
+  %t21 = select i1 %t13, i8* getelementptr inbounds ([8 x i8], [8 x i8]* @.str.0, i64 0, i64 0), i8* getelementptr inbounds ([10 x i8], [10 x i8]* @.str.0.utf8, i64 0, i64 0)
+  call i32 (i8*, ...) @printf(i8* %t21, i32 %t19, i32 %t20)
+  %t22 = load i8, i8* %v.d
+  %t23 = icmp ult i8 %t22, 128
+  %t24 = lshr i8 %t22, 6
+  %t25 = or i8 %t24, 192
+  %t26 = and i8 %t22, 63
+  %t27 = or i8 %t26, 128
+  %t28 = select i1 %t23, i8 %t22, i8 %t25
+  %t29 = zext i8 %t28 to i32
+  %t30 = zext i8 %t27 to i32
+  %t31 = select i1 %t23, i8* getelementptr inbounds ([8 x i8], [8 x i8]* @.str.1, i64 0, i64 0), i8* getelementptr inbounds ([10 x i8], [10 x i8]* @.str.1.utf8, i64 0, i64 0)
+  call i32 (i8*, ...) @printf(i8* %t31, i32 %t29, i32 %t30)
+  %t32 = load i8, i8* %v.e
+  %t33 = icmp ult i8 %t32, 128
+  %t34 = lshr i8 %t32, 6
+  %t35 = or i8 %t34, 192
+  %t36 = and i8 %t32, 63
+  %t37 = or i8 %t36, 128
+  %t38 = select i1 %t33, i8 %t32, i8 %t35
+  %t39 = zext i8 %t38 to i32
+  %t40 = zext i8 %t37 to i32
+  %t41 = select i1 %t33, i8* getelementptr inbounds ([8 x i8], [8 x i8]* @.str.2, i64 0, i64 0), i8* getelementptr inbounds ([10 x i8], [10 x i8]* @.str.2.utf8, i64 0, i64 0)
+  call i32 (i8*, ...) @printf(i8* %t41, i32 %t39, i32 %t40)
+  %t42 = load i8, i8* %v.a
+  %t43 = icmp ult i8 %t42, 128
+  %t44 = lshr i8 %t42, 6
+  %t45 = or i8 %t44, 192
+  %t46 = and i8 %t42, 63
+  %t47 = or i8 %t46, 128
+  %t48 = select i1 %t43, i8 %t42, i8 %t45
+  %t49 = zext i8 %t48 to i32
+  %t50 = zext i8 %t47 to i32
+  %t51 = select i1 %t43, i8* getelementptr inbounds ([8 x i8], [8 x i8]* @.str.3, i64 0, i64 0), i8* getelementptr inbounds ([10 x i8], [10 x i8]* @.str.3.utf8, i64 0, i64 0)
+  call i32 (i8*, ...) @printf(i8* %t51, i32 %t49, i32 %t50)
+  %t52 = load i32, i32* %v.i
+  call i32 (i8*, ...) @printf(i8* getelementptr inbounds ([8 x i8], [8 x i8]* @.str.4, i64 0, i64 0), i32 %t52)
+  %t53 = load i8, i8* %v.q
+  %t54 = icmp ult i8 %t53, 128
+  %t55 = lshr i8 %t53, 6
+  %t56 = or i8 %t55, 192
+  %t57 = and i8 %t53, 63
+  %t58 = or i8 %t57, 128
+  %t59 = select i1 %t54, i8 %t53, i8 %t56
+  %t60 = zext i8 %t59 to i32
+  %t61 = zext i8 %t58 to i32
+  %t62 = select i1 %t54, i8* getelementptr inbounds ([8 x i8], [8 x i8]* @.str.5, i64 0, i64 0), i8* getelementptr inbounds ([10 x i8], [10 x i8]* @.str.5.utf8, i64 0, i64 0)
+  call i32 (i8*, ...) @printf(i8* %t62, i32 %t60, i32 %t61)
+  %t63 = load i8, i8* %v.b
+  %t64 = icmp ult i8 %t63, 128
+  %t65 = lshr i8 %t63, 6
+  %t66 = or i8 %t65, 192
+  %t67 = and i8 %t63, 63
+  %t68 = or i8 %t67, 128
+  %t69 = select i1 %t64, i8 %t63, i8 %t66
+  %t70 = zext i8 %t69 to i32
+  %t71 = zext i8 %t68 to i32
+  %t72 = select i1 %t64, i8* getelementptr inbounds ([8 x i8], [8 x i8]* @.str.6, i64 0, i64 0), i8* getelementptr inbounds ([10 x i8], [10 x i8]* @.str.6.utf8, i64 0, i64 0)
+  call i32 (i8*, ...) @printf(i8* %t72, i32 %t70, i32 %t71)
+  %t73 = load i8, i8* %v.h
+  %t74 = icmp ult i8 %t73, 128
+  %t75 = lshr i8 %t73, 6
+  %t76 = or i8 %t75, 192
+  %t77 = and i8 %t73, 63
+  %t78 = or i8 %t77, 128
+  %t79 = select i1 %t74, i8 %t73, i8 %t76
+  %t80 = zext i8 %t79 to i32
+  %t81 = zext i8 %t78 to i32
+  %t82 = select i1 %t74, i8* getelementptr inbounds ([8 x i8], [8 x i8]* @.str.7, i64 0, i64 0), i8* getelementptr inbounds ([10 x i8], [10 x i8]* @.str.7.utf8, i64 0, i64 0)
+  call i32 (i8*, ...) @printf(i8* %t82, i32 %t80, i32 %t81)
+  %t83 = load i8, i8* %v.z
+  %t84 = icmp ult i8 %t83, 128
+  %t85 = lshr i8 %t83, 6
+  %t86 = or i8 %t85, 192
+  %t87 = and i8 %t83, 63
+  %t88 = or i8 %t87, 128
+  %t89 = select i1 %t84, i8 %t83, i8 %t86
+  %t90 = zext i8 %t89 to i32
+  %t91 = zext i8 %t88 to i32
+  %t92 = select i1 %t84, i8* getelementptr inbounds ([8 x i8], [8 x i8]* @.str.8, i64 0, i64 0), i8* getelementptr inbounds ([10 x i8], [10 x i8]* @.str.8.utf8, i64 0, i64 0)
+  call i32 (i8*, ...) @printf(i8* %t92, i32 %t90, i32 %t91)
+  ret i32 0
+}
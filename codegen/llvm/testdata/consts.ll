@@ -0,0 +1,134 @@
+@.str.0 = private unnamed_addr constant [8 x i8] c"i = %d\0A\00"
+@.str.1 = private unnamed_addr constant [8 x i8] c"s = %d\0A\00"
+@.str.2 = private unnamed_addr constant [8 x i8] c"m = %d\0A\00"
+@.str.3 = private unnamed_addr constant [8 x i8] c"c = %c\0A\00"
+@.str.3.utf8 = private unnamed_addr constant [10 x i8] c"c = %c%c\0A\00"
+@.str.4 = private unnamed_addr constant [8 x i8] c"x = %f\0A\00"
+@.str.4.special = private unnamed_addr constant [8 x i8] c"x = %s\0A\00"
+@.str.5 = private unnamed_addr constant [8 x i8] c"b = %s\0A\00"
+@.str.true = private unnamed_addr constant [5 x i8] c"true\00"
+@.str.false = private unnamed_addr constant [6 x i8] c"false\00"
+@.str.posinf = private unnamed_addr constant [5 x i8] c"+Inf\00"
+@.str.neginf = private unnamed_addr constant [5 x i8] c"-Inf\00"
+@.str.nan = private unnamed_addr constant [4 x i8] c"NaN\00"
+
+declare i32 @printf(i8*, ...)
+
+define i32 @main() {
+entry:
+  %v.i = alloca i32
+  %v.s = alloca i32
+  %v.m = alloca i32
+  %v.c = alloca i8
+  %v.x = alloca double
+  %v.b = alloca i1
+  store i32 0, i32* %v.i
+  store i32 0, i32* %v.s
+  store i32 0, i32* %v.m
+  store i8 0, i8* %v.c
+  store double 0.0, double* %v.x
+  store i1 false, i1* %v.b
+  br label %loop.head1
+loop.head1:
+  %t1 = load i32, i32* %v.i
+  %t2 = icmp slt i32 %t1, 10
+  br i1 %t2, label %loop.body1, label %loop.done1
+loop.body1:
+  %t3 = load i32, i32* %v.i
+  switch i32 %t3, label %switch.range1.0 [ i32 9, label %switch.case1.0 ]
+switch.range1.0:
+  %t4 = sub i32 %t3, 0
+  %t5 = icmp ule i32 %t4, 5
+  br i1 %t5, label %switch.case1.1, label %switch.done1
+switch.case1.0:
+  %t6 = load i32, i32* %v.s
+  %t7 = add i32 %t6, 21
+  store i32 %t7, i32* %v.s
+  br label %switch.done1
+switch.case1.1:
+  %t8 = load i32, i32* %v.s
+  %t9 = add i32 %t8, 1
+  store i32 %t9, i32* %v.s
+  br label %switch.done1
+switch.done1:
+  %t10 = load i32, i32* %v.i
+  %t11 = add i32 %t10, 1
+  store i32 %t11, i32* %v.i
+  br label %loop.head1
+loop.done1:
+  %t12 = and i32 -10, -17
+  store i32 %t12, i32* %v.m
+  store i8 122, i8* %v.c
+  %t13 = load i8, i8* %v.c
+  switch i8 %t13, label %switch.range2.0 [ ]
+switch.range2.0:
+  %t14 = sub i8 %t13, 97
+  %t15 = icmp ule i8 %t14, 25
+  br i1 %t15, label %switch.case2.0, label %switch.done2
+switch.case2.0:
+  store i8 97, i8* %v.c
+  br label %switch.done2
+switch.done2:
+  %t16 = fmul double 0x4024000000000000, 0x4000000000000000
+  store double %t16, double* %v.x
+  br i1 true, label %cond.rhs3, label %cond.done3
+cond.rhs3:
+  %t17 = icmp sgt i32 -10, 0
+  %t18 = xor i1 %t17, true
+  br label %cond.done3
+cond.done3:
+  %t19 = phi i1 [ false, %switch.done2 ], [ %t18, %cond.rhs3 ]
+  br i1 %t19, label %cond.rhs2, label %cond.done2
+cond.rhs2:
+  %t20 = load double, double* %v.x
+  %t21 = fcmp olt double %t20, 0x7FF0000000000000
+  br label %cond.done2
+cond.done2:
+  %t22 = phi i1 [ false, %cond.done3 ], [ %t21, %cond.rhs2 ]
+  br i1 %t22, label %cond.rhs1, label %cond.done1
+cond.rhs1:
+  %t23 = fsub double 0x7FF0000000000000, 0x7FF0000000000000
+  %t24 = fcmp oeq double %t23, 0x0000000000000000
+  %t25 = xor i1 %t24, true
+  br label %cond.done1
+cond.done1:
+  %t26 = phi i1 [ false, %cond.done2 ], [ %t25, %cond.rhs1 ]
+  store i1 %t26, i1* %v.b
+  %t27 = load i32, i32* %v.i
+  call i32 (i8*, ...) @printf(i8* getelementptr inbounds ([8 x i8], [8 x i8]* @.str.0, i64 0, i64 0), i32 %t27)
+  %t28 = load i32, i32* %v.s
+  call i32 (i8*, ...) @printf(i8* getelementptr inbounds ([8 x i8], [8 x i8]* @.str.1, i64 0, i64 0), i32 %t28)
+  %t29 = load i32, i32* %v.m
+  call i32 (i8*, ...) @printf(i8* getelementptr inbounds ([8 x i8], [8 x i8]* @.str.2, i64 0, i64 0), i32 %t29)
+  %t30 = load i8, i8* %v.c
+  %t31 = icmp ult i8 %t30, 128
+  %t32 = lshr i8 %t30, 6
+  %t33 = or i8 %t32, 192
+  %t34 = and i8 %t30, 63
+  %t35 = or i8 %t34, 128
+  %t36 = select i1 %t31, i8 %t30, i8 %t33
+  %t37 = zext i8 %t36 to i32
+  %t38 = zext i8 %t35 to i32
+  %t39 = select i1 %t31, i8* getelementptr inbounds ([8 x i8], [8 x i8]* @.str.3, i64 0, i64 0), i8* getelementptr inbounds ([10 x i8], [10 x i8]* @.str.3.utf8, i64 0, i64 0)
+  call i32 (i8*, ...) @printf(i8* %t39, i32 %t37, i32 %t38)
+  %t40 = load double, double* %v.x
+  %t41 = fcmp uno double %t40, %t40
+  %t42 = fcmp oeq double %t40, 0x7FF0000000000000
+  %t43 = fcmp oeq double %t40, 0xFFF0000000000000
+  %t44 = or i1 %t42, %t43
+  %t45 = or i1 %t41, %t44
+  br i1 %t45, label %print.special1, label %print.finite1
+print.special1:
+  %t46 = select i1 %t42, i8* getelementptr inbounds ([5 x i8], [5 x i8]* @.str.posinf, i64 0, i64 0), i8* getelementptr inbounds ([5 x i8], [5 x i8]* @.str.neginf, i64 0, i64 0)
+  %t47 = select i1 %t41, i8* getelementptr inbounds ([4 x i8], [4 x i8]* @.str.nan, i64 0, i64 0), i8* %t46
+  call i32 (i8*, ...) @printf(i8* getelementptr inbounds ([8 x i8], [8 x i8]* @.str.4.special, i64 0, i64 0), i8* %t47)
+  br label %print.done1
+print.finite1:
+  call i32 (i8*, ...) @printf(i8* getelementptr inbounds ([8 x i8], [8 x i8]* @.str.4, i64 0, i64 0), double %t40)
+  br label %print.done1
+print.done1:
+  %t48 = load i1, i1* %v.b
+  %t49 = select i1 %t48, i8* getelementptr inbounds ([5 x i8], [5 x i8]* @.str.true, i64 0, i64 0), i8* getelementptr inbounds ([6 x i8], [6 x i8]* @.str.false, i64 0, i64 0)
+  call i32 (i8*, ...) @printf(i8* getelementptr inbounds ([8 x i8], [8 x i8]* @.str.5, i64 0, i64 0), i8* %t49)
+  ret i32 0
+}
@@ -0,0 +1,78 @@
+@.str.0 = private unnamed_addr constant [8 x i8] c"a = %d\0A\00"
+@.str.1 = private unnamed_addr constant [8 x i8] c"b = %d\0A\00"
+@.str.2 = private unnamed_addr constant [8 x i8] c"t = %d\0A\00"
+@.str.3 = private unnamed_addr constant [12 x i8] c"steps = %d\0A\00"
+@.str.true = private unnamed_addr constant [5 x i8] c"true\00"
+@.str.false = private unnamed_addr constant [6 x i8] c"false\00"
+@.str.posinf = private unnamed_addr constant [5 x i8] c"+Inf\00"
+@.str.neginf = private unnamed_addr constant [5 x i8] c"-Inf\00"
+@.str.nan = private unnamed_addr constant [4 x i8] c"NaN\00"
+
+declare i32 @printf(i8*, ...)
+declare void @llvm.trap()
+
+define internal i32 @div(i32 %x, i32 %y) {
+entry:
+  %zero = icmp eq i32 %y, 0
+  br i1 %zero, label %trap, label %nonzero
+trap:
+  call void @llvm.trap()
+  unreachable
+nonzero:
+  %minus1 = icmp eq i32 %y, -1
+  br i1 %minus1, label %neg, label %quo
+neg:
+  ; -1<<31 / -1 overflows
+  %n = sub i32 0, %x
+  ret i32 %n
+quo:
+  %q = sdiv i32 %x, %y
+  ret i32 %q
+}
+
+define i32 @main() {
+entry:
+  %v.a = alloca i32
+  %v.b = alloca i32
+  %v.t = alloca i32
+  %v.steps = alloca i32
+  store i32 0, i32* %v.a
+  store i32 0, i32* %v.b
+  store i32 0, i32* %v.t
+  store i32 0, i32* %v.steps
+  store i32 1071, i32* %v.a
+  store i32 462, i32* %v.b
+  store i32 0, i32* %v.steps
+  br label %loop.head1
+loop.head1:
+  %t1 = load i32, i32* %v.b
+  %t2 = icmp ne i32 %t1, 0
+  br i1 %t2, label %loop.body1, label %loop.done1
+loop.body1:
+  %t3 = load i32, i32* %v.b
+  store i32 %t3, i32* %v.t
+  %t4 = load i32, i32* %v.a
+  %t5 = load i32, i32* %v.a
+  %t6 = load i32, i32* %v.b
+  %t7 = call i32 @div(i32 %t5, i32 %t6)
+  %t8 = load i32, i32* %v.b
+  %t9 = mul i32 %t7, %t8
+  %t10 = sub i32 %t4, %t9
+  store i32 %t10, i32* %v.b
+  %t11 = load i32, i32* %v.t
+  store i32 %t11, i32* %v.a
+  %t12 = load i32, i32* %v.steps
+  %t13 = add i32 %t12, 1
+  store i32 %t13, i32* %v.steps
+  br label %loop.head1
+loop.done1:
+  %t14 = load i32, i32* %v.a
+  call i32 (i8*, ...) @printf(i8* getelementptr inbounds ([8 x i8], [8 x i8]* @.str.0, i64 0, i64 0), i32 %t14)
+  %t15 = load i32, i32* %v.b
+  call i32 (i8*, ...) @printf(i8* getelementptr inbounds ([8 x i8], [8 x i8]* @.str.1, i64 0, i64 0), i32 %t15)
+  %t16 = load i32, i32* %v.t
+  call i32 (i8*, ...) @printf(i8* getelementptr inbounds ([8 x i8], [8 x i8]* @.str.2, i64 0, i64 0), i32 %t16)
+  %t17 = load i32, i32* %v.steps
+  call i32 (i8*, ...) @printf(i8* getelementptr inbounds ([12 x i8], [12 x i8]* @.str.3, i64 0, i64 0), i32 %t17)
+  ret i32 0
+}
@@ -0,0 +1,111 @@
+@.str.0 = private unnamed_addr constant [8 x i8] c"h = %d\0A\00"
+@.str.1 = private unnamed_addr constant [8 x i8] c"o = %d\0A\00"
+@.str.2 = private unnamed_addr constant [8 x i8] c"b = %d\0A\00"
+@.str.3 = private unnamed_addr constant [8 x i8] c"d = %d\0A\00"
+@.str.4 = private unnamed_addr constant [8 x i8] c"m = %d\0A\00"
+@.str.5 = private unnamed_addr constant [8 x i8] c"f = %f\0A\00"
+@.str.5.special = private unnamed_addr constant [8 x i8] c"f = %s\0A\00"
+@.str.6 = private unnamed_addr constant [8 x i8] c"e = %f\0A\00"
+@.str.6.special = private unnamed_addr constant [8 x i8] c"e = %s\0A\00"
+@.str.7 = private unnamed_addr constant [8 x i8] c"s = %f\0A\00"
+@.str.7.special = private unnamed_addr constant [8 x i8] c"s = %s\0A\00"
+@.str.true = private unnamed_addr constant [5 x i8] c"true\00"
+@.str.false = private unnamed_addr constant [6 x i8] c"false\00"
+@.str.posinf = private unnamed_addr constant [5 x i8] c"+Inf\00"
+@.str.neginf = private unnamed_addr constant [5 x i8] c"-Inf\00"
+@.str.nan = private unnamed_addr constant [4 x i8] c"NaN\00"
+
+declare i32 @printf(i8*, ...)
+
+define i32 @main() {
+entry:
+  %v.h = alloca i32
+  %v.o = alloca i32
+  %v.b = alloca i32
+  %v.d = alloca i32
+  %v.m = alloca i32
+  %v.f = alloca double
+  %v.e = alloca double
+  %v.s = alloca double
+  store i32 0, i32* %v.h
+  store i32 0, i32* %v.o
+  store i32 0, i32* %v.b
+  store i32 0, i32* %v.d
+  store i32 0, i32* %v.m
+  store double 0.0, double* %v.f
+  store double 0.0, double* %v.e
+  store double 0.0, double* %v.s
+  %t1 = add i32 255, 2147483647
+  store i32 %t1, i32* %v.h
+  %t2 = add i32 15, 15
+  store i32 %t2, i32* %v.o
+  store i32 170, i32* %v.b
+  store i32 1000000, i32* %v.d
+  %t3 = sub i32 0, 2147483647
+  %t4 = sub i32 %t3, 1
+  store i32 %t4, i32* %v.m
+  %t5 = fadd double 0x3FE0000000000000, 0x3FF0000000000000
+  store double %t5, double* %v.f
+  %t6 = fmul double 0x3F50624DD2F1A9FC, 0x40A3880000000000
+  store double %t6, double* %v.e
+  store double 0x4024800000000000, double* %v.s
+  %t7 = load i32, i32* %v.h
+  call i32 (i8*, ...) @printf(i8* getelementptr inbounds ([8 x i8], [8 x i8]* @.str.0, i64 0, i64 0), i32 %t7)
+  %t8 = load i32, i32* %v.o
+  call i32 (i8*, ...) @printf(i8* getelementptr inbounds ([8 x i8], [8 x i8]* @.str.1, i64 0, i64 0), i32 %t8)
+  %t9 = load i32, i32* %v.b
+  call i32 (i8*, ...) @printf(i8* getelementptr inbounds ([8 x i8], [8 x i8]* @.str.2, i64 0, i64 0), i32 %t9)
+  %t10 = load i32, i32* %v.d
+  call i32 (i8*, ...) @printf(i8* getelementptr inbounds ([8 x i8], [8 x i8]* @.str.3, i64 0, i64 0), i32 %t10)
+  %t11 = load i32, i32* %v.m
+  call i32 (i8*, ...) @printf(i8* getelementptr inbounds ([8 x i8], [8 x i8]* @.str.4, i64 0, i64 0), i32 %t11)
+  %t12 = load double, double* %v.f
+  %t13 = fcmp uno double %t12, %t12
+  %t14 = fcmp oeq double %t12, 0x7FF0000000000000
+  %t15 = fcmp oeq double %t12, 0xFFF0000000000000
+  %t16 = or i1 %t14, %t15
+  %t17 = or i1 %t13, %t16
+  br i1 %t17, label %print.special1, label %print.finite1
+print.special1:
+  %t18 = select i1 %t14, i8* getelementptr inbounds ([5 x i8], [5 x i8]* @.str.posinf, i64 0, i64 0), i8* getelementptr inbounds ([5 x i8], [5 x i8]* @.str.neginf, i64 0, i64 0)
+  %t19 = select i1 %t13, i8* getelementptr inbounds ([4 x i8], [4 x i8]* @.str.nan, i64 0, i64 0), i8* %t18
+  call i32 (i8*, ...) @printf(i8* getelementptr inbounds ([8 x i8], [8 x i8]* @.str.5.special, i64 0, i64 0), i8* %t19)
+  br label %print.done1
+print.finite1:
+  call i32 (i8*, ...) @printf(i8* getelementptr inbounds ([8 x i8], [8 x i8]* @.str.5, i64 0, i64 0), double %t12)
+  br label %print.done1
+print.done1:
+  %t20 = load double, double* %v.e
+  %t21 = fcmp uno double %t20, %t20
+  %t22 = fcmp oeq double %t20, 0x7FF0000000000000
+  %t23 = fcmp oeq double %t20, 0xFFF0000000000000
+  %t24 = or i1 %t22, %t23
+  %t25 = or i1 %t21, %t24
+  br i1 %t25, label %print.special2, label %print.finite2
+print.special2:
+  %t26 = select i1 %t22, i8* getelementptr inbounds ([5 x i8], [5 x i8]* @.str.posinf, i64 0, i64 0), i8* getelementptr inbounds ([5 x i8], [5 x i8]* @.str.neginf, i64 0, i64 0)
+  %t27 = select i1 %t21, i8* getelementptr inbounds ([4 x i8], [4 x i8]* @.str.nan, i64 0, i64 0), i8* %t26
+  call i32 (i8*, ...) @printf(i8* getelementptr inbounds ([8 x i8], [8 x i8]* @.str.6.special, i64 0, i64 0), i8* %t27)
+  br label %print.done2
+print.finite2:
+  call i32 (i8*, ...) @printf(i8* getelementptr inbounds ([8 x i8], [8 x i8]* @.str.6, i64 0, i64 0), double %t20)
+  br label %print.done2
+print.done2:
+  %t28 = load double, double* %v.s
+  %t29 = fcmp uno double %t28, %t28
+  %t30 = fcmp oeq double %t28, 0x7FF0000000000000
+  %t31 = fcmp oeq double %t28, 0xFFF0000000000000
+  %t32 = or i1 %t30, %t31
+  %t33 = or i1 %t29, %t32
+  br i1 %t33, label %print.special3, label %print.finite3
+print.special3:
+  %t34 = select i1 %t30, i8* getelementptr inbounds ([5 x i8], [5 x i8]* @.str.posinf, i64 0, i64 0), i8* getelementptr inbounds ([5 x i8], [5 x i8]* @.str.neginf, i64 0, i64 0)
+  %t35 = select i1 %t29, i8* getelementptr inbounds ([4 x i8], [4 x i8]* @.str.nan, i64 0, i64 0), i8* %t34
+  call i32 (i8*, ...) @printf(i8* getelementptr inbounds ([8 x i8], [8 x i8]* @.str.7.special, i64 0, i64 0), i8* %t35)
+  br label %print.done3
+print.finite3:
+  call i32 (i8*, ...) @printf(i8* getelementptr inbounds ([8 x i8], [8 x i8]* @.str.7, i64 0, i64 0), double %t28)
+  br label %print.done3
+print.done3:
+  ret i32 0
+}
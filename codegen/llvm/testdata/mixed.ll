@@ -0,0 +1,201 @@
+@.str.0 = private unnamed_addr constant [8 x i8] c"i = %d\0A\00"
+@.str.1 = private unnamed_addr constant [8 x i8] c"n = %d\0A\00"
+@.str.2 = private unnamed_addr constant [8 x i8] c"f = %d\0A\00"
+@.str.3 = private unnamed_addr constant [8 x i8] c"x = %f\0A\00"
+@.str.3.special = private unnamed_addr constant [8 x i8] c"x = %s\0A\00"
+@.str.4 = private unnamed_addr constant [8 x i8] c"y = %f\0A\00"
+@.str.4.special = private unnamed_addr constant [8 x i8] c"y = %s\0A\00"
+@.str.5 = private unnamed_addr constant [8 x i8] c"c = %c\0A\00"
+@.str.5.utf8 = private unnamed_addr constant [10 x i8] c"c = %c%c\0A\00"
+@.str.6 = private unnamed_addr constant [8 x i8] c"b = %s\0A\00"
+@.str.7 = private unnamed_addr constant [8 x i8] c"e = %s\0A\00"
+@.str.true = private unnamed_addr constant [5 x i8] c"true\00"
+@.str.false = private unnamed_addr constant [6 x i8] c"false\00"
+@.str.posinf = private unnamed_addr constant [5 x i8] c"+Inf\00"
+@.str.neginf = private unnamed_addr constant [5 x i8] c"-Inf\00"
+@.str.nan = private unnamed_addr constant [4 x i8] c"NaN\00"
+
+declare i32 @printf(i8*, ...)
+declare void @llvm.trap()
+
+define internal i32 @div(i32 %x, i32 %y) {
+entry:
+  %zero = icmp eq i32 %y, 0
+  br i1 %zero, label %trap, label %nonzero
+trap:
+  call void @llvm.trap()
+  unreachable
+nonzero:
+  %minus1 = icmp eq i32 %y, -1
+  br i1 %minus1, label %neg, label %quo
+neg:
+  ; -1<<31 / -1 overflows
+  %n = sub i32 0, %x
+  ret i32 %n
+quo:
+  %q = sdiv i32 %x, %y
+  ret i32 %q
+}
+
+define internal i32 @ftoi(double %x) {
+entry:
+  %lo = fcmp oge double %x, -2147483648.0
+  %hi = fcmp olt double %x, 2147483648.0
+  %in = and i1 %lo, %hi
+  %t = fptosi double %x to i32
+  %r = select i1 %in, i32 %t, i32 -2147483648
+  ret i32 %r
+}
+
+define i32 @main() {
+entry:
+  %v.i = alloca i32
+  %v.n = alloca i32
+  %v.f = alloca i32
+  %v.x = alloca double
+  %v.y = alloca double
+  %v.c = alloca i8
+  %v.b = alloca i1
+  %v.e = alloca i1
+  store i32 0, i32* %v.i
+  store i32 0, i32* %v.n
+  store i32 0, i32* %v.f
+  store double 0.0, double* %v.x
+  store double 0.0, double* %v.y
+  store i8 0, i8* %v.c
+  store i1 false, i1* %v.b
+  store i1 false, i1* %v.e
+  store i32 10, i32* %v.n
+  store i32 0, i32* %v.i
+  store i32 1, i32* %v.f
+  br label %loop.head1
+loop.head1:
+  %t1 = load i32, i32* %v.i
+  %t2 = load i32, i32* %v.n
+  %t3 = icmp slt i32 %t1, %t2
+  br i1 %t3, label %loop.body1, label %loop.done1
+loop.body1:
+  %t4 = load i32, i32* %v.i
+  %t5 = add i32 %t4, 1
+  store i32 %t5, i32* %v.i
+  %t6 = load i32, i32* %v.i
+  %t7 = icmp sle i32 %t6, 5
+  br i1 %t7, label %if.then1, label %if.else1
+if.then1:
+  %t8 = load i32, i32* %v.f
+  %t9 = load i32, i32* %v.i
+  %t10 = mul i32 %t8, %t9
+  store i32 %t10, i32* %v.f
+  br label %if.done1
+if.else1:
+  %t11 = load i32, i32* %v.f
+  %t12 = load i32, i32* %v.i
+  %t13 = call i32 @div(i32 %t12, i32 2)
+  %t14 = sub i32 %t11, %t13
+  store i32 %t14, i32* %v.f
+  br label %if.done1
+if.done1:
+  br label %loop.head1
+loop.done1:
+  store double 0x3FF8000000000000, double* %v.x
+  %t15 = load double, double* %v.x
+  %t16 = load i32, i32* %v.i
+  %t17 = sitofp i32 %t16 to double
+  %t18 = fmul double %t15, %t17
+  %t19 = fadd double %t18, 0x3FD0000000000000
+  store double %t19, double* %v.y
+  %t20 = load double, double* %v.y
+  %t21 = fneg double %t20
+  %t22 = sitofp i32 2 to double
+  %t23 = fdiv double %t21, %t22
+  store double %t23, double* %v.x
+  %t24 = load i32, i32* %v.i
+  %t25 = add i32 65, %t24
+  %t26 = trunc i32 %t25 to i8
+  store i8 %t26, i8* %v.c
+  %t27 = load double, double* %v.y
+  %t28 = call i32 @ftoi(double %t27)
+  %t29 = load i8, i8* %v.c
+  %t30 = zext i8 %t29 to i32
+  %t31 = add i32 %t28, %t30
+  store i32 %t31, i32* %v.i
+  %t32 = load double, double* %v.x
+  %t33 = fcmp olt double %t32, 0x0000000000000000
+  br i1 %t33, label %cond.rhs2, label %cond.done2
+cond.rhs2:
+  %t34 = load i32, i32* %v.i
+  %t35 = icmp eq i32 %t34, 90
+  %t36 = xor i1 %t35, true
+  br label %cond.done2
+cond.done2:
+  %t37 = phi i1 [ false, %loop.done1 ], [ %t36, %cond.rhs2 ]
+  br i1 %t37, label %cond.done1, label %cond.rhs1
+cond.rhs1:
+  %t38 = load i8, i8* %v.c
+  %t39 = icmp ugt i8 %t38, 65
+  br label %cond.done1
+cond.done1:
+  %t40 = phi i1 [ true, %cond.done2 ], [ %t39, %cond.rhs1 ]
+  store i1 %t40, i1* %v.b
+  %t41 = load i32, i32* %v.n
+  %t42 = sitofp i32 %t41 to double
+  %t43 = load double, double* %v.y
+  %t44 = fcmp oeq double %t42, %t43
+  store i1 %t44, i1* %v.e
+  %t45 = load i32, i32* %v.i
+  call i32 (i8*, ...) @printf(i8* getelementptr inbounds ([8 x i8], [8 x i8]* @.str.0, i64 0, i64 0), i32 %t45)
+  %t46 = load i32, i32* %v.n
+  call i32 (i8*, ...) @printf(i8* getelementptr inbounds ([8 x i8], [8 x i8]* @.str.1, i64 0, i64 0), i32 %t46)
+  %t47 = load i32, i32* %v.f
+  call i32 (i8*, ...) @printf(i8* getelementptr inbounds ([8 x i8], [8 x i8]* @.str.2, i64 0, i64 0), i32 %t47)
+  %t48 = load double, double* %v.x
+  %t49 = fcmp uno double %t48, %t48
+  %t50 = fcmp oeq double %t48, 0x7FF0000000000000
+  %t51 = fcmp oeq double %t48, 0xFFF0000000000000
+  %t52 = or i1 %t50, %t51
+  %t53 = or i1 %t49, %t52
+  br i1 %t53, label %print.special1, label %print.finite1
+print.special1:
+  %t54 = select i1 %t50, i8* getelementptr inbounds ([5 x i8], [5 x i8]* @.str.posinf, i64 0, i64 0), i8* getelementptr inbounds ([5 x i8], [5 x i8]* @.str.neginf, i64 0, i64 0)
+  %t55 = select i1 %t49, i8* getelementptr inbounds ([4 x i8], [4 x i8]* @.str.nan, i64 0, i64 0), i8* %t54
+  call i32 (i8*, ...) @printf(i8* getelementptr inbounds ([8 x i8], [8 x i8]* @.str.3.special, i64 0, i64 0), i8* %t55)
+  br label %print.done1
+print.finite1:
+  call i32 (i8*, ...) @printf(i8* getelementptr inbounds ([8 x i8], [8 x i8]* @.str.3, i64 0, i64 0), double %t48)
+  br label %print.done1
+print.done1:
+  %t56 = load double, double* %v.y
+  %t57 = fcmp uno double %t56, %t56
+  %t58 = fcmp oeq double %t56, 0x7FF0000000000000
+  %t59 = fcmp oeq double %t56, 0xFFF0000000000000
+  %t60 = or i1 %t58, %t59
+  %t61 = or i1 %t57, %t60
+  br i1 %t61, label %print.special2, label %print.finite2
+print.special2:
+  %t62 = select i1 %t58, i8* getelementptr inbounds ([5 x i8], [5 x i8]* @.str.posinf, i64 0, i64 0), i8* getelementptr inbounds ([5 x i8], [5 x i8]* @.str.neginf, i64 0, i64 0)
+  %t63 = select i1 %t57, i8* getelementptr inbounds ([4 x i8], [4 x i8]* @.str.nan, i64 0, i64 0), i8* %t62
+  call i32 (i8*, ...) @printf(i8* getelementptr inbounds ([8 x i8], [8 x i8]* @.str.4.special, i64 0, i64 0), i8* %t63)
+  br label %print.done2
+print.finite2:
+  call i32 (i8*, ...) @printf(i8* getelementptr inbounds ([8 x i8], [8 x i8]* @.str.4, i64 0, i64 0), double %t56)
+  br label %print.done2
+print.done2:
+  %t64 = load i8, i8* %v.c
+  %t65 = icmp ult i8 %t64, 128
+  %t66 = lshr i8 %t64, 6
+  %t67 = or i8 %t66, 192
+  %t68 = and i8 %t64, 63
+  %t69 = or i8 %t68, 128
+  %t70 = select i1 %t65, i8 %t64, i8 %t67
+  %t71 = zext i8 %t70 to i32
+  %t72 = zext i8 %t69 to i32
+  %t73 = select i1 %t65, i8* getelementptr inbounds ([8 x i8], [8 x i8]* @.str.5, i64 0, i64 0), i8* getelementptr inbounds ([10 x i8], [10 x i8]* @.str.5.utf8, i64 0, i64 0)
+  call i32 (i8*, ...) @printf(i8* %t73, i32 %t71, i32 %t72)
+  %t74 = load i1, i1* %v.b
+  %t75 = select i1 %t74, i8* getelementptr inbounds ([5 x i8], [5 x i8]* @.str.true, i64 0, i64 0), i8* getelementptr inbounds ([6 x i8], [6 x i8]* @.str.false, i64 0, i64 0)
+  call i32 (i8*, ...) @printf(i8* getelementptr inbounds ([8 x i8], [8 x i8]* @.str.6, i64 0, i64 0), i8* %t75)
+  %t76 = load i1, i1* %v.e
+  %t77 = select i1 %t76, i8* getelementptr inbounds ([5 x i8], [5 x i8]* @.str.true, i64 0, i64 0), i8* getelementptr inbounds ([6 x i8], [6 x i8]* @.str.false, i64 0, i64 0)
+  call i32 (i8*, ...) @printf(i8* getelementptr inbounds ([8 x i8], [8 x i8]* @.str.7, i64 0, i64 0), i8* %t77)
+  ret i32 0
+}
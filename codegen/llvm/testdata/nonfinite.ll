@@ -0,0 +1,126 @@
+@.str.0 = private unnamed_addr constant [8 x i8] c"z = %f\0A\00"
+@.str.0.special = private unnamed_addr constant [8 x i8] c"z = %s\0A\00"
+@.str.1 = private unnamed_addr constant [8 x i8] c"p = %f\0A\00"
+@.str.1.special = private unnamed_addr constant [8 x i8] c"p = %s\0A\00"
+@.str.2 = private unnamed_addr constant [8 x i8] c"n = %f\0A\00"
+@.str.2.special = private unnamed_addr constant [8 x i8] c"n = %s\0A\00"
+@.str.3 = private unnamed_addr constant [8 x i8] c"q = %f\0A\00"
+@.str.3.special = private unnamed_addr constant [8 x i8] c"q = %s\0A\00"
+@.str.4 = private unnamed_addr constant [8 x i8] c"r = %f\0A\00"
+@.str.4.special = private unnamed_addr constant [8 x i8] c"r = %s\0A\00"
+@.str.true = private unnamed_addr constant [5 x i8] c"true\00"
+@.str.false = private unnamed_addr constant [6 x i8] c"false\00"
+@.str.posinf = private unnamed_addr constant [5 x i8] c"+Inf\00"
+@.str.neginf = private unnamed_addr constant [5 x i8] c"-Inf\00"
+@.str.nan = private unnamed_addr constant [4 x i8] c"NaN\00"
+
+declare i32 @printf(i8*, ...)
+
+define i32 @main() {
+entry:
+  %v.z = alloca double
+  %v.p = alloca double
+  %v.n = alloca double
+  %v.q = alloca double
+  %v.r = alloca double
+  store double 0.0, double* %v.z
+  store double 0.0, double* %v.p
+  store double 0.0, double* %v.n
+  store double 0.0, double* %v.q
+  store double 0.0, double* %v.r
+  %t1 = load double, double* %v.z
+  %t2 = fdiv double 0x3FF0000000000000, %t1
+  store double %t2, double* %v.p
+  %t3 = fneg double 0x3FF0000000000000
+  %t4 = load double, double* %v.z
+  %t5 = fdiv double %t3, %t4
+  store double %t5, double* %v.n
+  %t6 = load double, double* %v.z
+  %t7 = load double, double* %v.z
+  %t8 = fdiv double %t6, %t7
+  store double %t8, double* %v.q
+  %t9 = load double, double* %v.q
+  %t10 = fneg double %t9
+  store double %t10, double* %v.r
+  %t11 = load double, double* %v.z
+  %t12 = fcmp uno double %t11, %t11
+  %t13 = fcmp oeq double %t11, 0x7FF0000000000000
+  %t14 = fcmp oeq double %t11, 0xFFF0000000000000
+  %t15 = or i1 %t13, %t14
+  %t16 = or i1 %t12, %t15
+  br i1 %t16, label %print.special1, label %print.finite1
+print.special1:
+  %t17 = select i1 %t13, i8* getelementptr inbounds ([5 x i8], [5 x i8]* @.str.posinf, i64 0, i64 0), i8* getelementptr inbounds ([5 x i8], [5 x i8]* @.str.neginf, i64 0, i64 0)
+  %t18 = select i1 %t12, i8* getelementptr inbounds ([4 x i8], [4 x i8]* @.str.nan, i64 0, i64 0), i8* %t17
+  call i32 (i8*, ...) @printf(i8* getelementptr inbounds ([8 x i8], [8 x i8]* @.str.0.special, i64 0, i64 0), i8* %t18)
+  br label %print.done1
+print.finite1:
+  call i32 (i8*, ...) @printf(i8* getelementptr inbounds ([8 x i8], [8 x i8]* @.str.0, i64 0, i64 0), double %t11)
+  br label %print.done1
+print.done1:
+  %t19 = load double, double* %v.p
+  %t20 = fcmp uno double %t19, %t19
+  %t21 = fcmp oeq double %t19, 0x7FF0000000000000
+  %t22 = fcmp oeq double %t19, 0xFFF0000000000000
+  %t23 = or i1 %t21, %t22
+  %t24 = or i1 %t20, %t23
+  br i1 %t24, label %print.special2, label %print.finite2
+print.special2:
+  %t25 = select i1 %t21, i8* getelementptr inbounds ([5 x i8], [5 x i8]* @.str.posinf, i64 0, i64 0), i8* getelementptr inbounds ([5 x i8], [5 x i8]* @.str.neginf, i64 0, i64 0)
+  %t26 = select i1 %t20, i8* getelementptr inbounds ([4 x i8], [4 x i8]* @.str.nan, i64 0, i64 0), i8* %t25
+  call i32 (i8*, ...) @printf(i8* getelementptr inbounds ([8 x i8], [8 x i8]* @.str.1.special, i64 0, i64 0), i8* %t26)
+  br label %print.done2
+print.finite2:
+  call i32 (i8*, ...) @printf(i8* getelementptr inbounds ([8 x i8], [8 x i8]* @.str.1, i64 0, i64 0), double %t19)
+  br label %print.done2
+print.done2:
+  %t27 = load double, double* %v.n
+  %t28 = fcmp uno double %t27, %t27
+  %t29 = fcmp oeq double %t27, 0x7FF0000000000000
+  %t30 = fcmp oeq double %t27, 0xFFF0000000000000
+  %t31 = or i1 %t29, %t30
+  %t32 = or i1 %t28, %t31
+  br i1 %t32, label %print.special3, label %print.finite3
+print.special3:
+  %t33 = select i1 %t29, i8* getelementptr inbounds ([5 x i8], [5 x i8]* @.str.posinf, i64 0, i64 0), i8* getelementptr inbounds ([5 x i8], [5 x i8]* @.str.neginf, i64 0, i64 0)
+  %t34 = select i1 %t28, i8* getelementptr inbounds ([4 x i8], [4 x i8]* @.str.nan, i64 0, i64 0), i8* %t33
+  call i32 (i8*, ...) @printf(i8* getelementptr inbounds ([8 x i8], [8 x i8]* @.str.2.special, i64 0, i64 0), i8* %t34)
+  br label %print.done3
+print.finite3:
+  call i32 (i8*, ...) @printf(i8* getelementptr inbounds ([8 x i8], [8 x i8]* @.str.2, i64 0, i64 0), double %t27)
+  br label %print.done3
+print.done3:
+  %t35 = load double, double* %v.q
+  %t36 = fcmp uno double %t35, %t35
+  %t37 = fcmp oeq double %t35, 0x7FF0000000000000
+  %t38 = fcmp oeq double %t35, 0xFFF0000000000000
+  %t39 = or i1 %t37, %t38
+  %t40 = or i1 %t36, %t39
+  br i1 %t40, label %print.special4, label %print.finite4
+print.special4:
+  %t41 = select i1 %t37, i8* getelementptr inbounds ([5 x i8], [5 x i8]* @.str.posinf, i64 0, i64 0), i8* getelementptr inbounds ([5 x i8], [5 x i8]* @.str.neginf, i64 0, i64 0)
+  %t42 = select i1 %t36, i8* getelementptr inbounds ([4 x i8], [4 x i8]* @.str.nan, i64 0, i64 0), i8* %t41
+  call i32 (i8*, ...) @printf(i8* getelementptr inbounds ([8 x i8], [8 x i8]* @.str.3.special, i64 0, i64 0), i8* %t42)
+  br label %print.done4
+print.finite4:
+  call i32 (i8*, ...) @printf(i8* getelementptr inbounds ([8 x i8], [8 x i8]* @.str.3, i64 0, i64 0), double %t35)
+  br label %print.done4
+print.done4:
+  %t43 = load double, double* %v.r
+  %t44 = fcmp uno double %t43, %t43
+  %t45 = fcmp oeq double %t43, 0x7FF0000000000000
+  %t46 = fcmp oeq double %t43, 0xFFF0000000000000
+  %t47 = or i1 %t45, %t46
+  %t48 = or i1 %t44, %t47
+  br i1 %t48, label %print.special5, label %print.finite5
+print.special5:
+  %t49 = select i1 %t45, i8* getelementptr inbounds ([5 x i8], [5 x i8]* @.str.posinf, i64 0, i64 0), i8* getelementptr inbounds ([5 x i8], [5 x i8]* @.str.neginf, i64 0, i64 0)
+  %t50 = select i1 %t44, i8* getelementptr inbounds ([4 x i8], [4 x i8]* @.str.nan, i64 0, i64 0), i8* %t49
+  call i32 (i8*, ...) @printf(i8* getelementptr inbounds ([8 x i8], [8 x i8]* @.str.4.special, i64 0, i64 0), i8* %t50)
+  br label %print.done5
+print.finite5:
+  call i32 (i8*, ...) @printf(i8* getelementptr inbounds ([8 x i8], [8 x i8]* @.str.4, i64 0, i64 0), double %t43)
+  br label %print.done5
+print.done5:
+  ret i32 0
+}
@@ -0,0 +1,225 @@
+@.str.0 = private unnamed_addr constant [8 x i8] c"i = %d\0A\00"
+@.str.1 = private unnamed_addr constant [12 x i8] c"small = %d\0A\00"
+@.str.2 = private unnamed_addr constant [10 x i8] c"big = %d\0A\00"
+@.str.3 = private unnamed_addr constant [10 x i8] c"odd = %d\0A\00"
+@.str.4 = private unnamed_addr constant [12 x i8] c"other = %d\0A\00"
+@.str.5 = private unnamed_addr constant [9 x i8] c"ft = %d\0A\00"
+@.str.6 = private unnamed_addr constant [11 x i8] c"none = %d\0A\00"
+@.str.7 = private unnamed_addr constant [10 x i8] c"sum = %d\0A\00"
+@.str.8 = private unnamed_addr constant [8 x i8] c"c = %c\0A\00"
+@.str.8.utf8 = private unnamed_addr constant [10 x i8] c"c = %c%c\0A\00"
+@.str.9 = private unnamed_addr constant [13 x i8] c"vowels = %d\0A\00"
+@.str.10 = private unnamed_addr constant [13 x i8] c"digits = %d\0A\00"
+@.str.true = private unnamed_addr constant [5 x i8] c"true\00"
+@.str.false = private unnamed_addr constant [6 x i8] c"false\00"
+@.str.posinf = private unnamed_addr constant [5 x i8] c"+Inf\00"
+@.str.neginf = private unnamed_addr constant [5 x i8] c"-Inf\00"
+@.str.nan = private unnamed_addr constant [4 x i8] c"NaN\00"
+
+declare i32 @printf(i8*, ...)
+declare void @llvm.trap()
+
+define internal i32 @rem(i32 %x, i32 %y) {
+entry:
+  %zero = icmp eq i32 %y, 0
+  br i1 %zero, label %trap, label %nonzero
+trap:
+  call void @llvm.trap()
+  unreachable
+nonzero:
+  %minus1 = icmp eq i32 %y, -1
+  br i1 %minus1, label %none, label %rem
+none:
+  ; -1<<31 % -1 overflows
+  ret i32 0
+rem:
+  %r = srem i32 %x, %y
+  ret i32 %r
+}
+
+define i32 @main() {
+entry:
+  %v.i = alloca i32
+  %v.small = alloca i32
+  %v.big = alloca i32
+  %v.odd = alloca i32
+  %v.other = alloca i32
+  %v.ft = alloca i32
+  %v.none = alloca i32
+  %v.sum = alloca i32
+  %v.c = alloca i8
+  %v.vowels = alloca i32
+  %v.digits = alloca i32
+  store i32 0, i32* %v.i
+  store i32 0, i32* %v.small
+  store i32 0, i32* %v.big
+  store i32 0, i32* %v.odd
+  store i32 0, i32* %v.other
+  store i32 0, i32* %v.ft
+  store i32 0, i32* %v.none
+  store i32 0, i32* %v.sum
+  store i8 0, i8* %v.c
+  store i32 0, i32* %v.vowels
+  store i32 0, i32* %v.digits
+  %t1 = sub i32 0, 3
+  store i32 %t1, i32* %v.i
+  br label %loop.head1
+loop.head1:
+  %t2 = load i32, i32* %v.i
+  %t3 = icmp slt i32 %t2, 13
+  br i1 %t3, label %loop.body1, label %loop.done1
+loop.body1:
+  %t4 = load i32, i32* %v.i
+  switch i32 %t4, label %switch.range1.0 [ i32 1, label %switch.case1.2 i32 3, label %switch.case1.2 i32 5, label %switch.case1.2 i32 7, label %switch.case1.2 i32 8, label %switch.case1.4 ]
+switch.range1.0:
+  %t5 = sub i32 %t4, -3
+  %t6 = icmp ule i32 %t5, 2
+  br i1 %t6, label %switch.case1.0, label %switch.range1.1
+switch.range1.1:
+  %t7 = sub i32 %t4, 10
+  %t8 = icmp ule i32 %t7, 2
+  br i1 %t8, label %switch.case1.3, label %switch.case1.1
+switch.case1.0:
+  %t9 = load i32, i32* %v.small
+  %t10 = load i32, i32* %v.i
+  %t11 = add i32 %t9, %t10
+  store i32 %t11, i32* %v.small
+  br label %switch.done1
+switch.case1.1:
+  %t12 = load i32, i32* %v.other
+  %t13 = add i32 %t12, 1
+  store i32 %t13, i32* %v.other
+  br label %switch.done1
+switch.case1.2:
+  %t14 = load i32, i32* %v.odd
+  %t15 = add i32 %t14, 1
+  store i32 %t15, i32* %v.odd
+  %t16 = load i32, i32* %v.i
+  %t17 = call i32 @rem(i32 %t16, i32 3)
+  switch i32 %t17, label %switch.done2 [ i32 0, label %switch.case2.0 i32 1, label %switch.case2.1 i32 2, label %switch.case2.2 ]
+switch.case2.0:
+  %t18 = load i32, i32* %v.sum
+  %t19 = add i32 %t18, 100
+  store i32 %t19, i32* %v.sum
+  br label %switch.done2
+switch.case2.1:
+  %t20 = load i32, i32* %v.sum
+  %t21 = add i32 %t20, 10
+  store i32 %t21, i32* %v.sum
+  br label %switch.case2.2
+switch.case2.2:
+  %t22 = load i32, i32* %v.sum
+  %t23 = add i32 %t22, 1
+  store i32 %t23, i32* %v.sum
+  br label %switch.done2
+switch.done2:
+  br label %switch.done1
+switch.case1.3:
+  %t24 = load i32, i32* %v.big
+  %t25 = mul i32 %t24, 2
+  %t26 = load i32, i32* %v.i
+  %t27 = add i32 %t25, %t26
+  store i32 %t27, i32* %v.big
+  br label %switch.case1.4
+switch.case1.4:
+  %t28 = load i32, i32* %v.ft
+  %t29 = add i32 %t28, 1
+  store i32 %t29, i32* %v.ft
+  br label %switch.done1
+switch.done1:
+  %t30 = load i32, i32* %v.i
+  switch i32 %t30, label %switch.range3.0 [ ]
+switch.range3.0:
+  %t31 = sub i32 %t30, 100
+  %t32 = icmp ule i32 %t31, 100
+  br i1 %t32, label %switch.case3.0, label %switch.done3
+switch.case3.0:
+  store i32 1, i32* %v.none
+  br label %switch.done3
+switch.done3:
+  %t33 = load i32, i32* %v.i
+  %t34 = add i32 %t33, 1
+  store i32 %t34, i32* %v.i
+  br label %loop.head1
+loop.done1:
+  store i8 48, i8* %v.c
+  br label %loop.head2
+loop.head2:
+  %t35 = load i8, i8* %v.c
+  %t36 = icmp ule i8 %t35, 122
+  br i1 %t36, label %loop.body2, label %loop.done2
+loop.body2:
+  %t37 = load i8, i8* %v.c
+  switch i8 %t37, label %switch.range4.0 [ i8 97, label %switch.case4.0 i8 101, label %switch.case4.0 i8 105, label %switch.case4.0 i8 111, label %switch.case4.0 i8 117, label %switch.case4.0 ]
+switch.range4.0:
+  %t38 = sub i8 %t37, 48
+  %t39 = icmp ule i8 %t38, 9
+  br i1 %t39, label %switch.case4.1, label %switch.done4
+switch.case4.0:
+  %t40 = load i32, i32* %v.vowels
+  %t41 = add i32 %t40, 1
+  store i32 %t41, i32* %v.vowels
+  br label %switch.done4
+switch.case4.1:
+  %t42 = load i32, i32* %v.digits
+  %t43 = add i32 %t42, 1
+  store i32 %t43, i32* %v.digits
+  br label %switch.done4
+switch.done4:
+  %t44 = load i8, i8* %v.c
+  %t45 = zext i8 %t44 to i32
+  %t46 = add i32 %t45, 1
+  %t47 = trunc i32 %t46 to i8
+  store i8 %t47, i8* %v.c
+  br label %loop.head2
+loop.done2:
+  %t48 = load i8, i8* %v.c
+  switch i8 %t48, label %switch.case5.0 [ i8 122, label %switch.case5.1 ]
+switch.case5.0:
+  %t49 = load i8, i8* %v.c
+  %t50 = zext i8 %t49 to i32
+  %t51 = add i32 %t50, 1
+  %t52 = trunc i32 %t51 to i8
+  store i8 %t52, i8* %v.c
+  br label %switch.case5.1
+switch.case5.1:
+  %t53 = load i8, i8* %v.c
+  %t54 = zext i8 %t53 to i32
+  %t55 = sub i32 %t54, 1
+  %t56 = trunc i32 %t55 to i8
+  store i8 %t56, i8* %v.c
+  br label %switch.done5
+switch.done5:
+  %t57 = load i32, i32* %v.i
+  call i32 (i8*, ...) @printf(i8* getelementptr inbounds ([8 x i8], [8 x i8]* @.str.0, i64 0, i64 0), i32 %t57)
+  %t58 = load i32, i32* %v.small
+  call i32 (i8*, ...) @printf(i8* getelementptr inbounds ([12 x i8], [12 x i8]* @.str.1, i64 0, i64 0), i32 %t58)
+  %t59 = load i32, i32* %v.big
+  call i32 (i8*, ...) @printf(i8* getelementptr inbounds ([10 x i8], [10 x i8]* @.str.2, i64 0, i64 0), i32 %t59)
+  %t60 = load i32, i32* %v.odd
+  call i32 (i8*, ...) @printf(i8* getelementptr inbounds ([10 x i8], [10 x i8]* @.str.3, i64 0, i64 0), i32 %t60)
+  %t61 = load i32, i32* %v.other
+  call i32 (i8*, ...) @printf(i8* getelementptr inbounds ([12 x i8], [12 x i8]* @.str.4, i64 0, i64 0), i32 %t61)
+  %t62 = load i32, i32* %v.ft
+  call i32 (i8*, ...) @printf(i8* getelementptr inbounds ([9 x i8], [9 x i8]* @.str.5, i64 0, i64 0), i32 %t62)
+  %t63 = load i32, i32* %v.none
+  call i32 (i8*, ...) @printf(i8* getelementptr inbounds ([11 x i8], [11 x i8]* @.str.6, i64 0, i64 0), i32 %t63)
+  %t64 = load i32, i32* %v.sum
+  call i32 (i8*, ...) @printf(i8* getelementptr inbounds ([10 x i8], [10 x i8]* @.str.7, i64 0, i64 0), i32 %t64)
+  %t65 = load i8, i8* %v.c
+  %t66 = icmp ult i8 %t65, 128
+  %t67 = lshr i8 %t65, 6
+  %t68 = or i8 %t67, 192
+  %t69 = and i8 %t65, 63
+  %t70 = or i8 %t69, 128
+  %t71 = select i1 %t66, i8 %t65, i8 %t68
+  %t72 = zext i8 %t71 to i32
+  %t73 = zext i8 %t70 to i32
+  %t74 = select i1 %t66, i8* getelementptr inbounds ([8 x i8], [8 x i8]* @.str.8, i64 0, i64 0), i8* getelementptr inbounds ([10 x i8], [10 x i8]* @.str.8.utf8, i64 0, i64 0)
+  call i32 (i8*, ...) @printf(i8* %t74, i32 %t72, i32 %t73)
+  %t75 = load i32, i32* %v.vowels
+  call i32 (i8*, ...) @printf(i8* getelementptr inbounds ([13 x i8], [13 x i8]* @.str.9, i64 0, i64 0), i32 %t75)
+  %t76 = load i32, i32* %v.digits
+  call i32 (i8*, ...) @printf(i8* getelementptr inbounds ([13 x i8], [13 x i8]* @.str.10, i64 0, i64 0), i32 %t76)
+  ret i32 0
+}
@@ -0,0 +1,252 @@
+@.str.0 = private unnamed_addr constant [8 x i8] c"i = %d\0A\00"
+@.str.1 = private unnamed_addr constant [8 x i8] c"n = %d\0A\00"
+@.str.2 = private unnamed_addr constant [8 x i8] c"q = %d\0A\00"
+@.str.3 = private unnamed_addr constant [11 x i8] c"sign = %d\0A\00"
+@.str.4 = private unnamed_addr constant [8 x i8] c"x = %f\0A\00"
+@.str.4.special = private unnamed_addr constant [8 x i8] c"x = %s\0A\00"
+@.str.5 = private unnamed_addr constant [8 x i8] c"y = %f\0A\00"
+@.str.5.special = private unnamed_addr constant [8 x i8] c"y = %s\0A\00"
+@.str.6 = private unnamed_addr constant [8 x i8] c"b = %s\0A\00"
+@.str.7 = private unnamed_addr constant [8 x i8] c"c = %c\0A\00"
+@.str.7.utf8 = private unnamed_addr constant [10 x i8] c"c = %c%c\0A\00"
+@.str.true = private unnamed_addr constant [5 x i8] c"true\00"
+@.str.false = private unnamed_addr constant [6 x i8] c"false\00"
+@.str.posinf = private unnamed_addr constant [5 x i8] c"+Inf\00"
+@.str.neginf = private unnamed_addr constant [5 x i8] c"-Inf\00"
+@.str.nan = private unnamed_addr constant [4 x i8] c"NaN\00"
+
+declare i32 @printf(i8*, ...)
+declare void @llvm.trap()
+
+define internal i32 @div(i32 %x, i32 %y) {
+entry:
+  %zero = icmp eq i32 %y, 0
+  br i1 %zero, label %trap, label %nonzero
+trap:
+  call void @llvm.trap()
+  unreachable
+nonzero:
+  %minus1 = icmp eq i32 %y, -1
+  br i1 %minus1, label %neg, label %quo
+neg:
+  ; -1<<31 / -1 overflows
+  %n = sub i32 0, %x
+  ret i32 %n
+quo:
+  %q = sdiv i32 %x, %y
+  ret i32 %q
+}
+
+define internal i32 @rem(i32 %x, i32 %y) {
+entry:
+  %zero = icmp eq i32 %y, 0
+  br i1 %zero, label %trap, label %nonzero
+trap:
+  call void @llvm.trap()
+  unreachable
+nonzero:
+  %minus1 = icmp eq i32 %y, -1
+  br i1 %minus1, label %none, label %rem
+none:
+  ; -1<<31 % -1 overflows
+  ret i32 0
+rem:
+  %r = srem i32 %x, %y
+  ret i32 %r
+}
+
+define i32 @main() {
+entry:
+  %v.i = alloca i32
+  %v.n = alloca i32
+  %v.q = alloca i32
+  %v.sign = alloca i32
+  %v.x = alloca double
+  %v.y = alloca double
+  %v.b = alloca i1
+  %v.c = alloca i8
+  store i32 0, i32* %v.i
+  store i32 0, i32* %v.n
+  store i32 0, i32* %v.q
+  store i32 0, i32* %v.sign
+  store double 0.0, double* %v.x
+  store double 0.0, double* %v.y
+  store i1 false, i1* %v.b
+  store i8 0, i8* %v.c
+  %t1 = sub i32 0, 3
+  store i32 %t1, i32* %v.i
+  %t2 = load i32, i32* %v.i
+  %t3 = icmp slt i32 %t2, 0
+  br i1 %t3, label %cond.then1, label %cond.else1
+cond.then1:
+  %t4 = load i32, i32* %v.i
+  %t5 = sub i32 0, %t4
+  br label %cond.end1
+cond.else1:
+  %t6 = load i32, i32* %v.i
+  br label %cond.end1
+cond.end1:
+  %t7 = phi i32 [ %t5, %cond.then1 ], [ %t6, %cond.else1 ]
+  store i32 %t7, i32* %v.n
+  %t8 = load i32, i32* %v.i
+  %t9 = icmp slt i32 %t8, 0
+  br i1 %t9, label %cond.then2, label %cond.else2
+cond.then2:
+  %t10 = sitofp i32 1 to double
+  br label %cond.end2
+cond.else2:
+  br label %cond.end2
+cond.end2:
+  %t11 = phi double [ %t10, %cond.then2 ], [ 0x4004000000000000, %cond.else2 ]
+  %t12 = sitofp i32 2 to double
+  %t13 = fdiv double %t11, %t12
+  store double %t13, double* %v.x
+  %t14 = load i32, i32* %v.i
+  %t15 = icmp sgt i32 %t14, 0
+  br i1 %t15, label %cond.then3, label %cond.else3
+cond.then3:
+  %t16 = load i32, i32* %v.i
+  %t17 = sitofp i32 %t16 to double
+  %t18 = fdiv double 0x3FF0000000000000, %t17
+  br label %cond.end3
+cond.else3:
+  %t19 = load i32, i32* %v.i
+  %t20 = icmp eq i32 %t19, 0
+  br i1 %t20, label %cond.then4, label %cond.else4
+cond.then4:
+  br label %cond.end4
+cond.else4:
+  %t21 = sitofp i32 2 to double
+  br label %cond.end4
+cond.end4:
+  %t22 = phi double [ 0x0000000000000000, %cond.then4 ], [ %t21, %cond.else4 ]
+  br label %cond.end3
+cond.end3:
+  %t23 = phi double [ %t18, %cond.then3 ], [ %t22, %cond.end4 ]
+  store double %t23, double* %v.y
+  %t24 = load i32, i32* %v.n
+  %t25 = icmp sgt i32 %t24, 2
+  br i1 %t25, label %cond.then5, label %cond.else5
+cond.then5:
+  %t26 = load i32, i32* %v.n
+  %t27 = call i32 @rem(i32 %t26, i32 2)
+  %t28 = icmp eq i32 %t27, 1
+  br label %cond.end5
+cond.else5:
+  br label %cond.end5
+cond.end5:
+  %t29 = phi i1 [ %t28, %cond.then5 ], [ false, %cond.else5 ]
+  store i1 %t29, i1* %v.b
+  %t30 = load i1, i1* %v.b
+  br i1 %t30, label %cond.then6, label %cond.else6
+cond.then6:
+  br label %cond.end6
+cond.else6:
+  br label %cond.end6
+cond.end6:
+  %t31 = phi i8 [ 121, %cond.then6 ], [ 110, %cond.else6 ]
+  store i8 %t31, i8* %v.c
+  %t32 = load i32, i32* %v.i
+  %t33 = icmp ne i32 %t32, 0
+  br i1 %t33, label %cond.then7, label %cond.else7
+cond.then7:
+  %t34 = load i32, i32* %v.i
+  %t35 = call i32 @div(i32 100, i32 %t34)
+  br label %cond.end7
+cond.else7:
+  %t36 = load i32, i32* %v.i
+  %t37 = add i32 %t36, 3
+  %t38 = call i32 @div(i32 100, i32 %t37)
+  br label %cond.end7
+cond.end7:
+  %t39 = phi i32 [ %t35, %cond.then7 ], [ %t38, %cond.else7 ]
+  store i32 %t39, i32* %v.q
+  br label %loop.head1
+loop.head1:
+  %t40 = load i32, i32* %v.i
+  %t41 = icmp slt i32 %t40, 4
+  br i1 %t41, label %loop.body1, label %loop.done1
+loop.body1:
+  %t42 = load i32, i32* %v.sign
+  %t43 = mul i32 %t42, 10
+  %t44 = load i32, i32* %v.i
+  %t45 = icmp slt i32 %t44, 0
+  br i1 %t45, label %cond.then8, label %cond.else8
+cond.then8:
+  br label %cond.end8
+cond.else8:
+  %t46 = load i32, i32* %v.i
+  %t47 = icmp sgt i32 %t46, 0
+  br i1 %t47, label %cond.then9, label %cond.else9
+cond.then9:
+  br label %cond.end9
+cond.else9:
+  br label %cond.end9
+cond.end9:
+  %t48 = phi i32 [ 2, %cond.then9 ], [ 0, %cond.else9 ]
+  br label %cond.end8
+cond.end8:
+  %t49 = phi i32 [ 1, %cond.then8 ], [ %t48, %cond.end9 ]
+  %t50 = add i32 %t43, %t49
+  store i32 %t50, i32* %v.sign
+  %t51 = load i32, i32* %v.i
+  %t52 = add i32 %t51, 1
+  store i32 %t52, i32* %v.i
+  br label %loop.head1
+loop.done1:
+  %t53 = load i32, i32* %v.i
+  call i32 (i8*, ...) @printf(i8* getelementptr inbounds ([8 x i8], [8 x i8]* @.str.0, i64 0, i64 0), i32 %t53)
+  %t54 = load i32, i32* %v.n
+  call i32 (i8*, ...) @printf(i8* getelementptr inbounds ([8 x i8], [8 x i8]* @.str.1, i64 0, i64 0), i32 %t54)
+  %t55 = load i32, i32* %v.q
+  call i32 (i8*, ...) @printf(i8* getelementptr inbounds ([8 x i8], [8 x i8]* @.str.2, i64 0, i64 0), i32 %t55)
+  %t56 = load i32, i32* %v.sign
+  call i32 (i8*, ...) @printf(i8* getelementptr inbounds ([11 x i8], [11 x i8]* @.str.3, i64 0, i64 0), i32 %t56)
+  %t57 = load double, double* %v.x
+  %t58 = fcmp uno double %t57, %t57
+  %t59 = fcmp oeq double %t57, 0x7FF0000000000000
+  %t60 = fcmp oeq double %t57, 0xFFF0000000000000
+  %t61 = or i1 %t59, %t60
+  %t62 = or i1 %t58, %t61
+  br i1 %t62, label %print.special1, label %print.finite1
+print.special1:
+  %t63 = select i1 %t59, i8* getelementptr inbounds ([5 x i8], [5 x i8]* @.str.posinf, i64 0, i64 0), i8* getelementptr inbounds ([5 x i8], [5 x i8]* @.str.neginf, i64 0, i64 0)
+  %t64 = select i1 %t58, i8* getelementptr inbounds ([4 x i8], [4 x i8]* @.str.nan, i64 0, i64 0), i8* %t63
+  call i32 (i8*, ...) @printf(i8* getelementptr inbounds ([8 x i8], [8 x i8]* @.str.4.special, i64 0, i64 0), i8* %t64)
+  br label %print.done1
+print.finite1:
+  call i32 (i8*, ...) @printf(i8* getelementptr inbounds ([8 x i8], [8 x i8]* @.str.4, i64 0, i64 0), double %t57)
+  br label %print.done1
+print.done1:
+  %t65 = load double, double* %v.y
+  %t66 = fcmp uno double %t65, %t65
+  %t67 = fcmp oeq double %t65, 0x7FF0000000000000
+  %t68 = fcmp oeq double %t65, 0xFFF0000000000000
+  %t69 = or i1 %t67, %t68
+  %t70 = or i1 %t66, %t69
+  br i1 %t70, label %print.special2, label %print.finite2
+print.special2:
+  %t71 = select i1 %t67, i8* getelementptr inbounds ([5 x i8], [5 x i8]* @.str.posinf, i64 0, i64 0), i8* getelementptr inbounds ([5 x i8], [5 x i8]* @.str.neginf, i64 0, i64 0)
+  %t72 = select i1 %t66, i8* getelementptr inbounds ([4 x i8], [4 x i8]* @.str.nan, i64 0, i64 0), i8* %t71
+  call i32 (i8*, ...) @printf(i8* getelementptr inbounds ([8 x i8], [8 x i8]* @.str.5.special, i64 0, i64 0), i8* %t72)
+  br label %print.done2
+print.finite2:
+  call i32 (i8*, ...) @printf(i8* getelementptr inbounds ([8 x i8], [8 x i8]* @.str.5, i64 0, i64 0), double %t65)
+  br label %print.done2
+print.done2:
+  %t73 = load i1, i1* %v.b
+  %t74 = select i1 %t73, i8* getelementptr inbounds ([5 x i8], [5 x i8]* @.str.true, i64 0, i64 0), i8* getelementptr inbounds ([6 x i8], [6 x i8]* @.str.false, i64 0, i64 0)
+  call i32 (i8*, ...) @printf(i8* getelementptr inbounds ([8 x i8], [8 x i8]* @.str.6, i64 0, i64 0), i8* %t74)
+  %t75 = load i8, i8* %v.c
+  %t76 = icmp ult i8 %t75, 128
+  %t77 = lshr i8 %t75, 6
+  %t78 = or i8 %t77, 192
+  %t79 = and i8 %t75, 63
+  %t80 = or i8 %t79, 128
+  %t81 = select i1 %t76, i8 %t75, i8 %t78
+  %t82 = zext i8 %t81 to i32
+  %t83 = zext i8 %t80 to i32
+  %t84 = select i1 %t76, i8* getelementptr inbounds ([8 x i8], [8 x i8]* @.str.7, i64 0, i64 0), i8* getelementptr inbounds ([10 x i8], [10 x i8]* @.str.7.utf8, i64 0, i64 0)
+  call i32 (i8*, ...) @printf(i8* %t84, i32 %t82, i32 %t83)
+  ret i32 0
+}
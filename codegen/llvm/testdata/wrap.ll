@@ -0,0 +1,208 @@
+@.str.0 = private unnamed_addr constant [10 x i8] c"big = %d\0A\00"
+@.str.1 = private unnamed_addr constant [8 x i8] c"i = %d\0A\00"
+@.str.2 = private unnamed_addr constant [8 x i8] c"q = %d\0A\00"
+@.str.3 = private unnamed_addr constant [8 x i8] c"r = %d\0A\00"
+@.str.4 = private unnamed_addr constant [8 x i8] c"m = %d\0A\00"
+@.str.5 = private unnamed_addr constant [8 x i8] c"n = %d\0A\00"
+@.str.6 = private unnamed_addr constant [8 x i8] c"k = %d\0A\00"
+@.str.7 = private unnamed_addr constant [8 x i8] c"c = %c\0A\00"
+@.str.7.utf8 = private unnamed_addr constant [10 x i8] c"c = %c%c\0A\00"
+@.str.8 = private unnamed_addr constant [8 x i8] c"z = %f\0A\00"
+@.str.8.special = private unnamed_addr constant [8 x i8] c"z = %s\0A\00"
+@.str.9 = private unnamed_addr constant [8 x i8] c"h = %f\0A\00"
+@.str.9.special = private unnamed_addr constant [8 x i8] c"h = %s\0A\00"
+@.str.10 = private unnamed_addr constant [9 x i8] c"lt = %s\0A\00"
+@.str.11 = private unnamed_addr constant [9 x i8] c"eq = %s\0A\00"
+@.str.true = private unnamed_addr constant [5 x i8] c"true\00"
+@.str.false = private unnamed_addr constant [6 x i8] c"false\00"
+@.str.posinf = private unnamed_addr constant [5 x i8] c"+Inf\00"
+@.str.neginf = private unnamed_addr constant [5 x i8] c"-Inf\00"
+@.str.nan = private unnamed_addr constant [4 x i8] c"NaN\00"
+
+declare i32 @printf(i8*, ...)
+declare void @llvm.trap()
+
+define internal i32 @div(i32 %x, i32 %y) {
+entry:
+  %zero = icmp eq i32 %y, 0
+  br i1 %zero, label %trap, label %nonzero
+trap:
+  call void @llvm.trap()
+  unreachable
+nonzero:
+  %minus1 = icmp eq i32 %y, -1
+  br i1 %minus1, label %neg, label %quo
+neg:
+  ; -1<<31 / -1 overflows
+  %n = sub i32 0, %x
+  ret i32 %n
+quo:
+  %q = sdiv i32 %x, %y
+  ret i32 %q
+}
+
+define internal i32 @rem(i32 %x, i32 %y) {
+entry:
+  %zero = icmp eq i32 %y, 0
+  br i1 %zero, label %trap, label %nonzero
+trap:
+  call void @llvm.trap()
+  unreachable
+nonzero:
+  %minus1 = icmp eq i32 %y, -1
+  br i1 %minus1, label %none, label %rem
+none:
+  ; -1<<31 % -1 overflows
+  ret i32 0
+rem:
+  %r = srem i32 %x, %y
+  ret i32 %r
+}
+
+define internal i32 @ftoi(double %x) {
+entry:
+  %lo = fcmp oge double %x, -2147483648.0
+  %hi = fcmp olt double %x, 2147483648.0
+  %in = and i1 %lo, %hi
+  %t = fptosi double %x to i32
+  %r = select i1 %in, i32 %t, i32 -2147483648
+  ret i32 %r
+}
+
+define i32 @main() {
+entry:
+  %v.big = alloca i32
+  %v.i = alloca i32
+  %v.q = alloca i32
+  %v.r = alloca i32
+  %v.m = alloca i32
+  %v.n = alloca i32
+  %v.k = alloca i32
+  %v.c = alloca i8
+  %v.z = alloca double
+  %v.h = alloca double
+  %v.lt = alloca i1
+  %v.eq = alloca i1
+  store i32 0, i32* %v.big
+  store i32 0, i32* %v.i
+  store i32 0, i32* %v.q
+  store i32 0, i32* %v.r
+  store i32 0, i32* %v.m
+  store i32 0, i32* %v.n
+  store i32 0, i32* %v.k
+  store i8 0, i8* %v.c
+  store double 0.0, double* %v.z
+  store double 0.0, double* %v.h
+  store i1 false, i1* %v.lt
+  store i1 false, i1* %v.eq
+  store i32 2147483647, i32* %v.big
+  %t1 = load i32, i32* %v.big
+  %t2 = add i32 %t1, 1
+  store i32 %t2, i32* %v.big
+  %t3 = call i32 @div(i32 7, i32 2)
+  %t4 = sub i32 0, %t3
+  store i32 %t4, i32* %v.i
+  %t5 = sub i32 0, 1
+  store i32 %t5, i32* %v.m
+  %t6 = load i32, i32* %v.big
+  %t7 = load i32, i32* %v.m
+  %t8 = call i32 @div(i32 %t6, i32 %t7)
+  store i32 %t8, i32* %v.q
+  %t9 = load i32, i32* %v.big
+  %t10 = load i32, i32* %v.m
+  %t11 = call i32 @rem(i32 %t9, i32 %t10)
+  store i32 %t11, i32* %v.r
+  %t12 = trunc i32 300 to i8
+  store i8 %t12, i8* %v.c
+  store double 0x0000000000000000, double* %v.z
+  %t13 = load double, double* %v.z
+  %t14 = fneg double %t13
+  store double %t14, double* %v.z
+  %t15 = fdiv double 0x3FF0000000000000, 0x4008000000000000
+  store double %t15, double* %v.h
+  %t16 = call i32 @ftoi(double 0x4202A05F20000000)
+  store i32 %t16, i32* %v.n
+  %t17 = load double, double* %v.z
+  %t18 = fdiv double 0x0000000000000000, %t17
+  %t19 = call i32 @ftoi(double %t18)
+  store i32 %t19, i32* %v.k
+  %t20 = load double, double* %v.z
+  %t21 = load double, double* %v.h
+  %t22 = fcmp olt double %t20, %t21
+  br i1 %t22, label %cond.rhs1, label %cond.done1
+cond.rhs1:
+  %t23 = icmp ult i8 97, 98
+  br label %cond.done1
+cond.done1:
+  %t24 = phi i1 [ false, %entry ], [ %t23, %cond.rhs1 ]
+  store i1 %t24, i1* %v.lt
+  %t25 = load double, double* %v.h
+  %t26 = load double, double* %v.h
+  %t27 = fcmp une double %t25, %t26
+  %t28 = xor i1 %t27, true
+  store i1 %t28, i1* %v.eq
+  %t29 = load i32, i32* %v.big
+  call i32 (i8*, ...) @printf(i8* getelementptr inbounds ([10 x i8], [10 x i8]* @.str.0, i64 0, i64 0), i32 %t29)
+  %t30 = load i32, i32* %v.i
+  call i32 (i8*, ...) @printf(i8* getelementptr inbounds ([8 x i8], [8 x i8]* @.str.1, i64 0, i64 0), i32 %t30)
+  %t31 = load i32, i32* %v.q
+  call i32 (i8*, ...) @printf(i8* getelementptr inbounds ([8 x i8], [8 x i8]* @.str.2, i64 0, i64 0), i32 %t31)
+  %t32 = load i32, i32* %v.r
+  call i32 (i8*, ...) @printf(i8* getelementptr inbounds ([8 x i8], [8 x i8]* @.str.3, i64 0, i64 0), i32 %t32)
+  %t33 = load i32, i32* %v.m
+  call i32 (i8*, ...) @printf(i8* getelementptr inbounds ([8 x i8], [8 x i8]* @.str.4, i64 0, i64 0), i32 %t33)
+  %t34 = load i32, i32* %v.n
+  call i32 (i8*, ...) @printf(i8* getelementptr inbounds ([8 x i8], [8 x i8]* @.str.5, i64 0, i64 0), i32 %t34)
+  %t35 = load i32, i32* %v.k
+  call i32 (i8*, ...) @printf(i8* getelementptr inbounds ([8 x i8], [8 x i8]* @.str.6, i64 0, i64 0), i32 %t35)
+  %t36 = load i8, i8* %v.c
+  %t37 = icmp ult i8 %t36, 128
+  %t38 = lshr i8 %t36, 6
+  %t39 = or i8 %t38, 192
+  %t40 = and i8 %t36, 63
+  %t41 = or i8 %t40, 128
+  %t42 = select i1 %t37, i8 %t36, i8 %t39
+  %t43 = zext i8 %t42 to i32
+  %t44 = zext i8 %t41 to i32
+  %t45 = select i1 %t37, i8* getelementptr inbounds ([8 x i8], [8 x i8]* @.str.7, i64 0, i64 0), i8* getelementptr inbounds ([10 x i8], [10 x i8]* @.str.7.utf8, i64 0, i64 0)
+  call i32 (i8*, ...) @printf(i8* %t45, i32 %t43, i32 %t44)
+  %t46 = load double, double* %v.z
+  %t47 = fcmp uno double %t46, %t46
+  %t48 = fcmp oeq double %t46, 0x7FF0000000000000
+  %t49 = fcmp oeq double %t46, 0xFFF0000000000000
+  %t50 = or i1 %t48, %t49
+  %t51 = or i1 %t47, %t50
+  br i1 %t51, label %print.special1, label %print.finite1
+print.special1:
+  %t52 = select i1 %t48, i8* getelementptr inbounds ([5 x i8], [5 x i8]* @.str.posinf, i64 0, i64 0), i8* getelementptr inbounds ([5 x i8], [5 x i8]* @.str.neginf, i64 0, i64 0)
+  %t53 = select i1 %t47, i8* getelementptr inbounds ([4 x i8], [4 x i8]* @.str.nan, i64 0, i64 0), i8* %t52
+  call i32 (i8*, ...) @printf(i8* getelementptr inbounds ([8 x i8], [8 x i8]* @.str.8.special, i64 0, i64 0), i8* %t53)
+  br label %print.done1
+print.finite1:
+  call i32 (i8*, ...) @printf(i8* getelementptr inbounds ([8 x i8], [8 x i8]* @.str.8, i64 0, i64 0), double %t46)
+  br label %print.done1
+print.done1:
+  %t54 = load double, double* %v.h
+  %t55 = fcmp uno double %t54, %t54
+  %t56 = fcmp oeq double %t54, 0x7FF0000000000000
+  %t57 = fcmp oeq double %t54, 0xFFF0000000000000
+  %t58 = or i1 %t56, %t57
+  %t59 = or i1 %t55, %t58
+  br i1 %t59, label %print.special2, label %print.finite2
+print.special2:
+  %t60 = select i1 %t56, i8* getelementptr inbounds ([5 x i8], [5 x i8]* @.str.posinf, i64 0, i64 0), i8* getelementptr inbounds ([5 x i8], [5 x i8]* @.str.neginf, i64 0, i64 0)
+  %t61 = select i1 %t55, i8* getelementptr inbounds ([4 x i8], [4 x i8]* @.str.nan, i64 0, i64 0), i8* %t60
+  call i32 (i8*, ...) @printf(i8* getelementptr inbounds ([8 x i8], [8 x i8]* @.str.9.special, i64 0, i64 0), i8* %t61)
+  br label %print.done2
+print.finite2:
+  call i32 (i8*, ...) @printf(i8* getelementptr inbounds ([8 x i8], [8 x i8]* @.str.9, i64 0, i64 0), double %t54)
+  br label %print.done2
+print.done2:
+  %t62 = load i1, i1* %v.lt
+  %t63 = select i1 %t62, i8* getelementptr inbounds ([5 x i8], [5 x i8]* @.str.true, i64 0, i64 0), i8* getelementptr inbounds ([6 x i8], [6 x i8]* @.str.false, i64 0, i64 0)
+  call i32 (i8*, ...) @printf(i8* getelementptr inbounds ([9 x i8], [9 x i8]* @.str.10, i64 0, i64 0), i8* %t63)
+  %t64 = load i1, i1* %v.eq
+  %t65 = select i1 %t64, i8* getelementptr inbounds ([5 x i8], [5 x i8]* @.str.true, i64 0, i64 0), i8* getelementptr inbounds ([6 x i8], [6 x i8]* @.str.false, i64 0, i64 0)
+  call i32 (i8*, ...) @printf(i8* getelementptr inbounds ([9 x i8], [9 x i8]* @.str.11, i64 0, i64 0), i8* %t65)
+  ret i32 0
+}
@@ -0,0 +1,20 @@
+// remainders, bitwise operators and shifts, whose counts are taken
+// modulo 32
+int main() {
+	int i, j, r, s, m, a, o, n, l, h, k, w;
+	char c;
+	i = 0 - 7;
+	j = 3;
+	r = i % j;
+	s = 7 % (0 - j);
+	c = 'a';
+	m = c % 10;
+	a = c & 95;
+	o = 12 | j ^ 5;
+	n = ~c;
+	l = 1 << 31;
+	h = l >> j + 1;
+	k = j << 33 + i * 5;
+	w = i >> 0 - 1;
+	c = char(c ^ ~i);
+}
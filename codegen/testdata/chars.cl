@@ -0,0 +1,16 @@
+// chars beyond ASCII, printed in UTF-8, escape sequences and the
+// NUL char, printed as a byte
+int main() {
+	char c, d, e, a;
+	int i;
+	char q, b, h, z;
+	c = 'é';
+	d = char(int(c) + 1);
+	e = char(255);
+	a = char(int(e) + 66);
+	i = int(c);
+	q = '\'';
+	b = '\\';
+	h = '\xE8';
+	z = '\0';
+}
@@ -0,0 +1,30 @@
+// named constants, evaluated at compile time, in expressions and as
+// switch labels
+int main() {
+	const int N = 10, M = N * 2 + 1;
+	const int LOW = 0 - N, MASK = ~(1 << 4);
+	const char A = 'a', Z = char(int(A) + 25);
+	const float HALF = M / 2, INF = 1.0 / 0.0;
+	const bool BIG = M > N;
+	int i, s, m;
+	char c;
+	float x;
+	bool b;
+	while (i < N) {
+		switch (i) {
+		case N - 1:
+			s += M;
+		case 0 ... N / 2:
+			s++;
+		}
+		i++;
+	}
+	m = LOW & MASK;
+	c = Z;
+	switch (c) {
+	case A ... Z:
+		c = A;
+	}
+	x = HALF * 2.0;
+	b = BIG && !(LOW > 0) && x < INF && !(INF - INF == 0.0);
+}
@@ -0,0 +1,13 @@
+// Euclid's algorithm
+int main() {
+	int a, b, t, steps;
+	a = 1071;
+	b = 462;
+	steps = 0;
+	while (b != 0) {
+		t = b;
+		b = a - a / b * b;
+		a = t;
+		steps = steps + 1;
+	}
+}
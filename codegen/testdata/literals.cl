@@ -0,0 +1,13 @@
+// numeric literal forms
+int main() {
+	int h, o, b, d, m;
+	float f, e, s;
+	h = 0xFF + 0X7fff_FFFF;
+	o = 0o17 + 017;
+	b = 0b1010_1010;
+	d = 1_000_000;
+	m = -2147483647 - 1;
+	f = .5 + 1.;
+	e = 1e-3 * 2.5E+3;
+	s = 1_0.2_5;
+}
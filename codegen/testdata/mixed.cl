@@ -0,0 +1,21 @@
+// int, float, char and bool arithmetic with conversions
+int main() {
+	int i, n, f;
+	float x, y;
+	char c;
+	bool b, e;
+	n = 10;
+	i = 0;
+	f = 1;
+	while (i < n) {
+		i = i + 1;
+		if (i <= 5) f = f * i; else f = f - i / 2;
+	}
+	x = 1.5;
+	y = x * i + 0.25;
+	x = -y / 2;
+	c = char(65 + i);
+	i = int(y) + int(c);
+	b = x < 0.0 && !(i == 90) || c > 'A';
+	e = float(n) == y;
+}
@@ -0,0 +1,8 @@
+// infinities and NaN, printed as by fmt whatever the sign of the NaN
+int main() {
+	float z, p, n, q, r;
+	p = 1.0 / z;
+	n = -1.0 / z;
+	q = z / z;
+	r = -q;
+}
@@ -0,0 +1,20 @@
+// strings, which only some back ends support
+int main() {
+	string s, t, r, q;
+	int n, i;
+	char c;
+	bool lt, eq;
+	s = "héllo";
+	t = s + ", " + "wörld\x21";
+	n = len(t);
+	c = t[1];
+	i = 0;
+	r = "";
+	while (i < len(s)) {
+		r = string(s[i]) + r;
+		i = i + 1;
+	}
+	q = "\"q\"";
+	lt = "abc" < "abd" && "z" < "é";
+	eq = r == "olléh";
+}
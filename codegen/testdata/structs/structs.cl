@@ -0,0 +1,37 @@
+// structs, which only some back ends support
+int main() {
+	struct Point {
+		int x, y;
+	};
+	struct Segment {
+		struct Point from, to;
+		string name;
+		char tag;
+		bool closed;
+		float length;
+	};
+	struct Point p, q;
+	struct Segment s, t;
+	int n, far;
+	p.x = 3;
+	p.y = 4;
+	q = p;
+	q.x += 2;
+	q.y++;
+	s.from = p;
+	s.to = q;
+	s.name = "pq";
+	s.tag = 'é';
+	s.length = s.to.x - s.from.x;
+	n = 0;
+	while (n < 3) {
+		s.from.x = s.from.x * 2;
+		n++;
+	}
+	t = s;
+	t.to.y = -1;
+	t.closed = t.from.x > t.to.x;
+	s.name = s.name + "!";
+	far = (t.closed ? t.from : t.to).x;
+	p = n > 2 ? s.to : q;
+}
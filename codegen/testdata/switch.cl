@@ -0,0 +1,54 @@
+// switch statements: lists of labels, case ranges, a default that is
+// not last, fallthrough, nesting and a tag matching no case
+int main() {
+	int i, small, big, odd, other, ft, none, sum;
+	char c;
+	int vowels, digits;
+	i = 0 - 3;
+	while (i < 13) {
+		switch (i) {
+		case 0 - 3 ... 0 - 1:
+			small += i;
+		default:
+			other++;
+		case 1, 3, 5 ... 5, 7:
+			odd++;
+			switch (i % 3) {
+			case 0:
+				sum += 100;
+			case 1:
+				sum += 10;
+				fallthrough;
+			case 2:
+				sum++;
+			}
+		case 10 ... 12:
+			big = big * 2 + i;
+			fallthrough;
+		case 8:
+			ft++;
+		}
+		switch (i) {
+		case 100 ... 200:
+			none = 1;
+		}
+		i++;
+	}
+	c = '0';
+	while (c <= 'z') {
+		switch (c) {
+		case 'a', 'e', 'i', 'o', 'u':
+			vowels++;
+		case '0' ... '9':
+			digits++;
+		}
+		c = char(int(c) + 1);
+	}
+	switch (c) {
+	default:
+		c = char(int(c) + 1);
+		fallthrough;
+	case 'z':
+		c = char(int(c) - 1);
+	}
+}
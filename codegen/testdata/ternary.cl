@@ -0,0 +1,19 @@
+// conditional expressions: nesting, an int branch promoted to float,
+// and only the branch chosen evaluated
+int main() {
+	int i, n, q, sign;
+	float x, y;
+	bool b;
+	char c;
+	i = 0 - 3;
+	n = i < 0 ? 0 - i : i;
+	x = (i < 0 ? 1 : 2.5) / 2;
+	y = i > 0 ? 1.0 / float(i) : i == 0 ? 0.0 : 2;
+	b = n > 2 ? n % 2 == 1 : false;
+	c = b ? 'y' : 'n';
+	q = i != 0 ? 100 / i : 100 / (i + 3);
+	while (i < 4) {
+		sign = sign * 10 + (i < 0 ? 1 : i > 0 ? 2 : 0);
+		i++;
+	}
+}
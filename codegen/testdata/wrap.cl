@@ -0,0 +1,23 @@
+// integer overflow, truncating division, the most negative int
+// divided by -1, char conversion, float conversion out of the range
+// of int and negative zero
+int main() {
+	int big, i, q, r, m, n, k;
+	char c;
+	float z, h;
+	bool lt, eq;
+	big = 2147483647;
+	big = big + 1;
+	i = 0 - 7 / 2;
+	m = 0 - 1;
+	q = big / m;
+	r = big % m;
+	c = char(300);
+	z = 0.0;
+	z = -z;
+	h = 1.0 / 3.0;
+	n = int(1e10);
+	k = int(0.0 / z);
+	lt = z < h && 'a' < 'b';
+	eq = !(h != h);
+}
@@ -0,0 +1,430 @@
+package wasm
+
+import (
+	"errors"
+	"fmt"
+	"math"
+)
+
+// validate checks that b is a well formed binary module: that its
+// sections are in order and of the size they claim, that every index
+// is in range and that the code of every function, which may only use
+// the instructions emitted by this package, is well typed.
+func validate(b []byte) (err error) {
+	defer func() {
+		if e := recover(); e != nil {
+			if e, ok := e.(validateError); ok {
+				err = e
+				return
+			}
+			panic(e)
+		}
+	}()
+	r := &reader{b: b}
+	if string(r.bytes(4)) != "\x00asm" {
+		r.fail("bad magic number")
+	}
+	if v := r.bytes(4); v[0] != 1 || v[1]|v[2]|v[3] != 0 {
+		r.fail("unsupported version %v", v)
+	}
+
+	var (
+		types     [][2][]byte // parameters and results
+		funcs     []uint32    // type indexes
+		globals   []globalType
+		codeCount = -1
+		lastID    byte
+	)
+	for r.pos < len(r.b) {
+		id := r.byte()
+		size := int(r.u32())
+		end := r.pos + size
+		if end > len(r.b) {
+			r.fail("section %d overruns module", id)
+		}
+		if id != 0 {
+			if id <= lastID {
+				r.fail("section %d out of order", id)
+			}
+			lastID = id
+		}
+		switch id {
+		case 0: // custom
+			r.name()
+			r.pos = end
+		case 1: // type
+			for n := r.u32(); n > 0; n-- {
+				if r.byte() != 0x60 {
+					r.fail("bad function type")
+				}
+				params := r.valTypes()
+				types = append(types, [2][]byte{params, r.valTypes()})
+			}
+		case 3: // function
+			for n := r.u32(); n > 0; n-- {
+				t := r.u32()
+				if int(t) >= len(types) {
+					r.fail("function type %d out of range", t)
+				}
+				funcs = append(funcs, t)
+			}
+		case 6: // global
+			for n := r.u32(); n > 0; n-- {
+				g := globalType{r.valType(), r.byte()}
+				if g.mut > 1 {
+					r.fail("bad mutability %d", g.mut)
+				}
+				r.constExpr(g.typ)
+				globals = append(globals, g)
+			}
+		case 7: // export
+			seen := make(map[string]bool)
+			for n := r.u32(); n > 0; n-- {
+				name := r.name()
+				if seen[name] {
+					r.fail("duplicate export %q", name)
+				}
+				seen[name] = true
+				kind, i := r.byte(), int(r.u32())
+				switch {
+				case kind == 0 && i < len(funcs):
+				case kind == 3 && i < len(globals):
+				default:
+					r.fail("export %q of kind %d index %d out of range", name, kind, i)
+				}
+			}
+		case 10: // code
+			codeCount = int(r.u32())
+			if codeCount != len(funcs) {
+				r.fail("%d bodies for %d functions", codeCount, len(funcs))
+			}
+			for _, t := range funcs {
+				size := int(r.u32())
+				c := &checker{reader: r, globals: globals, end: r.pos + size}
+				c.locals = append(c.locals, types[t][0]...)
+				for n := r.u32(); n > 0; n-- {
+					count, typ := r.u32(), r.valType()
+					for ; count > 0; count-- {
+						c.locals = append(c.locals, typ)
+					}
+				}
+				c.body(types[t][1])
+			}
+		default:
+			r.fail("unexpected section %d", id)
+		}
+		if r.pos != end {
+			r.fail("section %d has size %d, contents %d", id, size, size+r.pos-end)
+		}
+	}
+	if codeCount < 0 && len(funcs) > 0 {
+		return errors.New("missing code section")
+	}
+	return nil
+}
+
+type validateError struct{ error }
+
+type globalType struct {
+	typ, mut byte
+}
+
+type reader struct {
+	b   []byte
+	pos int
+}
+
+func (r *reader) fail(format string, args ...interface{}) {
+	panic(validateError{fmt.Errorf("offset %#x: %s", r.pos, fmt.Sprintf(format, args...))})
+}
+
+func (r *reader) bytes(n int) []byte {
+	if r.pos+n > len(r.b) {
+		r.fail("unexpected end of module")
+	}
+	r.pos += n
+	return r.b[r.pos-n : r.pos]
+}
+
+func (r *reader) byte() byte { return r.bytes(1)[0] }
+
+func (r *reader) u32() uint32 {
+	var v uint64
+	for shift := uint(0); ; shift += 7 {
+		if shift >= 35 {
+			r.fail("integer too long")
+		}
+		c := r.byte()
+		v |= uint64(c&0x7f) << shift
+		if c&0x80 == 0 {
+			break
+		}
+	}
+	if v > math.MaxUint32 {
+		r.fail("integer out of range")
+	}
+	return uint32(v)
+}
+
+func (r *reader) s32() int32 {
+	var v int64
+	var shift uint
+	for {
+		if shift >= 35 {
+			r.fail("integer too long")
+		}
+		c := r.byte()
+		v |= int64(c&0x7f) << shift
+		shift += 7
+		if c&0x80 == 0 {
+			if c&0x40 != 0 {
+				v |= -1 << shift
+			}
+			break
+		}
+	}
+	if v < math.MinInt32 || v > math.MaxInt32 {
+		r.fail("integer out of range")
+	}
+	return int32(v)
+}
+
+func (r *reader) name() string {
+	return string(r.bytes(int(r.u32())))
+}
+
+func (r *reader) valType() byte {
+	t := r.byte()
+	if t != i32 && t != f64 {
+		r.fail("unexpected value type %#x", t)
+	}
+	return t
+}
+
+func (r *reader) valTypes() []byte {
+	var ts []byte
+	for n := r.u32(); n > 0; n-- {
+		ts = append(ts, r.valType())
+	}
+	return ts
+}
+
+func (r *reader) constExpr(t byte) {
+	switch op := r.byte(); {
+	case op == opI32Const && t == i32:
+		r.s32()
+	case op == opF64Const && t == f64:
+		r.bytes(8)
+	default:
+		r.fail("bad initializer for %#x global", t)
+	}
+	if r.byte() != opEnd {
+		r.fail("initializer not terminated")
+	}
+}
+
+// Signatures of the instructions without immediates, apart from the
+// control instructions and select.
+var signatures = map[byte]struct {
+	in  []byte
+	out byte
+}{
+	opI32Eqz: {[]byte{i32}, i32},
+	opI32Eq:  {[]byte{i32, i32}, i32}, opI32Ne: {[]byte{i32, i32}, i32},
+	opI32LtS: {[]byte{i32, i32}, i32}, opI32GtS: {[]byte{i32, i32}, i32},
+	opI32LeS: {[]byte{i32, i32}, i32}, opI32GeS: {[]byte{i32, i32}, i32},
+	opI32LeU: {[]byte{i32, i32}, i32},
+	opF64Eq:  {[]byte{f64, f64}, i32}, opF64Ne: {[]byte{f64, f64}, i32},
+	opF64Lt: {[]byte{f64, f64}, i32}, opF64Gt: {[]byte{f64, f64}, i32},
+	opF64Le: {[]byte{f64, f64}, i32}, opF64Ge: {[]byte{f64, f64}, i32},
+	opI32Add: {[]byte{i32, i32}, i32}, opI32Sub: {[]byte{i32, i32}, i32},
+	opI32Mul: {[]byte{i32, i32}, i32}, opI32DivS: {[]byte{i32, i32}, i32},
+	opI32RemS: {[]byte{i32, i32}, i32}, opI32And: {[]byte{i32, i32}, i32},
+	opI32Or: {[]byte{i32, i32}, i32}, opI32Xor: {[]byte{i32, i32}, i32},
+	opI32Shl: {[]byte{i32, i32}, i32}, opI32ShrS: {[]byte{i32, i32}, i32},
+	opF64Neg: {[]byte{f64}, f64},
+	opF64Add: {[]byte{f64, f64}, f64}, opF64Sub: {[]byte{f64, f64}, f64},
+	opF64Mul: {[]byte{f64, f64}, f64}, opF64Div: {[]byte{f64, f64}, f64},
+	opF64ConvI32: {[]byte{i32}, f64},
+}
+
+// unknown is the type of an operand popped off the stack in
+// unreachable code.
+const unknown byte = 0
+
+type frame struct {
+	op      byte // opBlock, opLoop, opIf or opElse; 0 for the body
+	results []byte
+	height  int
+	dead    bool
+}
+
+// A checker type checks a function body by keeping track of the types
+// of its operand stack and of its enclosing blocks.
+type checker struct {
+	*reader
+	globals []globalType
+	locals  []byte
+	end     int
+	stack   []byte
+	frames  []frame
+}
+
+func (c *checker) push(t byte) { c.stack = append(c.stack, t) }
+
+func (c *checker) pop(want byte) byte {
+	f := &c.frames[len(c.frames)-1]
+	if len(c.stack) == f.height {
+		if f.dead {
+			return want
+		}
+		c.fail("operand stack underflow")
+	}
+	t := c.stack[len(c.stack)-1]
+	c.stack = c.stack[:len(c.stack)-1]
+	if want != unknown && t != unknown && t != want {
+		c.fail("operand has type %#x, want %#x", t, want)
+	}
+	return t
+}
+
+// labelTypes returns the types of the operands of a branch to the
+// frame at depth d.
+func (c *checker) labelTypes(d uint32) []byte {
+	if int(d) >= len(c.frames) {
+		c.fail("branch depth %d out of range", d)
+	}
+	f := c.frames[len(c.frames)-1-int(d)]
+	if f.op == opLoop {
+		return nil
+	}
+	return f.results
+}
+
+func (c *checker) popAll(ts []byte) {
+	for i := len(ts) - 1; i >= 0; i-- {
+		c.pop(ts[i])
+	}
+}
+
+// leave checks that the stack holds exactly the results of the
+// innermost frame.
+func (c *checker) leave() frame {
+	f := c.frames[len(c.frames)-1]
+	c.popAll(f.results)
+	if len(c.stack) != f.height {
+		c.fail("%d values left on the stack", len(c.stack)-f.height)
+	}
+	return f
+}
+
+func (c *checker) unreachable() {
+	f := &c.frames[len(c.frames)-1]
+	c.stack = c.stack[:f.height]
+	f.dead = true
+}
+
+func (c *checker) blockType() []byte {
+	switch t := c.byte(); t {
+	case blockVoid:
+		return nil
+	case i32, f64:
+		return []byte{t}
+	default:
+		c.fail("unsupported block type %#x", t)
+	}
+	return nil
+}
+
+func (c *checker) local() byte {
+	i := c.u32()
+	if int(i) >= len(c.locals) {
+		c.fail("local %d out of range", i)
+	}
+	return c.locals[i]
+}
+
+func (c *checker) global() globalType {
+	i := c.u32()
+	if int(i) >= len(c.globals) {
+		c.fail("global %d out of range", i)
+	}
+	return c.globals[i]
+}
+
+func (c *checker) body(results []byte) {
+	c.frames = []frame{{results: results}}
+	for len(c.frames) > 0 {
+		if c.pos >= c.end {
+			c.fail("function body not terminated")
+		}
+		op := c.byte()
+		if sig, ok := signatures[op]; ok {
+			c.popAll(sig.in)
+			c.push(sig.out)
+			continue
+		}
+		switch op {
+		case opBlock, opLoop, opIf:
+			ts := c.blockType()
+			if op == opIf {
+				c.pop(i32)
+			}
+			c.frames = append(c.frames, frame{op: op, results: ts, height: len(c.stack)})
+		case opElse:
+			f := c.leave()
+			if f.op != opIf {
+				c.fail("else without if")
+			}
+			c.frames[len(c.frames)-1] = frame{op: opElse, results: f.results, height: f.height}
+		case opEnd:
+			f := c.leave()
+			if f.op == opIf && len(f.results) > 0 {
+				c.fail("if without else yields a value")
+			}
+			c.frames = c.frames[:len(c.frames)-1]
+			c.stack = append(c.stack, f.results...)
+		case opBr:
+			c.popAll(c.labelTypes(c.u32()))
+			c.unreachable()
+		case opBrIf:
+			ts := c.labelTypes(c.u32())
+			c.pop(i32)
+			c.popAll(ts)
+			c.stack = append(c.stack, ts...)
+		case opSelect:
+			c.pop(i32)
+			t := c.pop(unknown)
+			c.push(c.pop(t))
+		case opLocalGet:
+			c.push(c.local())
+		case opLocalSet:
+			c.pop(c.local())
+		case opGlobalGet:
+			c.push(c.global().typ)
+		case opGlobalSet:
+			g := c.global()
+			if g.mut == 0 {
+				c.fail("assignment to immutable global")
+			}
+			c.pop(g.typ)
+		case opI32Const:
+			c.s32()
+			c.push(i32)
+		case opF64Const:
+			c.bytes(8)
+			c.push(f64)
+		case opPrefixFC:
+			if sub := c.u32(); sub != 2 {
+				c.fail("unsupported instruction 0xfc %d", sub)
+			}
+			c.pop(f64)
+			c.push(i32)
+		default:
+			c.fail("unsupported instruction %#x", op)
+		}
+	}
+	if c.pos != c.end {
+		c.fail("code after the end of the function body")
+	}
+}
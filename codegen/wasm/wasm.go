@@ -0,0 +1,610 @@
+// Package wasm compiles clite programs to WebAssembly modules.
+//
+// A module exports a function "main", taking no arguments, which runs
+// the body of the program. Each variable of the program is a mutable
+// global exported under the name of the variable, so that its final
+// value can be read by the host once main has returned: ints, chars
+// and bools are i32 globals, floats are f64 globals. The semantics
+// are those described in package codegen; dividing an int, or taking
+// its remainder, by zero traps.
+//
+// Besides the mutable-globals feature, needed to export the
+// variables, modules use the saturating float to int conversion of
+// the nontrapping-float-to-int feature. Both are part of WebAssembly
+// 2.0.
+package wasm
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"math"
+
+	"github.com/mentalpumkins/clite-go/ast"
+	"github.com/mentalpumkins/clite-go/codegen"
+	"github.com/mentalpumkins/clite-go/eval"
+	"github.com/mentalpumkins/clite-go/types"
+)
+
+// Generate type checks prog and writes it to w as a binary module.
+func Generate(w io.Writer, prog *ast.Program) error {
+	m, err := compile(prog)
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(m.encode())
+	return err
+}
+
+// GenerateText type checks prog and writes it to w as a module in the
+// WebAssembly text format.
+func GenerateText(w io.Writer, prog *ast.Program) error {
+	m, err := compile(prog)
+	if err != nil {
+		return err
+	}
+	bw := bufio.NewWriter(w)
+	m.text(bw)
+	return bw.Flush()
+}
+
+// Value types.
+const (
+	i32 byte = 0x7f
+	f64 byte = 0x7c
+)
+
+// Opcodes of the instructions used.
+const (
+	opBlock      = 0x02
+	opLoop       = 0x03
+	opIf         = 0x04
+	opElse       = 0x05
+	opEnd        = 0x0b
+	opBr         = 0x0c
+	opBrIf       = 0x0d
+	opSelect     = 0x1b
+	opLocalGet   = 0x20
+	opLocalSet   = 0x21
+	opGlobalGet  = 0x23
+	opGlobalSet  = 0x24
+	opI32Const   = 0x41
+	opF64Const   = 0x44
+	opI32Eqz     = 0x45
+	opI32Eq      = 0x46
+	opI32Ne      = 0x47
+	opI32LtS     = 0x48
+	opI32GtS     = 0x4a
+	opI32LeS     = 0x4c
+	opI32LeU     = 0x4d
+	opI32GeS     = 0x4e
+	opF64Eq      = 0x61
+	opF64Ne      = 0x62
+	opF64Lt      = 0x63
+	opF64Gt      = 0x64
+	opF64Le      = 0x65
+	opF64Ge      = 0x66
+	opI32Add     = 0x6a
+	opI32Sub     = 0x6b
+	opI32Mul     = 0x6c
+	opI32DivS    = 0x6d
+	opI32RemS    = 0x6f
+	opI32And     = 0x71
+	opI32Or      = 0x72
+	opI32Xor     = 0x73
+	opI32Shl     = 0x74
+	opI32ShrS    = 0x75
+	opF64Neg     = 0x9a
+	opF64Add     = 0xa0
+	opF64Sub     = 0xa1
+	opF64Mul     = 0xa2
+	opF64Div     = 0xa3
+	opF64ConvI32 = 0xb7
+	opPrefixFC   = 0xfc // followed by i32.trunc_sat_f64_s, 2
+
+	blockVoid = 0x40
+)
+
+var mnemonics = map[byte]string{
+	opBlock: "block", opLoop: "loop", opIf: "if", opElse: "else", opEnd: "end",
+	opBr: "br", opBrIf: "br_if", opSelect: "select",
+	opLocalGet: "local.get", opLocalSet: "local.set",
+	opGlobalGet: "global.get", opGlobalSet: "global.set",
+	opI32Const: "i32.const", opF64Const: "f64.const",
+	opI32Eqz: "i32.eqz", opI32Eq: "i32.eq", opI32Ne: "i32.ne",
+	opI32LtS: "i32.lt_s", opI32GtS: "i32.gt_s", opI32LeS: "i32.le_s", opI32LeU: "i32.le_u", opI32GeS: "i32.ge_s",
+	opF64Eq: "f64.eq", opF64Ne: "f64.ne", opF64Lt: "f64.lt", opF64Gt: "f64.gt",
+	opF64Le: "f64.le", opF64Ge: "f64.ge",
+	opI32Add: "i32.add", opI32Sub: "i32.sub", opI32Mul: "i32.mul", opI32DivS: "i32.div_s",
+	opI32RemS: "i32.rem_s", opI32And: "i32.and", opI32Or: "i32.or", opI32Xor: "i32.xor",
+	opI32Shl: "i32.shl", opI32ShrS: "i32.shr_s", opF64Neg: "f64.neg",
+	opF64Add: "f64.add", opF64Sub: "f64.sub", opF64Mul: "f64.mul", opF64Div: "f64.div",
+	opF64ConvI32: "f64.convert_i32_s", opPrefixFC: "i32.trunc_sat_f64_s",
+}
+
+// An instr is one instruction of the body of main. imm holds its
+// immediate operand, if any: an int32 for i32.const, a float64 for
+// f64.const, a byte block type for block, loop and if, and a uint32
+// index or branch depth otherwise.
+type instr struct {
+	op  byte
+	imm interface{}
+}
+
+type global struct {
+	name string
+	typ  byte
+}
+
+type module struct {
+	globals []global
+	code    []instr
+}
+
+func valType(t ast.Type) byte {
+	if t == ast.FLOAT_TYPE {
+		return f64
+	}
+	return i32
+}
+
+func compile(prog *ast.Program) (*module, error) {
+	tm, consts, vars, err := codegen.Check(prog)
+	if err != nil {
+		return nil, err
+	}
+	if codegen.UsesStrings(prog, vars) {
+		return nil, fmt.Errorf("wasm: strings are not supported")
+	}
+	if codegen.UsesStructs(vars) {
+		return nil, fmt.Errorf("wasm: structs are not supported")
+	}
+	g := &gen{m: new(module), tm: tm, consts: consts, index: make(map[ast.Variable]uint32)}
+	for i, v := range vars {
+		g.index[v.Name] = uint32(i)
+		g.m.globals = append(g.m.globals, global{string(v.Name), valType(v.Type)})
+	}
+	for _, s := range prog.Body {
+		g.stmt(s)
+	}
+	if g.err != nil {
+		return nil, g.err
+	}
+	return g.m, nil
+}
+
+type gen struct {
+	m      *module
+	tm     *types.TypeMap
+	consts types.Consts
+	index  map[ast.Variable]uint32
+	err    error
+}
+
+func (g *gen) emit(op byte, imm ...interface{}) {
+	in := instr{op: op}
+	if len(imm) > 0 {
+		in.imm = imm[0]
+	}
+	g.m.code = append(g.m.code, in)
+}
+
+func (g *gen) errorf(format string, args ...interface{}) {
+	if g.err == nil {
+		g.err = fmt.Errorf(format, args...)
+	}
+}
+
+func (g *gen) stmt(s ast.Stmt) {
+	switch s := s.(type) {
+	case nil, *ast.Skip:
+	case *ast.Block:
+		for _, m := range s.Members {
+			g.stmt(m)
+		}
+	case *ast.Assignment:
+		g.exprAs(s.Source, g.tm.TypeOf(s.Target))
+		g.emit(opGlobalSet, g.index[s.Target])
+	case *ast.Conditional:
+		g.expr(s.Test)
+		g.emit(opIf, byte(blockVoid))
+		g.stmt(s.Body)
+		if s.Else != nil {
+			g.emit(opElse)
+			g.stmt(s.Else)
+		}
+		g.emit(opEnd)
+	case *ast.Loop:
+		g.emit(opBlock, byte(blockVoid))
+		g.emit(opLoop, byte(blockVoid))
+		g.expr(s.Test)
+		g.emit(opI32Eqz)
+		g.emit(opBrIf, uint32(1))
+		g.stmt(s.Body)
+		g.emit(opBr, uint32(0))
+		g.emit(opEnd)
+		g.emit(opEnd)
+	case *ast.Switch:
+		g.switchStmt(s)
+	default:
+		g.errorf("wasm: unsupported statement %T", s)
+	}
+}
+
+// switchStmt compiles s to a block per case, nested so that the end of
+// the block of case i, reached by br i from the innermost, is the start
+// of its body, and the bodies follow one another within the outermost
+// block, whose end is that of the switch. The tag is kept in local 3.
+func (g *gen) switchStmt(s *ast.Switch) {
+	n := len(s.Cases)
+	for i := 0; i <= n; i++ {
+		g.emit(opBlock, byte(blockVoid))
+	}
+	g.expr(s.Tag)
+	g.emit(opLocalSet, uint32(3))
+	def := uint32(n)
+	for i, c := range s.Cases {
+		if len(c.Labels) == 0 {
+			def = uint32(i)
+		}
+		for _, l := range c.Labels {
+			lo, hi, _ := eval.Label(l, g.consts.Lookup)
+			g.emit(opLocalGet, uint32(3))
+			g.emit(opI32Const, int32(lo))
+			if lo == hi {
+				g.emit(opI32Eq)
+			} else {
+				// lo <= tag <= hi if and only if tag-lo <= hi-lo unsigned
+				g.emit(opI32Sub)
+				g.emit(opI32Const, int32(hi-lo))
+				g.emit(opI32LeU)
+			}
+			g.emit(opBrIf, uint32(i))
+		}
+	}
+	g.emit(opBr, def)
+	for i, c := range s.Cases {
+		g.emit(opEnd)
+		for _, m := range c.Body {
+			g.stmt(m)
+		}
+		if !c.Fallthrough && i < n-1 {
+			g.emit(opBr, uint32(n-1-i))
+		}
+	}
+	g.emit(opEnd)
+}
+
+// exprAs evaluates e converted to type t.
+func (g *gen) exprAs(e ast.Expr, t ast.Type) {
+	g.expr(e)
+	g.convert(g.tm.TypeOf(e), t)
+}
+
+// convert converts the value on top of the stack from type from to
+// type to.
+func (g *gen) convert(from, to ast.Type) {
+	switch {
+	case from == to:
+	case to == ast.FLOAT_TYPE:
+		g.emit(opF64ConvI32)
+	case to == ast.BOOL_TYPE && from == ast.FLOAT_TYPE:
+		g.emit(opF64Const, 0.0)
+		g.emit(opF64Ne)
+	case to == ast.BOOL_TYPE:
+		g.emit(opI32Const, int32(0))
+		g.emit(opI32Ne)
+	case from == ast.FLOAT_TYPE:
+		// truncate, yielding the most negative int for NaN and out
+		// of range values: select(trunc(f), min, in range)
+		g.emit(opLocalSet, uint32(0))
+		g.emit(opLocalGet, uint32(0))
+		g.emit(opPrefixFC)
+		g.emit(opI32Const, int32(math.MinInt32))
+		g.emit(opLocalGet, uint32(0))
+		g.emit(opF64Const, float64(math.MinInt32))
+		g.emit(opF64Ge)
+		g.emit(opLocalGet, uint32(0))
+		g.emit(opF64Const, -float64(math.MinInt32))
+		g.emit(opF64Lt)
+		g.emit(opI32And)
+		g.emit(opSelect)
+		if to == ast.CHAR_TYPE {
+			g.emit(opI32Const, int32(0xff))
+			g.emit(opI32And)
+		}
+	case to == ast.CHAR_TYPE:
+		g.emit(opI32Const, int32(0xff))
+		g.emit(opI32And)
+	}
+}
+
+var intOps = map[string]byte{
+	"+": opI32Add, "-": opI32Sub, "*": opI32Mul, "/": opI32DivS,
+	// the most negative int % -1 is 0, and shift counts are taken
+	// modulo 32, as in clite
+	"%": opI32RemS, "&": opI32And, "|": opI32Or, "^": opI32Xor,
+	"<<": opI32Shl, ">>": opI32ShrS,
+	"<": opI32LtS, "<=": opI32LeS, ">": opI32GtS, ">=": opI32GeS,
+	"==": opI32Eq, "!=": opI32Ne,
+}
+
+var floatOps = map[string]byte{
+	"+": opF64Add, "-": opF64Sub, "*": opF64Mul, "/": opF64Div,
+	"<": opF64Lt, "<=": opF64Le, ">": opF64Gt, ">=": opF64Ge,
+	"==": opF64Eq, "!=": opF64Ne,
+}
+
+func (g *gen) expr(e ast.Expr) {
+	switch e := e.(type) {
+	case ast.Variable:
+		if k, ok := g.consts[e]; ok {
+			g.expr(k)
+			return
+		}
+		g.emit(opGlobalGet, g.index[e])
+	case ast.IntVal:
+		g.emit(opI32Const, int32(e))
+	case ast.CharVal:
+		g.emit(opI32Const, int32(e))
+	case ast.BoolVal:
+		if e {
+			g.emit(opI32Const, int32(1))
+		} else {
+			g.emit(opI32Const, int32(0))
+		}
+	case ast.FloatVal:
+		g.emit(opF64Const, float64(e))
+	case *ast.Binary:
+		g.binary(e)
+	case *ast.Ternary:
+		t := g.tm.TypeOf(e)
+		g.expr(e.Test)
+		g.emit(opIf, valType(t))
+		g.exprAs(e.Then, t)
+		g.emit(opElse)
+		g.exprAs(e.Else, t)
+		g.emit(opEnd)
+	case *ast.Unary:
+		from := g.tm.TypeOf(e.Term)
+		switch e.Op {
+		case "!":
+			g.expr(e.Term)
+			g.emit(opI32Eqz)
+		case "-":
+			if from == ast.FLOAT_TYPE {
+				g.expr(e.Term)
+				g.emit(opF64Neg)
+			} else {
+				g.emit(opI32Const, int32(0))
+				g.expr(e.Term)
+				g.emit(opI32Sub)
+			}
+		case "~":
+			g.expr(e.Term)
+			g.emit(opI32Const, int32(-1))
+			g.emit(opI32Xor)
+		default:
+			g.expr(e.Term)
+			g.convert(from, g.tm.TypeOf(e))
+		}
+	default:
+		g.errorf("wasm: unsupported expression %T", e)
+	}
+}
+
+func (g *gen) binary(e *ast.Binary) {
+	op := string(e.Op)
+	switch op {
+	case "&&", "||":
+		g.expr(e.Term1)
+		g.emit(opIf, i32)
+		if op == "&&" {
+			g.expr(e.Term2)
+			g.emit(opElse)
+			g.emit(opI32Const, int32(0))
+		} else {
+			g.emit(opI32Const, int32(1))
+			g.emit(opElse)
+			g.expr(e.Term2)
+		}
+		g.emit(opEnd)
+		return
+	}
+	t := g.tm.TypeOf(e)
+	if t == ast.BOOL_TYPE {
+		// comparison: operands have the same type, or are promoted
+		// to float
+		t = g.tm.TypeOf(e.Term1)
+		if g.tm.TypeOf(e.Term2) == ast.FLOAT_TYPE {
+			t = ast.FLOAT_TYPE
+		}
+	}
+	g.exprAs(e.Term1, t)
+	g.exprAs(e.Term2, t)
+	ops := intOps
+	if t == ast.FLOAT_TYPE {
+		ops = floatOps
+	}
+	if op == "/" && t != ast.FLOAT_TYPE {
+		// the most negative int divided by -1 wraps around rather
+		// than trapping: x / y is y == -1 ? 0 - x : x / y
+		g.emit(opLocalSet, uint32(2))
+		g.emit(opLocalSet, uint32(1))
+		g.emit(opLocalGet, uint32(2))
+		g.emit(opI32Const, int32(-1))
+		g.emit(opI32Eq)
+		g.emit(opIf, i32)
+		g.emit(opI32Const, int32(0))
+		g.emit(opLocalGet, uint32(1))
+		g.emit(opI32Sub)
+		g.emit(opElse)
+		g.emit(opLocalGet, uint32(1))
+		g.emit(opLocalGet, uint32(2))
+		g.emit(opI32DivS)
+		g.emit(opEnd)
+	} else if code, ok := ops[op]; ok {
+		g.emit(code)
+	} else {
+		g.errorf("wasm: unsupported operator %s", op)
+	}
+}
+
+// Binary encoding
+
+func (m *module) encode() []byte {
+	var out bytes.Buffer
+	out.WriteString("\x00asm")
+	out.Write([]byte{1, 0, 0, 0})
+
+	section := func(id byte, contents []byte) {
+		out.WriteByte(id)
+		out.Write(uleb(uint64(len(contents))))
+		out.Write(contents)
+	}
+	vec := func(n int, items ...[]byte) []byte {
+		b := uleb(uint64(n))
+		for _, it := range items {
+			b = append(b, it...)
+		}
+		return b
+	}
+
+	// type 0: [] -> []
+	section(1, vec(1, []byte{0x60, 0, 0}))
+	// function 0 has type 0
+	section(3, vec(1, []byte{0}))
+
+	var globals [][]byte
+	for _, gl := range m.globals {
+		b := []byte{gl.typ, 1} // mutable
+		if gl.typ == f64 {
+			b = append(b, opF64Const, 0, 0, 0, 0, 0, 0, 0, 0, opEnd)
+		} else {
+			b = append(b, opI32Const, 0, opEnd)
+		}
+		globals = append(globals, b)
+	}
+	section(6, vec(len(globals), globals...))
+
+	exports := [][]byte{append(name("main"), 0x00, 0)}
+	for i, gl := range m.globals {
+		exports = append(exports, append(append(name(gl.name), 0x03), uleb(uint64(i))...))
+	}
+	section(7, vec(len(exports), exports...))
+
+	// locals: an f64 for conversions, two i32s for divisions and one
+	// for the tag of a switch
+	body := vec(2, []byte{1, f64}, []byte{3, i32})
+	for _, in := range m.code {
+		body = append(body, in.op)
+		switch imm := in.imm.(type) {
+		case int32:
+			body = append(body, sleb(int64(imm))...)
+		case float64:
+			var b [8]byte
+			bits := math.Float64bits(imm)
+			for i := range b {
+				b[i] = byte(bits >> (8 * uint(i)))
+			}
+			body = append(body, b[:]...)
+		case byte:
+			body = append(body, imm)
+		case uint32:
+			body = append(body, uleb(uint64(imm))...)
+		}
+		if in.op == opPrefixFC {
+			body = append(body, 2)
+		}
+	}
+	body = append(body, opEnd)
+	section(10, vec(1, append(uleb(uint64(len(body))), body...)))
+	return out.Bytes()
+}
+
+func name(s string) []byte {
+	return append(uleb(uint64(len(s))), s...)
+}
+
+func uleb(v uint64) []byte {
+	var b []byte
+	for {
+		c := byte(v & 0x7f)
+		v >>= 7
+		if v != 0 {
+			c |= 0x80
+		}
+		b = append(b, c)
+		if v == 0 {
+			return b
+		}
+	}
+}
+
+func sleb(v int64) []byte {
+	var b []byte
+	for {
+		c := byte(v & 0x7f)
+		v >>= 7
+		if (v == 0 && c&0x40 == 0) || (v == -1 && c&0x40 != 0) {
+			return append(b, c)
+		}
+		b = append(b, c|0x80)
+	}
+}
+
+// Text format
+
+func typeName(t byte) string {
+	if t == f64 {
+		return "f64"
+	}
+	return "i32"
+}
+
+func (m *module) text(w io.Writer) {
+	fmt.Fprintf(w, "(module\n")
+	for i, gl := range m.globals {
+		fmt.Fprintf(w, "  (global $%d (export %q) (mut %s) (%s.const 0))\n",
+			i, gl.name, typeName(gl.typ), typeName(gl.typ))
+	}
+	fmt.Fprintf(w, "  (func (export \"main\") (local f64 i32 i32 i32)\n")
+	depth := 2
+	for _, in := range m.code {
+		if in.op == opEnd || in.op == opElse {
+			depth--
+		}
+		fmt.Fprintf(w, "%*s%s", 2*depth, "", mnemonics[in.op])
+		switch imm := in.imm.(type) {
+		case int32:
+			fmt.Fprintf(w, " %d", imm)
+		case float64:
+			fmt.Fprintf(w, " %s", floatText(imm))
+		case byte:
+			if imm != blockVoid {
+				fmt.Fprintf(w, " (result %s)", typeName(imm))
+			}
+		case uint32:
+			fmt.Fprintf(w, " %d", imm)
+		}
+		fmt.Fprintf(w, "\n")
+		switch in.op {
+		case opBlock, opLoop, opIf, opElse:
+			depth++
+		}
+	}
+	fmt.Fprintf(w, "  )\n)\n")
+}
+
+func floatText(f float64) string {
+	switch {
+	case math.IsInf(f, 1):
+		return "inf"
+	case math.IsInf(f, -1):
+		return "-inf"
+	case f != f:
+		return "nan"
+	}
+	return fmt.Sprintf("%x", f) // hexadecimal floats are exact
+}
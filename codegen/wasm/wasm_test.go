@@ -0,0 +1,181 @@
+package wasm
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/mentalpumkins/clite-go/ast"
+	"github.com/mentalpumkins/clite-go/codegen"
+	"github.com/mentalpumkins/clite-go/parser"
+)
+
+const prog = `int main() {
+	int i;
+	float x;
+	char c;
+	bool b;
+	i = 3;
+	x = i * 2.5;
+	c = char(int(x));
+	while (i > 0 && !b)
+		if (x / i < 1.0) b = true; else i = i / -1 - 1;
+}`
+
+func TestGenerateText(t *testing.T) {
+	var buf bytes.Buffer
+	if err := GenerateText(&buf, parser.MustParse(prog)); err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		`(global $0 (export "i") (mut i32) (i32.const 0))`,
+		`(global $1 (export "x") (mut f64) (f64.const 0))`,
+		`(func (export "main") (local f64 i32 i32 i32)`,
+		"    global.get 0\n    f64.convert_i32_s\n    f64.const 0x1.4p+01\n    f64.mul\n    global.set 1\n",
+		"i32.trunc_sat_f64_s",
+		"    block\n      loop\n",
+		"br_if 1",
+		"if (result i32)",
+	} {
+		if !strings.Contains(buf.String(), want) {
+			t.Errorf("output lacks %q:\n%s", want, buf.String())
+		}
+	}
+}
+
+func TestValidate(t *testing.T) {
+	srcs := []string{prog}
+	files, _ := filepath.Glob("../testdata/*.cl")
+	for _, file := range files {
+		src, err := ioutil.ReadFile(file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		srcs = append(srcs, string(src))
+	}
+	for _, src := range srcs {
+		var buf bytes.Buffer
+		if err := Generate(&buf, parser.MustParse(src)); err != nil {
+			t.Fatal(err)
+		}
+		if err := validate(buf.Bytes()); err != nil {
+			t.Errorf("%v\n%s", err, src)
+		}
+	}
+
+	// the validator rejects broken modules
+	var buf bytes.Buffer
+	Generate(&buf, parser.MustParse(prog))
+	b := buf.Bytes()
+	for i := len(b) - 1; i >= 0; i-- {
+		if b[i] == opI32Eqz {
+			b[i] = opF64Neg
+			break
+		}
+	}
+	if err := validate(b); err == nil {
+		t.Error("ill typed module validated")
+	}
+	if err := validate(b[:len(b)-1]); err == nil {
+		t.Error("truncated module validated")
+	}
+}
+
+// runJS instantiates the module named by its first argument, runs its
+// main function and prints its exported globals, one per line.
+const runJS = `
+const fs = require('fs');
+const m = new WebAssembly.Module(fs.readFileSync(process.argv[2]));
+const inst = new WebAssembly.Instance(m, {});
+inst.exports.main();
+for (const [name, v] of Object.entries(inst.exports)) {
+	if (name !== 'main') console.log(name, Object.is(v.value, -0) ? '-0' : String(v.value));
+}
+`
+
+func TestRun(t *testing.T) {
+	node, err := exec.LookPath("node")
+	if err != nil {
+		t.Skip("no node command")
+	}
+	dir, err := ioutil.TempDir("", "wasm")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	js := filepath.Join(dir, "run.js")
+	if err := ioutil.WriteFile(js, []byte(runJS), 0644); err != nil {
+		t.Fatal(err)
+	}
+	files, _ := filepath.Glob("../testdata/*.cl")
+	for _, file := range files {
+		src, err := ioutil.ReadFile(file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		want, err := ioutil.ReadFile(strings.TrimSuffix(file, ".cl") + ".out")
+		if err != nil {
+			t.Fatal(err)
+		}
+		prog := parser.MustParse(string(src))
+		_, _, vars, err := codegen.Check(prog)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var buf bytes.Buffer
+		if err := Generate(&buf, prog); err != nil {
+			t.Fatal(err)
+		}
+		mod := filepath.Join(dir, "prog.wasm")
+		if err := ioutil.WriteFile(mod, buf.Bytes(), 0644); err != nil {
+			t.Fatal(err)
+		}
+		out, err := exec.Command(node, js, mod).Output()
+		if err != nil {
+			t.Fatalf("%s: %v", file, err)
+		}
+		got := state(t, vars, out)
+		if got != string(want) {
+			t.Errorf("%s left\n%s\nwant\n%s", file, got, want)
+		}
+	}
+}
+
+// state formats the globals printed by runJS as a final state.
+func state(t *testing.T, vars []codegen.Var, out []byte) string {
+	var buf bytes.Buffer
+	sc := bufio.NewScanner(bytes.NewReader(out))
+	for _, v := range vars {
+		if !sc.Scan() {
+			t.Fatalf("missing value of %s in\n%s", v.Name, out)
+		}
+		f := strings.Fields(sc.Text())
+		if len(f) != 2 || f[0] != string(v.Name) {
+			t.Fatalf("got %q, want value of %s", sc.Text(), v.Name)
+		}
+		x, err := strconv.ParseFloat(f[1], 64)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var val interface{}
+		switch v.Type {
+		case ast.INT_TYPE:
+			val = int32(x)
+		case ast.FLOAT_TYPE:
+			val = x
+		case ast.CHAR_TYPE:
+			val = uint8(x)
+		case ast.BOOL_TYPE:
+			val = strconv.FormatBool(x != 0)
+		}
+		fmt.Fprintf(&buf, "%s = "+codegen.Format(v.Type)+"\n", v.Name, val)
+	}
+	return buf.String()
+}
@@ -0,0 +1,214 @@
+package dataflow
+
+import (
+	"github.com/mentalpumkins/clite-go/ast"
+	"github.com/mentalpumkins/clite-go/cfg"
+)
+
+// Vars numbers the declared variables of a program so that sets of
+// variables can be held in a Set.
+type Vars struct {
+	Names []ast.Variable
+	index map[ast.Variable]int
+}
+
+// NewVars numbers the variables declared by prog in declaration order.
+func NewVars(prog *ast.Program) *Vars {
+	vs := &Vars{index: make(map[ast.Variable]int)}
+	for _, d := range prog.DecPart {
+		if d, ok := d.(*ast.VariableDecl); ok {
+			if _, dup := vs.index[d.Var]; !dup {
+				vs.index[d.Var] = len(vs.Names)
+				vs.Names = append(vs.Names, d.Var)
+			}
+		}
+	}
+	return vs
+}
+
+// Index returns the number of v, or -1 if v is not declared.
+func (vs *Vars) Index(v ast.Variable) int {
+	if i, ok := vs.index[v]; ok {
+		return i
+	}
+	return -1
+}
+
+// Uses returns the variables read by node n, in order of appearance.
+func Uses(n ast.Stmt) []ast.Variable {
+	var e ast.Expr
+	switch n := n.(type) {
+	case *ast.Assignment:
+		e = n.Source
+	case *ast.Conditional:
+		e = n.Test
+	case *ast.Loop:
+		e = n.Test
+	case *ast.Switch:
+		e = n.Tag
+	default:
+		return nil
+	}
+	var vars []ast.Variable
+	ast.Inspect(e, func(node ast.Node) bool {
+		if v, ok := node.(ast.Variable); ok {
+			vars = append(vars, v)
+		}
+		return node != nil
+	})
+	return vars
+}
+
+// Def returns the variable written by node n, if any. An assignment to
+// a field of a struct variable writes the variable, but only in part:
+// see Kills.
+func Def(n ast.Stmt) (ast.Variable, bool) {
+	if a, ok := n.(*ast.Assignment); ok {
+		return a.Target, true
+	}
+	return "", false
+}
+
+// Kills reports whether node n overwrites the whole of the variable it
+// writes, so that the value held before is lost.
+func Kills(n ast.Stmt) bool {
+	a, ok := n.(*ast.Assignment)
+	return ok && len(a.Fields) == 0
+}
+
+// ReachingDefs is the solution to the reaching definitions problem:
+// which assignments may have produced the value of a variable at a
+// given point.
+type ReachingDefs struct {
+	*Result
+	Defs []*ast.Assignment // a Set element i stands for Defs[i]
+}
+
+// ReachingDefinitions solves the reaching definitions problem for g.
+func ReachingDefinitions(g *cfg.Graph) *ReachingDefs {
+	r := new(ReachingDefs)
+	index := make(map[*ast.Assignment]int)
+	byVar := make(map[ast.Variable][]int)
+	for _, b := range g.Blocks {
+		for _, s := range b.Stmts {
+			if a, ok := s.(*ast.Assignment); ok {
+				index[a] = len(r.Defs)
+				byVar[a.Target] = append(byVar[a.Target], len(r.Defs))
+				r.Defs = append(r.Defs, a)
+			}
+		}
+	}
+	r.Result = Solve(g, &Analysis{
+		Size:     len(r.Defs),
+		Boundary: NewSet(len(r.Defs)),
+		Transfer: func(n ast.Stmt, in Set) Set {
+			a, ok := n.(*ast.Assignment)
+			if !ok {
+				return in
+			}
+			out := in.Copy()
+			if Kills(a) {
+				for _, d := range byVar[a.Target] {
+					out.Remove(d)
+				}
+			}
+			out.Add(index[a])
+			return out
+		},
+	})
+	return r
+}
+
+// Reaching returns the assignments to v that reach node n.
+func (r *ReachingDefs) Reaching(n ast.Stmt, v ast.Variable) []*ast.Assignment {
+	var defs []*ast.Assignment
+	if in := r.Before(n); in != nil {
+		for _, d := range in.Elems() {
+			if r.Defs[d].Target == v {
+				defs = append(defs, r.Defs[d])
+			}
+		}
+	}
+	return defs
+}
+
+// LiveVars is the solution to the live variables problem: which
+// variables may be read before being overwritten.
+type LiveVars struct {
+	*Result
+	Vars *Vars
+}
+
+// Liveness solves the live variables problem for g. The variables in
+// exit are taken to be live when the program ends.
+func Liveness(g *cfg.Graph, vars *Vars, exit []ast.Variable) *LiveVars {
+	n := len(vars.Names)
+	boundary := NewSet(n)
+	for _, v := range exit {
+		if i := vars.Index(v); i >= 0 {
+			boundary.Add(i)
+		}
+	}
+	res := Solve(g, &Analysis{
+		Backward: true,
+		Size:     n,
+		Boundary: boundary,
+		Transfer: func(nd ast.Stmt, out Set) Set {
+			in := out.Copy()
+			if v, ok := Def(nd); ok && Kills(nd) && vars.Index(v) >= 0 {
+				in.Remove(vars.Index(v))
+			}
+			for _, v := range Uses(nd) {
+				if i := vars.Index(v); i >= 0 {
+					in.Add(i)
+				}
+			}
+			return in
+		},
+	})
+	return &LiveVars{res, vars}
+}
+
+// LiveAfter reports whether v is live just after node n.
+func (l *LiveVars) LiveAfter(n ast.Stmt, v ast.Variable) bool {
+	i := l.Vars.Index(v)
+	out := l.After(n)
+	return i >= 0 && out != nil && out.Has(i)
+}
+
+// Assigned is the solution to the definite assignment problem: which
+// variables have been assigned on every path to a given point.
+type Assigned struct {
+	*Result
+	Vars *Vars
+}
+
+// DefiniteAssignment solves the definite assignment problem for g.
+// No variable is assigned on entry to the program. A struct variable
+// is assigned once any of its fields is.
+func DefiniteAssignment(g *cfg.Graph, vars *Vars) *Assigned {
+	n := len(vars.Names)
+	res := Solve(g, &Analysis{
+		Must:     true,
+		Size:     n,
+		Boundary: NewSet(n),
+		Transfer: func(nd ast.Stmt, in Set) Set {
+			v, ok := Def(nd)
+			if !ok || vars.Index(v) < 0 {
+				return in
+			}
+			out := in.Copy()
+			out.Add(vars.Index(v))
+			return out
+		},
+	})
+	return &Assigned{res, vars}
+}
+
+// AssignedBefore reports whether v is definitely assigned just before
+// node n.
+func (a *Assigned) AssignedBefore(n ast.Stmt, v ast.Variable) bool {
+	i := a.Vars.Index(v)
+	in := a.Before(n)
+	return i >= 0 && in != nil && in.Has(i)
+}
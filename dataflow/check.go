@@ -0,0 +1,74 @@
+package dataflow
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/mentalpumkins/clite-go/ast"
+	"github.com/mentalpumkins/clite-go/cfg"
+	"github.com/mentalpumkins/clite-go/token"
+)
+
+// A Diagnostic is a problem found in a program by Check.
+type Diagnostic struct {
+	Pos token.Position
+	Msg string
+}
+
+func (d Diagnostic) String() string {
+	return fmt.Sprintf("%s: %s", d.Pos, d.Msg)
+}
+
+// Check reports the reads of variables that may not have been
+// assigned yet and the assignments whose value is never read. As the
+// final value of every variable is the output of a program, all of
+// them are read when it ends. The diagnostics are sorted by position.
+func Check(prog *ast.Program) []Diagnostic {
+	g := cfg.New(prog)
+	vars := NewVars(prog)
+	assigned := DefiniteAssignment(g, vars)
+	live := Liveness(g, vars, vars.Names)
+
+	var diags []Diagnostic
+	for _, b := range g.Blocks {
+		for _, n := range Nodes(b) {
+			seen := make(map[ast.Variable]bool)
+			for _, v := range Uses(n) {
+				if seen[v] || vars.Index(v) < 0 {
+					continue
+				}
+				seen[v] = true
+				if !assigned.AssignedBefore(n, v) {
+					diags = append(diags, Diagnostic{
+						stmtPos(n),
+						fmt.Sprintf("possibly uninitialized variable %s", v),
+					})
+				}
+			}
+			if v, ok := Def(n); ok && vars.Index(v) >= 0 && !live.LiveAfter(n, v) {
+				diags = append(diags, Diagnostic{
+					stmtPos(n),
+					fmt.Sprintf("%s assigned but never used", v),
+				})
+			}
+		}
+	}
+	sort.SliceStable(diags, func(i, j int) bool {
+		return diags[i].Pos.Offset < diags[j].Pos.Offset
+	})
+	return diags
+}
+
+func stmtPos(n ast.Stmt) token.Position {
+	switch n := n.(type) {
+	case *ast.Assignment:
+		return n.Pos
+	case *ast.Conditional:
+		return n.Pos
+	case *ast.Loop:
+		return n.Pos
+	case *ast.Switch:
+		return n.Pos
+	}
+	return token.Position{}
+}
@@ -0,0 +1,160 @@
+// Package dataflow provides a worklist solver for dataflow problems
+// over the statement-level control flow of a clite program, along
+// with the classic reaching definitions, live variables and definite
+// assignment analyses built on top of it.
+//
+// The nodes of the flow graph are the statements held in the blocks
+// of a cfg.Graph. The evaluation of the test of a Conditional or Loop
+// is represented by that Conditional or Loop statement itself.
+package dataflow
+
+import (
+	"github.com/mentalpumkins/clite-go/ast"
+	"github.com/mentalpumkins/clite-go/cfg"
+)
+
+// An Analysis describes a dataflow problem whose facts are sets of
+// small integers, e.g. indexes of variables or of definitions.
+type Analysis struct {
+	// Backward analyses propagate facts from the exit toward the
+	// entry; forward analyses from the entry toward the exit.
+	Backward bool
+	// Must analyses meet by intersection, may analyses by union.
+	Must bool
+	// Size is the number of elements in the universe of facts.
+	Size int
+	// Boundary is the fact holding on entry to the program for a
+	// forward analysis or on exit for a backward one.
+	Boundary Set
+	// Transfer returns the fact after a node given the fact before
+	// it, in the direction of the analysis. It must not modify in.
+	Transfer func(n ast.Stmt, in Set) Set
+}
+
+// A Result holds the solution of an Analysis. Facts are always given
+// in program order: In is the fact at the start of a block and Out
+// at its end, whatever the direction of the analysis.
+type Result struct {
+	In, Out []Set // indexed by cfg.Block.Index
+
+	before map[ast.Stmt]Set
+	after  map[ast.Stmt]Set
+}
+
+// Before returns the fact holding just before node n executes.
+func (r *Result) Before(n ast.Stmt) Set { return r.before[n] }
+
+// After returns the fact holding just after node n executes.
+func (r *Result) After(n ast.Stmt) Set { return r.after[n] }
+
+// Nodes returns the flow graph nodes of b in execution order.
+func Nodes(b *cfg.Block) []ast.Stmt {
+	nodes := b.Stmts
+	if b.Cond != nil {
+		nodes = append(nodes[:len(nodes):len(nodes)], b.Stmt)
+	}
+	return nodes
+}
+
+// Solve computes the fixed point of a over g.
+func Solve(g *cfg.Graph, a *Analysis) *Result {
+	n := len(g.Blocks)
+	r := &Result{
+		In:     make([]Set, n),
+		Out:    make([]Set, n),
+		before: make(map[ast.Stmt]Set),
+		after:  make(map[ast.Stmt]Set),
+	}
+	// In the direction of the analysis: head is where facts flow
+	// into a block and tail where they leave it.
+	head, tail := r.In, r.Out
+	preds := func(b *cfg.Block) []*cfg.Block { return b.Preds }
+	succs := func(b *cfg.Block) []*cfg.Block { return b.Succs }
+	boundary := g.Entry
+	if a.Backward {
+		head, tail = tail, head
+		preds, succs = succs, preds
+		boundary = g.Exit
+	}
+
+	initial := NewSet(a.Size)
+	if a.Must {
+		initial = Universe(a.Size)
+	}
+	for _, b := range g.Blocks {
+		head[b.Index] = initial
+		tail[b.Index] = initial
+	}
+
+	// transfer runs the facts at the head of b through its nodes,
+	// calling visit with the facts before and after each one.
+	transfer := func(b *cfg.Block, visit func(n ast.Stmt, in, out Set)) Set {
+		nodes := Nodes(b)
+		f := head[b.Index]
+		for i := range nodes {
+			nd := nodes[i]
+			if a.Backward {
+				nd = nodes[len(nodes)-1-i]
+			}
+			out := a.Transfer(nd, f)
+			if visit != nil {
+				visit(nd, f, out)
+			}
+			f = out
+		}
+		return f
+	}
+
+	work := make([]*cfg.Block, len(g.Blocks))
+	copy(work, g.Blocks)
+	if a.Backward {
+		for i, j := 0, len(work)-1; i < j; i, j = i+1, j-1 {
+			work[i], work[j] = work[j], work[i]
+		}
+	}
+	queued := make([]bool, n)
+	for i := range queued {
+		queued[i] = true
+	}
+	for len(work) > 0 {
+		b := work[0]
+		work = work[1:]
+		queued[b.Index] = false
+
+		if b == boundary {
+			head[b.Index] = a.Boundary
+		} else if ps := preds(b); len(ps) > 0 {
+			f := tail[ps[0].Index].Copy()
+			for _, p := range ps[1:] {
+				if a.Must {
+					f.Intersect(tail[p.Index])
+				} else {
+					f.Union(tail[p.Index])
+				}
+			}
+			head[b.Index] = f
+		}
+
+		out := transfer(b, nil)
+		if out.Equal(tail[b.Index]) {
+			continue
+		}
+		tail[b.Index] = out
+		for _, s := range succs(b) {
+			if !queued[s.Index] {
+				queued[s.Index] = true
+				work = append(work, s)
+			}
+		}
+	}
+
+	for _, b := range g.Blocks {
+		transfer(b, func(nd ast.Stmt, in, out Set) {
+			if a.Backward {
+				in, out = out, in
+			}
+			r.before[nd], r.after[nd] = in, out
+		})
+	}
+	return r
+}
@@ -0,0 +1,155 @@
+package dataflow
+
+import (
+	"testing"
+
+	"github.com/mentalpumkins/clite-go/ast"
+	"github.com/mentalpumkins/clite-go/cfg"
+	"github.com/mentalpumkins/clite-go/parser"
+)
+
+const src = `int main() {
+	int i, s, t;
+	i = 0;
+	s = 1;
+	while (i < 10) {
+		if (i > 5)
+			s = s + i;
+		else
+			t = i;
+		i = i + 1;
+	}
+	s = s + t;
+}`
+
+// stmts returns the assignments of prog in source order.
+func stmts(prog *ast.Program) []*ast.Assignment {
+	var list []*ast.Assignment
+	ast.Inspect(prog, func(n ast.Node) bool {
+		if a, ok := n.(*ast.Assignment); ok {
+			list = append(list, a)
+		}
+		return n != nil
+	})
+	return list
+}
+
+func TestReachingDefinitions(t *testing.T) {
+	prog := parser.MustParse(src)
+	g := cfg.New(prog)
+	rd := ReachingDefinitions(g)
+	a := stmts(prog) // i=0 s=1 s=s+i t=i i=i+1 s=s+t
+
+	if len(rd.Defs) != len(a) {
+		t.Fatalf("%d definitions, want %d", len(rd.Defs), len(a))
+	}
+	got := rd.Reaching(a[2], "s")
+	if len(got) != 2 || got[0] != a[1] || got[1] != a[2] {
+		t.Errorf("definitions of s reaching %d: %v", a[2].Pos.Line, got)
+	}
+	got = rd.Reaching(a[5], "i")
+	if len(got) != 2 || got[0] != a[0] || got[1] != a[4] {
+		t.Errorf("definitions of i reaching the end: %v", got)
+	}
+	got = rd.Reaching(a[5], "t")
+	if len(got) != 1 || got[0] != a[3] {
+		t.Errorf("definitions of t reaching the end: %v", got)
+	}
+}
+
+func TestLiveness(t *testing.T) {
+	prog := parser.MustParse(src)
+	g := cfg.New(prog)
+	live := Liveness(g, NewVars(prog), []ast.Variable{"s"})
+	a := stmts(prog)
+
+	if !live.LiveAfter(a[0], "i") || !live.LiveAfter(a[1], "s") {
+		t.Error("initial assignments are dead")
+	}
+	if !live.LiveAfter(a[3], "t") {
+		t.Error("t dead after assignment in loop")
+	}
+	if !live.LiveAfter(a[5], "s") || live.LiveAfter(a[5], "i") {
+		t.Errorf("live at exit: %s", live.After(a[5]))
+	}
+	if in := live.In[g.Entry.Index]; !in.Has(2) || in.Has(0) || in.Has(1) {
+		t.Errorf("live on entry: %s", in)
+	}
+}
+
+func TestDefiniteAssignment(t *testing.T) {
+	prog := parser.MustParse(src)
+	g := cfg.New(prog)
+	da := DefiniteAssignment(g, NewVars(prog))
+	a := stmts(prog)
+
+	if da.AssignedBefore(a[0], "i") {
+		t.Error("i assigned before its first assignment")
+	}
+	if !da.AssignedBefore(a[4], "i") || !da.AssignedBefore(a[4], "s") {
+		t.Errorf("assigned in loop body: %s", da.Before(a[4]))
+	}
+	if da.AssignedBefore(a[4], "t") || da.AssignedBefore(a[5], "t") {
+		t.Error("t assigned on only one branch is definitely assigned")
+	}
+}
+
+func TestCheck(t *testing.T) {
+	diags := Check(parser.MustParse(src))
+	want := []struct {
+		line int
+		msg  string
+	}{
+		{12, "possibly uninitialized variable t"},
+	}
+	if len(diags) != len(want) {
+		t.Fatalf("diagnostics %v", diags)
+	}
+	for i, d := range diags {
+		if d.Pos.Line != want[i].line || d.Msg != want[i].msg {
+			t.Errorf("diagnostic %d: %s, want %d: %s", i, d, want[i].line, want[i].msg)
+		}
+	}
+
+	diags = Check(parser.MustParse(`int main() {
+	int a, b;
+	if (a < 1) b = 2;
+	a = 3;
+	a = b;
+}`))
+	if len(diags) != 3 {
+		t.Fatalf("diagnostics %v", diags)
+	}
+	for i, msg := range []string{
+		"possibly uninitialized variable a",
+		"a assigned but never used",
+		"possibly uninitialized variable b",
+	} {
+		if diags[i].Msg != msg {
+			t.Errorf("diagnostic %d: %s, want %s", i, diags[i], msg)
+		}
+	}
+}
+
+func TestFields(t *testing.T) {
+	prog := parser.MustParse(`int main() {
+	struct P { int x, y; };
+	struct P p, q;
+	p = q;
+	p.x = 1;
+	q = p;
+}`)
+	g := cfg.New(prog)
+	a := stmts(prog) // p=q p.x=1 q=p
+
+	// assigning a field defines p without killing the earlier definitions
+	rd := ReachingDefinitions(g)
+	got := rd.Reaching(a[2], "p")
+	if len(got) != 2 || got[0] != a[0] || got[1] != a[1] {
+		t.Errorf("definitions of p reaching %d: %v", a[2].Pos.Line, got)
+	}
+	live := Liveness(g, NewVars(prog), []ast.Variable{"q"})
+	if !live.LiveAfter(a[0], "p") {
+		t.Error("p dead before the assignment of one of its fields")
+	}
+}
@@ -0,0 +1,80 @@
+package dataflow
+
+import (
+	"fmt"
+	"strings"
+)
+
+// A Set is a set of small non-negative integers, stored as a bit
+// vector. The zero length Set is empty.
+type Set []uint64
+
+// NewSet returns an empty set able to hold elements below size.
+func NewSet(size int) Set {
+	return make(Set, (size+63)/64)
+}
+
+// Universe returns the set of all elements below size.
+func Universe(size int) Set {
+	s := NewSet(size)
+	for i := 0; i < size; i++ {
+		s.Add(i)
+	}
+	return s
+}
+
+func (s Set) Has(i int) bool { return s[i/64]&(1<<uint(i%64)) != 0 }
+func (s Set) Add(i int)      { s[i/64] |= 1 << uint(i%64) }
+func (s Set) Remove(i int)   { s[i/64] &^= 1 << uint(i%64) }
+
+// Copy returns a copy of s that may be modified independently.
+func (s Set) Copy() Set {
+	c := make(Set, len(s))
+	copy(c, s)
+	return c
+}
+
+// Union sets s to the union of s and t.
+func (s Set) Union(t Set) {
+	for i := range s {
+		s[i] |= t[i]
+	}
+}
+
+// Intersect sets s to the intersection of s and t.
+func (s Set) Intersect(t Set) {
+	for i := range s {
+		s[i] &= t[i]
+	}
+}
+
+func (s Set) Equal(t Set) bool {
+	if len(s) != len(t) {
+		return false
+	}
+	for i := range s {
+		if s[i] != t[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// Elems returns the elements of s in increasing order.
+func (s Set) Elems() []int {
+	var elems []int
+	for i := 0; i < len(s)*64; i++ {
+		if s.Has(i) {
+			elems = append(elems, i)
+		}
+	}
+	return elems
+}
+
+func (s Set) String() string {
+	var elems []string
+	for _, e := range s.Elems() {
+		elems = append(elems, fmt.Sprint(e))
+	}
+	return "{" + strings.Join(elems, " ") + "}"
+}
@@ -0,0 +1,420 @@
+// Package eval implements the meaning of clite operators on values.
+//
+// ints are 32 bit two's complement integers whose arithmetic wraps
+// around; chars are unsigned 8 bit values, the code points U+0000 to
+// U+00FF, and strings sequences of chars. Converting a float to an
+// int truncates toward zero, yielding the most negative int when the
+// result does not fit, as the x86-64 conversion instructions do.
+//
+// The remainder x % y has the sign of x, and fails like division when
+// y is zero. The remainder, bitwise operators and shifts convert char
+// operands to int, and shifts only use the low 5 bits of their count, so that
+// x << 32 is x; >> is an arithmetic shift.
+package eval
+
+import (
+	"errors"
+	"fmt"
+	"math"
+
+	"github.com/mentalpumkins/clite-go/ast"
+	"github.com/mentalpumkins/clite-go/ast/operators"
+)
+
+// ErrDivByZero is returned when an int is divided, or its remainder
+// taken, by zero.
+var ErrDivByZero = errors.New("integer divide by zero")
+
+// An UndefinedError is returned by Expr for a variable that has no
+// value.
+type UndefinedError ast.Variable
+
+func (e UndefinedError) Error() string {
+	return fmt.Sprintf("variable %s has no value", ast.Variable(e))
+}
+
+// An IndexError is returned for an index out of the range of the
+// string indexed.
+type IndexError struct {
+	Index, Len int
+}
+
+func (e IndexError) Error() string {
+	return fmt.Sprintf("index %d out of range of string of length %d", e.Index, e.Len)
+}
+
+// Zero returns the initial value of variables of type t: that of a
+// struct has the zero value of each field.
+func Zero(t ast.Type) ast.Value {
+	if st, ok := t.(*ast.Struct); ok {
+		v := &ast.StructVal{T: st, Fields: make([]ast.Value, len(st.Fields))}
+		for i, f := range st.Fields {
+			v.Fields[i] = Zero(f.T)
+		}
+		return v
+	}
+	switch t {
+	case ast.FLOAT_TYPE:
+		return ast.FloatVal(0)
+	case ast.CHAR_TYPE:
+		return ast.CharVal(0)
+	case ast.BOOL_TYPE:
+		return ast.BoolVal(false)
+	case ast.STRING_TYPE:
+		return ast.StringVal("")
+	}
+	return ast.IntVal(0)
+}
+
+// Expr evaluates e, looking up the values of variables with env. A nil
+// env defines no variable, so that Expr evaluates constant
+// expressions. The second operand of && and || is only evaluated when
+// needed.
+func Expr(e ast.Expr, env func(ast.Variable) (ast.Value, bool)) (ast.Value, error) {
+	switch e := e.(type) {
+	case ast.Variable:
+		if env != nil {
+			if v, ok := env(e); ok {
+				return v, nil
+			}
+		}
+		return nil, UndefinedError(e)
+	case ast.Value:
+		return e, nil
+	case *ast.Binary:
+		x, err := Expr(e.Term1, env)
+		if err != nil {
+			return nil, err
+		}
+		switch e.Op {
+		case operators.Operator(operators.AND):
+			if !bool(x.(ast.BoolVal)) {
+				return x, nil
+			}
+			return Expr(e.Term2, env)
+		case operators.Operator(operators.OR):
+			if bool(x.(ast.BoolVal)) {
+				return x, nil
+			}
+			return Expr(e.Term2, env)
+		}
+		y, err := Expr(e.Term2, env)
+		if err != nil {
+			return nil, err
+		}
+		return Binary(e.Op, x, y)
+	case *ast.Unary:
+		x, err := Expr(e.Term, env)
+		if err != nil {
+			return nil, err
+		}
+		return Unary(e.Op, x)
+	case *ast.Index:
+		x, err := Expr(e.X, env)
+		if err != nil {
+			return nil, err
+		}
+		i, err := Expr(e.Index, env)
+		if err != nil {
+			return nil, err
+		}
+		return Index(x, i)
+	case *ast.Selector:
+		x, err := Expr(e.X, env)
+		if err != nil {
+			return nil, err
+		}
+		return Field(x, e.Field), nil
+	case *ast.Ternary:
+		x, err := Expr(e.Test, env)
+		if err != nil {
+			return nil, err
+		}
+		chosen, other := e.Then, e.Else
+		if !bool(x.(ast.BoolVal)) {
+			chosen, other = other, chosen
+		}
+		v, err := Expr(chosen, env)
+		if err != nil {
+			return nil, err
+		}
+		if isFloat(other, env) {
+			// an int branch is promoted like an arithmetic operand
+			v = Convert(v, ast.FLOAT_TYPE)
+		}
+		return v, nil
+	}
+	return nil, fmt.Errorf("cannot evaluate %T", e)
+}
+
+// isFloat reports whether e, which is not evaluated, has type float.
+func isFloat(e ast.Expr, env func(ast.Variable) (ast.Value, bool)) bool {
+	switch e := e.(type) {
+	case ast.Variable:
+		if env != nil {
+			v, ok := env(e)
+			_, f := v.(ast.FloatVal)
+			return ok && f
+		}
+	case ast.FloatVal:
+		return true
+	case *ast.Binary:
+		switch e.Op {
+		case "+", "-", "*", "/":
+			return isFloat(e.Term1, env) || isFloat(e.Term2, env)
+		}
+	case *ast.Unary:
+		return e.Op == "float" || e.Op == "-" && isFloat(e.Term, env)
+	case *ast.Ternary:
+		return isFloat(e.Then, env) || isFloat(e.Else, env)
+	case *ast.Selector:
+		// selecting has no effect
+		v, err := Expr(e, env)
+		_, f := v.(ast.FloatVal)
+		return err == nil && f
+	}
+	return false
+}
+
+// Binary applies the binary operator op to x and y. An int operand of
+// an arithmetic or relational operator whose other operand is a float
+// is first converted to float.
+func Binary(op operators.Operator, x, y ast.Value) (ast.Value, error) {
+	if s, ok := x.(ast.StringVal); ok {
+		return binaryString(op, s, y.(ast.StringVal))
+	}
+	if x.GetType() == ast.FLOAT_TYPE || y.GetType() == ast.FLOAT_TYPE {
+		x, y = Convert(x, ast.FLOAT_TYPE), Convert(y, ast.FLOAT_TYPE)
+	}
+	switch op {
+	case "&&":
+		return ast.BoolVal(truth(x) && truth(y)), nil
+	case "||":
+		return ast.BoolVal(truth(x) || truth(y)), nil
+	case "&", "|", "^", "<<", ">>":
+		a, b := int32(toInt(x)), int32(toInt(y))
+		switch op {
+		case "&":
+			return ast.IntVal(a & b), nil
+		case "|":
+			return ast.IntVal(a | b), nil
+		case "^":
+			return ast.IntVal(a ^ b), nil
+		case "<<":
+			return ast.IntVal(a << uint(b&31)), nil
+		}
+		return ast.IntVal(a >> uint(b&31)), nil
+	case "+", "-", "*", "/", "%":
+		if x, ok := x.(ast.FloatVal); ok {
+			y := y.(ast.FloatVal)
+			switch op {
+			case "+":
+				return x + y, nil
+			case "-":
+				return x - y, nil
+			case "*":
+				return x * y, nil
+			}
+			return x / y, nil
+		}
+		a, b := int32(toInt(x)), int32(toInt(y))
+		switch op {
+		case "+":
+			return ast.IntVal(a + b), nil
+		case "-":
+			return ast.IntVal(a - b), nil
+		case "*":
+			return ast.IntVal(a * b), nil
+		}
+		if b == 0 {
+			return nil, ErrDivByZero
+		}
+		if b == -1 {
+			// -1<<31 / -1 overflows
+			if op == "%" {
+				return ast.IntVal(0), nil
+			}
+			return ast.IntVal(-a), nil
+		}
+		if op == "%" {
+			return ast.IntVal(a % b), nil
+		}
+		return ast.IntVal(a / b), nil
+	case "<", "<=", ">", ">=", "==", "!=":
+		var c int // -1, 0 or +1 as x is less than, equal or greater than y
+		if f, ok := x.(ast.FloatVal); ok {
+			g := y.(ast.FloatVal)
+			if f != f || g != g {
+				// NaN is unordered: only != holds
+				return ast.BoolVal(op == "!="), nil
+			}
+			switch {
+			case f < g:
+				c = -1
+			case f > g:
+				c = 1
+			}
+		} else {
+			a, b := toInt(x), toInt(y)
+			switch {
+			case a < b:
+				c = -1
+			case a > b:
+				c = 1
+			}
+		}
+		switch op {
+		case "<":
+			return ast.BoolVal(c < 0), nil
+		case "<=":
+			return ast.BoolVal(c <= 0), nil
+		case ">":
+			return ast.BoolVal(c > 0), nil
+		case ">=":
+			return ast.BoolVal(c >= 0), nil
+		case "==":
+			return ast.BoolVal(c == 0), nil
+		}
+		return ast.BoolVal(c != 0), nil
+	}
+	return nil, fmt.Errorf("unknown binary operator %s", op)
+}
+
+// binaryString applies op, a concatenation or a comparison, which
+// orders strings by their chars, to x and y.
+func binaryString(op operators.Operator, x, y ast.StringVal) (ast.Value, error) {
+	switch op {
+	case "+":
+		return x + y, nil
+	case "<":
+		return ast.BoolVal(x < y), nil
+	case "<=":
+		return ast.BoolVal(x <= y), nil
+	case ">":
+		return ast.BoolVal(x > y), nil
+	case ">=":
+		return ast.BoolVal(x >= y), nil
+	case "==":
+		return ast.BoolVal(x == y), nil
+	case "!=":
+		return ast.BoolVal(x != y), nil
+	}
+	return nil, fmt.Errorf("binary operator %s not defined on strings", op)
+}
+
+// Unary applies the unary operator op, a negation, a complement, a
+// type conversion or len, to x.
+func Unary(op operators.Operator, x ast.Value) (ast.Value, error) {
+	switch op {
+	case operators.NOT:
+		return ast.BoolVal(!truth(x)), nil
+	case operators.NEG:
+		if f, ok := x.(ast.FloatVal); ok {
+			return -f, nil
+		}
+		return ast.IntVal(-int32(toInt(x))), nil
+	case operators.COMPL:
+		return ast.IntVal(^int32(toInt(x))), nil
+	case operators.INT:
+		return Convert(x, ast.INT_TYPE), nil
+	case operators.FLOAT:
+		return Convert(x, ast.FLOAT_TYPE), nil
+	case operators.CHAR:
+		return Convert(x, ast.CHAR_TYPE), nil
+	case operators.BOOL:
+		return Convert(x, ast.BOOL_TYPE), nil
+	case operators.STRING:
+		return Convert(x, ast.STRING_TYPE), nil
+	case operators.LEN:
+		return ast.IntVal(len(x.(ast.StringVal))), nil
+	}
+	return nil, fmt.Errorf("unknown unary operator %s", op)
+}
+
+// Label returns the range of the values of an int or char tag matched
+// by the case label l, whose expressions must be constant, the values
+// of named constants being looked up with env: from lo to hi,
+// inclusive, the code of chars. The range is empty when hi < lo.
+func Label(l *ast.CaseLabel, env func(ast.Variable) (ast.Value, bool)) (lo, hi int64, err error) {
+	v, err := Expr(l.Lo, env)
+	if err != nil {
+		return 0, 0, err
+	}
+	lo = toInt(v)
+	if l.Hi == nil {
+		return lo, lo, nil
+	}
+	if v, err = Expr(l.Hi, env); err != nil {
+		return 0, 0, err
+	}
+	return lo, toInt(v), nil
+}
+
+// Index returns the char of the string x at the int or char i.
+func Index(x, i ast.Value) (ast.Value, error) {
+	s, n := x.(ast.StringVal), int(toInt(i))
+	if n < 0 || n >= len(s) {
+		return nil, IndexError{n, len(s)}
+	}
+	return ast.CharVal(s[n]), nil
+}
+
+// Field returns the value of the field name of the struct x.
+func Field(x ast.Value, name string) ast.Value {
+	s := x.(*ast.StructVal)
+	return s.Fields[s.T.Field(name)]
+}
+
+// Convert converts v to type t.
+func Convert(v ast.Value, t ast.Type) ast.Value {
+	if v.GetType() == t {
+		return v
+	}
+	switch t {
+	case ast.INT_TYPE:
+		return ast.IntVal(toInt(v))
+	case ast.FLOAT_TYPE:
+		if f, ok := v.(ast.FloatVal); ok {
+			return f
+		}
+		return ast.FloatVal(toInt(v))
+	case ast.CHAR_TYPE:
+		return ast.CharVal(uint8(toInt(v)))
+	case ast.BOOL_TYPE:
+		return ast.BoolVal(truth(v))
+	case ast.STRING_TYPE:
+		// of a char
+		return ast.StringVal([]byte{uint8(toInt(v))})
+	}
+	return v
+}
+
+// toInt returns the integer value of a non-float value, or the
+// truncated value of a float.
+func toInt(v ast.Value) int64 {
+	switch v := v.(type) {
+	case ast.IntVal:
+		return int64(int32(v))
+	case ast.CharVal:
+		return int64(v)
+	case ast.BoolVal:
+		if v {
+			return 1
+		}
+		return 0
+	case ast.FloatVal:
+		f := math.Trunc(float64(v))
+		if f != f || f < math.MinInt32 || f > math.MaxInt32 {
+			return math.MinInt32
+		}
+		return int64(f)
+	}
+	return 0
+}
+
+func truth(v ast.Value) bool {
+	if f, ok := v.(ast.FloatVal); ok {
+		return f != 0
+	}
+	return toInt(v) != 0
+}
@@ -0,0 +1,158 @@
+package eval
+
+import (
+	"math"
+	"testing"
+
+	"github.com/mentalpumkins/clite-go/ast"
+	"github.com/mentalpumkins/clite-go/ast/operators"
+)
+
+var binaryTests = [...]struct {
+	op   operators.Operator
+	x, y ast.Value
+	want ast.Value
+}{
+	{"+", ast.IntVal(math.MaxInt32), ast.IntVal(1), ast.IntVal(math.MinInt32)},
+	{"*", ast.IntVal(65536), ast.IntVal(65536), ast.IntVal(0)},
+	{"/", ast.IntVal(-7), ast.IntVal(2), ast.IntVal(-3)},
+	{"/", ast.IntVal(math.MinInt32), ast.IntVal(-1), ast.IntVal(math.MinInt32)},
+	{"-", ast.FloatVal(1.5), ast.IntVal(2), ast.FloatVal(-0.5)},
+	{"/", ast.IntVal(1), ast.FloatVal(4), ast.FloatVal(0.25)},
+	{"<", ast.CharVal('a'), ast.CharVal('b'), ast.BoolVal(true)},
+	{"<=", ast.IntVal(6), ast.IntVal(5), ast.BoolVal(false)},
+	{"!=", ast.IntVal(462), ast.IntVal(0), ast.BoolVal(true)},
+	{">=", ast.IntVal(3), ast.FloatVal(3), ast.BoolVal(true)},
+	{"!=", ast.FloatVal(math.NaN()), ast.FloatVal(math.NaN()), ast.BoolVal(true)},
+	{"==", ast.FloatVal(math.NaN()), ast.FloatVal(math.NaN()), ast.BoolVal(false)},
+	{"<", ast.BoolVal(false), ast.BoolVal(true), ast.BoolVal(true)},
+	{"||", ast.BoolVal(false), ast.BoolVal(true), ast.BoolVal(true)},
+	{"+", ast.StringVal("ab"), ast.StringVal("c"), ast.StringVal("abc")},
+	{"<", ast.StringVal("ab"), ast.StringVal("b"), ast.BoolVal(true)},
+	{"<", ast.StringVal("z"), ast.StringVal("\xe9"), ast.BoolVal(true)},
+	{">=", ast.StringVal("ab"), ast.StringVal("abc"), ast.BoolVal(false)},
+	{"==", ast.StringVal(""), ast.StringVal(""), ast.BoolVal(true)},
+	{"%", ast.IntVal(-7), ast.IntVal(3), ast.IntVal(-1)},
+	{"%", ast.IntVal(7), ast.IntVal(-3), ast.IntVal(1)},
+	{"%", ast.IntVal(math.MinInt32), ast.IntVal(-1), ast.IntVal(0)},
+	{"&", ast.CharVal('a'), ast.IntVal(0x5f), ast.IntVal('A')},
+	{"|", ast.IntVal(12), ast.IntVal(3), ast.IntVal(15)},
+	{"^", ast.CharVal(0xff), ast.CharVal(0x0f), ast.IntVal(0xf0)},
+	{"<<", ast.IntVal(1), ast.IntVal(31), ast.IntVal(math.MinInt32)},
+	{"<<", ast.IntVal(3), ast.IntVal(33), ast.IntVal(6)},
+	{">>", ast.IntVal(-8), ast.IntVal(1), ast.IntVal(-4)},
+	{">>", ast.IntVal(-8), ast.IntVal(-1), ast.IntVal(-1)},
+}
+
+func TestBinary(t *testing.T) {
+	for _, test := range binaryTests {
+		got, err := Binary(test.op, test.x, test.y)
+		if err != nil || got != test.want {
+			t.Errorf("%s %s %s = %s, %v; want %s", test.x, test.op, test.y, got, err, test.want)
+		}
+	}
+	if _, err := Binary("/", ast.IntVal(1), ast.IntVal(0)); err != ErrDivByZero {
+		t.Errorf("division by zero: %v", err)
+	}
+	if _, err := Binary("%", ast.IntVal(1), ast.IntVal(0)); err != ErrDivByZero {
+		t.Errorf("remainder by zero: %v", err)
+	}
+}
+
+var convertTests = [...]struct {
+	v    ast.Value
+	t    ast.Type
+	want ast.Value
+}{
+	{ast.IntVal(300), ast.CHAR_TYPE, ast.CharVal(44)},
+	{ast.IntVal(-1), ast.CHAR_TYPE, ast.CharVal(255)},
+	{ast.CharVal('A'), ast.INT_TYPE, ast.IntVal(65)},
+	{ast.FloatVal(-2.9), ast.INT_TYPE, ast.IntVal(-2)},
+	{ast.FloatVal(1e10), ast.INT_TYPE, ast.IntVal(math.MinInt32)},
+	{ast.IntVal(7), ast.FLOAT_TYPE, ast.FloatVal(7)},
+	{ast.FloatVal(0.5), ast.BOOL_TYPE, ast.BoolVal(true)},
+	{ast.IntVal(0), ast.BOOL_TYPE, ast.BoolVal(false)},
+	{ast.CharVal(0xe9), ast.STRING_TYPE, ast.StringVal("\xe9")},
+}
+
+func TestConvert(t *testing.T) {
+	for _, test := range convertTests {
+		if got := Convert(test.v, test.t); got != test.want {
+			t.Errorf("%s(%s) = %s, want %s", test.t, test.v, got, test.want)
+		}
+	}
+}
+
+func TestExpr(t *testing.T) {
+	env := func(v ast.Variable) (ast.Value, bool) {
+		if v == "x" {
+			return ast.IntVal(4), true
+		}
+		return nil, false
+	}
+	// x * 2 + -float(x) / 8
+	e := &ast.Binary{
+		Op:    "+",
+		Term1: &ast.Binary{Op: "*", Term1: ast.Variable("x"), Term2: ast.IntVal(2)},
+		Term2: &ast.Binary{
+			Op:    "/",
+			Term1: &ast.Unary{Op: "-", Term: &ast.Unary{Op: "float", Term: ast.Variable("x")}},
+			Term2: ast.IntVal(8),
+		},
+	}
+	if v, err := Expr(e, env); err != nil || v != ast.FloatVal(7.5) {
+		t.Errorf("got %v, %v", v, err)
+	}
+	if _, err := Expr(e, nil); err != UndefinedError("x") {
+		t.Errorf("constant evaluation of a variable: %v", err)
+	}
+
+	// "héllo"[1] and len("héllo"), in chars
+	s := ast.StringVal("h\xe9llo")
+	if v, err := Expr(&ast.Index{X: s, Index: ast.CharVal(1)}, nil); err != nil || v != ast.CharVal(0xe9) {
+		t.Errorf("index: %v, %v", v, err)
+	}
+	if v, err := Expr(&ast.Unary{Op: "len", Term: s}, nil); err != nil || v != ast.IntVal(5) {
+		t.Errorf("len: %v, %v", v, err)
+	}
+	if _, err := Expr(&ast.Index{X: s, Index: ast.IntVal(5)}, nil); err != (IndexError{5, 5}) {
+		t.Errorf("index out of range: %v", err)
+	}
+
+	// ~'a', an int
+	if v, err := Expr(&ast.Unary{Op: "~", Term: ast.CharVal('a')}, nil); err != nil || v != ast.IntVal(-98) {
+		t.Errorf("complement: %v, %v", v, err)
+	}
+
+	// the right operand of || is not evaluated
+	e = &ast.Binary{Op: "||", Term1: ast.BoolVal(true), Term2: ast.Variable("y")}
+	if v, err := Expr(e, env); err != nil || v != ast.BoolVal(true) {
+		t.Errorf("short circuit: %v, %v", v, err)
+	}
+
+	// x > 2 ? 1 : y / 0.5, promoted to float; y is not evaluated
+	c := &ast.Ternary{
+		Test: &ast.Binary{Op: ">", Term1: ast.Variable("x"), Term2: ast.IntVal(2)},
+		Then: ast.IntVal(1),
+		Else: &ast.Binary{Op: "/", Term1: ast.Variable("y"), Term2: ast.FloatVal(0.5)},
+	}
+	if v, err := Expr(c, env); err != nil || v != ast.FloatVal(1) {
+		t.Errorf("conditional: %v, %v", v, err)
+	}
+}
+
+func TestStruct(t *testing.T) {
+	in := &ast.Struct{Name: "In", Fields: []*ast.Field{{Name: "s", T: ast.STRING_TYPE}}}
+	out := &ast.Struct{Name: "Out", Fields: []*ast.Field{{Name: "x", T: ast.INT_TYPE}, {Name: "in", T: in}}}
+	z := Zero(out).(*ast.StructVal)
+	if z.String() != `{x: 0, in: {s: }}` {
+		t.Errorf("zero: got %s", z)
+	}
+	z.Fields[1].(*ast.StructVal).Fields[0] = ast.StringVal("ab")
+	env := func(v ast.Variable) (ast.Value, bool) { return z, v == "o" }
+	// o.in.s[1]
+	e := &ast.Index{X: &ast.Selector{X: &ast.Selector{X: ast.Variable("o"), Field: "in"}, Field: "s"}, Index: ast.IntVal(1)}
+	if v, err := Expr(e, env); err != nil || v != ast.CharVal('b') {
+		t.Errorf("selector: %v, %v", v, err)
+	}
+}
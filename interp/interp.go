@@ -0,0 +1,992 @@
+// Package interp runs clite programs.
+//
+// A program is compiled once into a tree of Go closures. Variables are
+// resolved to indexes into slices holding the values of each type and
+// operators to functions on the types of their operands, so that
+// running the program involves no map lookups, type switches or
+// boxing of values. Operators have the meaning given to them by
+// package eval.
+package interp
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"sync"
+
+	"github.com/mentalpumkins/clite-go/ast"
+	"github.com/mentalpumkins/clite-go/codegen"
+	"github.com/mentalpumkins/clite-go/eval"
+	"github.com/mentalpumkins/clite-go/token"
+	"github.com/mentalpumkins/clite-go/types"
+)
+
+// An Error is an error raised while running the statement at Pos.
+type Error struct {
+	Pos token.Position
+	Err error
+}
+
+func (e *Error) Error() string { return fmt.Sprintf("%s: %v", e.Pos, e.Err) }
+
+// Unwrap returns e.Err.
+func (e *Error) Unwrap() error { return e.Err }
+
+// A machine holds the state of a running program. ints holds the
+// values of int and char variables, chars being kept in [0, 255]. The
+// fields of struct variables are held like variables, in the slices
+// for their types.
+type machine struct {
+	ints   []int32
+	floats []float64
+	bools  []bool
+	strs   []string
+
+	loop *ast.Loop // the innermost loop running
+
+	trace *tracer // or nil
+
+	ctx  context.Context
+	done <-chan struct{} // of ctx
+	// the steps the machine may take before calling refill, and
+	// those left to grant it after these, or -1 for no limit
+	budget, left int64
+	// the bytes strings may still take, or -1 for no limit
+	memLeft int64
+}
+
+// The compiled forms of expressions, by type, and of statements.
+// Errors are raised by panicking with an *Error or a *LimitError.
+type (
+	intExpr    func(*machine) int32
+	floatExpr  func(*machine) float64
+	boolExpr   func(*machine) bool
+	stringExpr func(*machine) string
+	stmt       func(*machine)
+)
+
+// A Program is a compiled clite program. It may be run any number of
+// times, concurrently.
+type Program struct {
+	vars  []codegen.Var
+	slots [][]int // of each variable, as given by compiler.slots
+	// the number of slots of each slice
+	nints, nfloats, nbools, nstrs int
+	body                          stmt
+
+	// for compiling the body recording its trace when first needed
+	prog       *ast.Program
+	c          *compiler
+	once       sync.Once
+	tracedBody stmt
+}
+
+// A Builtin is a function provided by the host that programs may
+// call. Func is called with arguments of the types of the parameters
+// and must return a value of the result type. Neither may be a struct
+// type.
+type Builtin struct {
+	types.Signature
+	Func func(args []ast.Value) (ast.Value, error)
+}
+
+// Builtins maps the names of builtins to them. A Program calls those
+// given to CompileBuiltins, so that runs not sharing state must be
+// compiled with Builtins not sharing state.
+type Builtins map[string]Builtin
+
+// Compile type checks prog and compiles it.
+func Compile(prog *ast.Program) (*Program, error) {
+	return CompileBuiltins(prog, nil)
+}
+
+// CompileBuiltins is like Compile for a program that may call the
+// functions of b.
+func CompileBuiltins(prog *ast.Program, b Builtins) (*Program, error) {
+	funcs := make(types.Funcs)
+	for name, f := range b {
+		for _, t := range append([]ast.Type{f.Result}, f.Params...) {
+			if _, ok := t.(*ast.Struct); ok {
+				return nil, fmt.Errorf("builtin %s: struct parameters and results are not supported", name)
+			}
+		}
+		funcs[name] = f.Signature
+	}
+	tm, consts, vars, err := codegen.CheckFuncs(prog, funcs)
+	if err != nil {
+		return nil, err
+	}
+	p := &Program{vars: vars}
+	c := &compiler{tm: tm, consts: consts, slot: make(map[ast.Variable][]int), builtins: b}
+	for _, v := range vars {
+		var slots []int
+		for _, t := range leaves(v.Type) {
+			var n *int
+			switch t {
+			case ast.FLOAT_TYPE:
+				n = &p.nfloats
+			case ast.BOOL_TYPE:
+				n = &p.nbools
+			case ast.STRING_TYPE:
+				n = &p.nstrs
+			default:
+				n = &p.nints
+			}
+			slots = append(slots, *n)
+			*n++
+		}
+		c.slot[v.Name] = slots
+		p.slots = append(p.slots, slots)
+	}
+	p.body = c.block(prog.Body)
+	p.prog, p.c = prog, c
+	return p, nil
+}
+
+// Vars returns the variables of p in declaration order.
+func (p *Program) Vars() []codegen.Var { return p.vars }
+
+// Run runs p, with all variables initially zero, and returns the final
+// values of its variables in declaration order.
+func (p *Program) Run() ([]ast.Value, error) {
+	return p.RunContext(context.Background(), Options{})
+}
+
+// RunContext is like Run, but stops with a *LimitError when ctx is
+// done or when a limit set by opts is hit.
+func (p *Program) RunContext(ctx context.Context, opts Options) (vals []ast.Value, err error) {
+	if err := ctx.Err(); err != nil {
+		return nil, &LimitError{Err: err}
+	}
+	if opts.MaxMemory > 0 && p.memory() > opts.MaxMemory {
+		return nil, &LimitError{Err: ErrMemoryLimit}
+	}
+	m := &machine{
+		ints:    make([]int32, p.nints),
+		floats:  make([]float64, p.nfloats),
+		bools:   make([]bool, p.nbools),
+		strs:    make([]string, p.nstrs),
+		ctx:     ctx,
+		done:    ctx.Done(),
+		left:    -1,
+		memLeft: -1,
+	}
+	if opts.MaxSteps > 0 {
+		m.left = opts.MaxSteps
+	}
+	if err := p.set(m, opts.Inputs); err != nil {
+		return nil, err
+	}
+	if opts.MaxMemory > 0 {
+		m.memLeft = opts.MaxMemory - p.memory()
+		for _, s := range m.strs {
+			m.memLeft -= int64(len(s))
+		}
+		if m.memLeft < 0 {
+			return nil, &LimitError{Err: ErrMemoryLimit}
+		}
+	}
+	body := p.body
+	if opts.Trace != nil {
+		body = p.traced()
+		w := bufio.NewWriter(opts.Trace)
+		m.trace = &tracer{enc: json.NewEncoder(w)}
+		defer func() {
+			if ferr := w.Flush(); ferr != nil && err == nil {
+				vals, err = nil, fmt.Errorf("interp: writing trace: %v", ferr)
+			}
+		}()
+	}
+	defer func() {
+		if r := recover(); r != nil {
+			switch e := r.(type) {
+			case *Error:
+				vals, err = nil, e
+			case *LimitError:
+				vals, err = nil, e
+			case traceError:
+				vals, err = nil, fmt.Errorf("interp: writing trace: %v", e.err)
+			default:
+				panic(r)
+			}
+		}
+	}()
+	body(m)
+
+	vals = make([]ast.Value, len(p.vars))
+	for i, v := range p.vars {
+		vals[i] = m.value(v.Type, p.slots[i])
+	}
+	return vals, nil
+}
+
+// value returns the value of type t held by m in slots.
+func (m *machine) value(t ast.Type, slots []int) ast.Value {
+	if st, ok := t.(*ast.Struct); ok {
+		v := &ast.StructVal{T: st, Fields: make([]ast.Value, len(st.Fields))}
+		for i, f := range st.Fields {
+			n := len(leaves(f.T))
+			v.Fields[i] = m.value(f.T, slots[:n])
+			slots = slots[n:]
+		}
+		return v
+	}
+	n := slots[0]
+	switch t {
+	case ast.CHAR_TYPE:
+		return ast.CharVal(m.ints[n])
+	case ast.FLOAT_TYPE:
+		return ast.FloatVal(m.floats[n])
+	case ast.BOOL_TYPE:
+		return ast.BoolVal(m.bools[n])
+	case ast.STRING_TYPE:
+		return ast.StringVal(m.strs[n])
+	}
+	return ast.IntVal(m.ints[n])
+}
+
+// set gives the variables of m the values of inputs.
+func (p *Program) set(m *machine, inputs map[ast.Variable]ast.Value) error {
+	for name, val := range inputs {
+		i := p.index(name)
+		if i < 0 {
+			return fmt.Errorf("interp: input %s is not a variable", name)
+		}
+		if t := p.vars[i].Type; val == nil || val.GetType() != t {
+			return fmt.Errorf("interp: cannot use %v as input %s of type %s", val, name, t)
+		}
+		m.set(val, p.slots[i])
+	}
+	return nil
+}
+
+// set stores val in slots of m.
+func (m *machine) set(val ast.Value, slots []int) {
+	n := slots[0]
+	switch val := val.(type) {
+	case ast.IntVal:
+		m.ints[n] = int32(val)
+	case ast.CharVal:
+		m.ints[n] = int32(uint8(val))
+	case ast.FloatVal:
+		m.floats[n] = float64(val)
+	case ast.BoolVal:
+		m.bools[n] = bool(val)
+	case ast.StringVal:
+		m.strs[n] = string(val)
+	case *ast.StructVal:
+		for i, f := range val.T.Fields {
+			k := len(leaves(f.T))
+			m.set(val.Fields[i], slots[:k])
+			slots = slots[k:]
+		}
+	}
+}
+
+// index returns the index of the variable name in p.vars, or -1.
+func (p *Program) index(name ast.Variable) int {
+	for i, v := range p.vars {
+		if v.Name == name {
+			return i
+		}
+	}
+	return -1
+}
+
+type compiler struct {
+	tm       *types.TypeMap
+	consts   types.Consts
+	slot     map[ast.Variable][]int
+	builtins Builtins
+	trace    bool           // compile statements to record their trace
+	pos      token.Position // of the statement being compiled
+}
+
+func (c *compiler) block(list []ast.Stmt) stmt {
+	var body []stmt
+	for _, s := range list {
+		if s := c.stmt(s); s != nil {
+			body = append(body, s)
+		}
+	}
+	switch len(body) {
+	case 0:
+		return func(*machine) {}
+	case 1:
+		return body[0]
+	}
+	return func(m *machine) {
+		for _, s := range body {
+			s(m)
+		}
+	}
+}
+
+// stmt compiles s, returning nil for a statement doing nothing unless
+// c records the trace.
+func (c *compiler) stmt(s ast.Stmt) stmt {
+	run := c.stmt0(s)
+	if !c.trace || s == nil {
+		return run
+	}
+	if run == nil {
+		run = func(*machine) {}
+	}
+	return c.traceStmt(s, run)
+}
+
+func (c *compiler) stmt0(s ast.Stmt) stmt {
+	switch s := s.(type) {
+	case *ast.Block:
+		if len(s.Members) == 0 {
+			return nil
+		}
+		return c.block(s.Members)
+	case *ast.Assignment:
+		c.pos = s.Pos
+		lv := s.Lvalue()
+		if _, ok := c.tm.TypeOf(lv).(*ast.Struct); ok {
+			x := c.copy(c.slots(lv), s.Source)
+			return func(m *machine) {
+				m.tick()
+				x(m)
+			}
+		}
+		n := c.slots(lv)[0]
+		switch c.tm.TypeOf(lv) {
+		case ast.FLOAT_TYPE:
+			x := c.float(s.Source)
+			return func(m *machine) {
+				m.tick()
+				m.floats[n] = x(m)
+			}
+		case ast.BOOL_TYPE:
+			x := c.bool(s.Source)
+			return func(m *machine) {
+				m.tick()
+				m.bools[n] = x(m)
+			}
+		case ast.STRING_TYPE:
+			x := c.string(s.Source)
+			return func(m *machine) {
+				m.tick()
+				v := x(m)
+				m.alloc(len(v) - len(m.strs[n]))
+				m.strs[n] = v
+			}
+		}
+		x := c.int(s.Source)
+		return func(m *machine) {
+			m.tick()
+			m.ints[n] = x(m)
+		}
+	case *ast.Conditional:
+		c.pos = s.Pos
+		cond := c.bool(s.Test)
+		test := func(m *machine) bool {
+			m.tick()
+			return cond(m)
+		}
+		if c.trace {
+			test = c.traceTest("conditional", s.Pos, test)
+		}
+		then, els := c.stmt(s.Body), c.stmt(s.Else)
+		switch {
+		case then == nil && els == nil:
+			return func(m *machine) { test(m) }
+		case els == nil:
+			return func(m *machine) {
+				if test(m) {
+					then(m)
+				}
+			}
+		case then == nil:
+			return func(m *machine) {
+				if !test(m) {
+					els(m)
+				}
+			}
+		}
+		return func(m *machine) {
+			if test(m) {
+				then(m)
+			} else {
+				els(m)
+			}
+		}
+	case *ast.Switch:
+		c.pos = s.Pos
+		tag := c.int(s.Tag)
+		type span struct {
+			lo, hi int64
+			i      int // of the case
+		}
+		var spans []span
+		def := -1
+		bodies := make([]stmt, len(s.Cases))
+		for i, k := range s.Cases {
+			if len(k.Labels) == 0 {
+				def = i
+			}
+			for _, l := range k.Labels {
+				lo, hi, _ := eval.Label(l, c.consts.Lookup)
+				spans = append(spans, span{lo, hi, i})
+			}
+			bodies[i] = c.block(k.Body)
+		}
+		choose := func(m *machine) int {
+			m.tick()
+			v := int64(tag(m))
+			for _, sp := range spans {
+				if sp.lo <= v && v <= sp.hi {
+					return sp.i
+				}
+			}
+			return def
+		}
+		if c.trace {
+			choose = c.traceCase(s.Pos, choose)
+		}
+		cases := s.Cases
+		return func(m *machine) {
+			for i := choose(m); i >= 0; i++ {
+				bodies[i](m)
+				if !cases[i].Fallthrough {
+					break
+				}
+			}
+		}
+	case *ast.Loop:
+		c.pos = s.Pos
+		loop := s
+		test := c.bool(s.Test)
+		if c.trace {
+			test = c.traceTest("loop", s.Pos, test)
+		}
+		body := c.stmt(s.Body)
+		if body == nil {
+			body = func(*machine) {}
+		}
+		return func(m *machine) {
+			outer := m.loop
+			m.loop = loop
+			for m.tick(); test(m); m.tick() {
+				body(m)
+			}
+			m.loop = outer
+		}
+	}
+	return nil
+}
+
+// slots returns the slots of e, a variable or the selection of a field
+// of one, in the slices for the types of its values: one for a value
+// of a basic type, and one for each field of a basic type of a struct,
+// through nested structs, in order.
+func (c *compiler) slots(e ast.Expr) []int {
+	switch e := e.(type) {
+	case ast.Variable:
+		return c.slot[e]
+	case *ast.Selector:
+		slots := c.slots(e.X)
+		st := c.tm.TypeOf(e.X).(*ast.Struct)
+		for _, f := range st.Fields {
+			n := len(leaves(f.T))
+			if f.Name == e.Field {
+				return slots[:n]
+			}
+			slots = slots[n:]
+		}
+	}
+	panic(fmt.Sprintf("interp: cannot compile %v as a variable", e))
+}
+
+// leaves returns the types of the slots of a value of type t.
+func leaves(t ast.Type) []ast.Type {
+	st, ok := t.(*ast.Struct)
+	if !ok {
+		return []ast.Type{t}
+	}
+	var l []ast.Type
+	for _, f := range st.Fields {
+		l = append(l, leaves(f.T)...)
+	}
+	return l
+}
+
+// unfold moves the selection e into the branches of the conditional
+// it selects from, if any, so that (b ? p : q).x is compiled as
+// b ? p.x : q.x. It returns e itself otherwise.
+func unfold(e *ast.Selector) ast.Expr {
+	x := e.X
+	if s, ok := x.(*ast.Selector); ok {
+		x = unfold(s)
+	}
+	if t, ok := x.(*ast.Ternary); ok {
+		return &ast.Ternary{
+			Test: t.Test,
+			Then: unfold(&ast.Selector{X: t.Then, Field: e.Field}),
+			Else: unfold(&ast.Selector{X: t.Else, Field: e.Field}),
+		}
+	}
+	return e
+}
+
+// copy compiles the assignment of the struct e to the slots dst.
+func (c *compiler) copy(dst []int, e ast.Expr) func(*machine) {
+	switch e := e.(type) {
+	case *ast.Ternary:
+		test, x, y := c.bool(e.Test), c.copy(dst, e.Then), c.copy(dst, e.Else)
+		return func(m *machine) {
+			if test(m) {
+				x(m)
+			} else {
+				y(m)
+			}
+		}
+	case *ast.Selector:
+		if x := unfold(e); x != ast.Expr(e) {
+			return c.copy(dst, x)
+		}
+	}
+	// the slots to copy of each slice, as pairs of destination and
+	// source
+	var ints, floats, bools, strs [][2]int
+	src := c.slots(e)
+	for i, t := range leaves(c.tm.TypeOf(e)) {
+		pair := [2]int{dst[i], src[i]}
+		switch t {
+		case ast.FLOAT_TYPE:
+			floats = append(floats, pair)
+		case ast.BOOL_TYPE:
+			bools = append(bools, pair)
+		case ast.STRING_TYPE:
+			strs = append(strs, pair)
+		default:
+			ints = append(ints, pair)
+		}
+	}
+	return func(m *machine) {
+		for _, p := range ints {
+			m.ints[p[0]] = m.ints[p[1]]
+		}
+		for _, p := range floats {
+			m.floats[p[0]] = m.floats[p[1]]
+		}
+		for _, p := range bools {
+			m.bools[p[0]] = m.bools[p[1]]
+		}
+		for _, p := range strs {
+			m.alloc(len(m.strs[p[1]]) - len(m.strs[p[0]]))
+			m.strs[p[0]] = m.strs[p[1]]
+		}
+	}
+}
+
+// int compiles e, of type int or char.
+func (c *compiler) int(e ast.Expr) intExpr {
+	switch e := e.(type) {
+	case ast.Variable:
+		if k, ok := c.consts[e]; ok {
+			return c.int(k)
+		}
+		n := c.slot[e][0]
+		return func(m *machine) int32 { return m.ints[n] }
+	case *ast.Selector:
+		if x := unfold(e); x != ast.Expr(e) {
+			return c.int(x)
+		}
+		n := c.slots(e)[0]
+		return func(m *machine) int32 { return m.ints[n] }
+	case ast.IntVal:
+		k := int32(e)
+		return func(*machine) int32 { return k }
+	case ast.CharVal:
+		k := int32(e)
+		return func(*machine) int32 { return k }
+	case *ast.Binary:
+		x, y := c.int(e.Term1), c.int(e.Term2)
+		switch e.Op {
+		case "+":
+			return func(m *machine) int32 { return x(m) + y(m) }
+		case "-":
+			return func(m *machine) int32 { return x(m) - y(m) }
+		case "*":
+			return func(m *machine) int32 { return x(m) * y(m) }
+		case "/":
+			pos := c.pos
+			return func(m *machine) int32 {
+				a, b := x(m), y(m)
+				if b == 0 {
+					panic(&Error{pos, eval.ErrDivByZero})
+				}
+				// the most negative int divided by -1 is
+				// itself, as in eval
+				return a / b
+			}
+		case "%":
+			pos := c.pos
+			return func(m *machine) int32 {
+				a, b := x(m), y(m)
+				if b == 0 {
+					panic(&Error{pos, eval.ErrDivByZero})
+				}
+				return a % b
+			}
+		case "&":
+			return func(m *machine) int32 { return x(m) & y(m) }
+		case "|":
+			return func(m *machine) int32 { return x(m) | y(m) }
+		case "^":
+			return func(m *machine) int32 { return x(m) ^ y(m) }
+		case "<<":
+			return func(m *machine) int32 { return x(m) << uint(y(m)&31) }
+		case ">>":
+			return func(m *machine) int32 { return x(m) >> uint(y(m)&31) }
+		}
+	case *ast.Unary:
+		switch e.Op {
+		case "-":
+			x := c.int(e.Term)
+			return func(m *machine) int32 { return -x(m) }
+		case "~":
+			x := c.int(e.Term)
+			return func(m *machine) int32 { return ^x(m) }
+		case "int":
+			if c.tm.TypeOf(e.Term) == ast.FLOAT_TYPE {
+				x := c.float(e.Term)
+				return func(m *machine) int32 { return ftoi(x(m)) }
+			}
+			return c.int(e.Term)
+		case "char":
+			x := c.int(e.Term)
+			return func(m *machine) int32 { return int32(uint8(x(m))) }
+		case "len":
+			x := c.string(e.Term)
+			return func(m *machine) int32 { return int32(len(x(m))) }
+		}
+	case *ast.Index:
+		x, i := c.string(e.X), c.int(e.Index)
+		pos := c.pos
+		return func(m *machine) int32 {
+			s, n := x(m), i(m)
+			if n < 0 || int(n) >= len(s) {
+				panic(&Error{pos, eval.IndexError{Index: int(n), Len: len(s)}})
+			}
+			return int32(s[n])
+		}
+	case *ast.Ternary:
+		test, x, y := c.bool(e.Test), c.int(e.Then), c.int(e.Else)
+		return func(m *machine) int32 {
+			if test(m) {
+				return x(m)
+			}
+			return y(m)
+		}
+	case *ast.Call:
+		f := c.call(e)
+		if c.tm.TypeOf(e) == ast.CHAR_TYPE {
+			return func(m *machine) int32 { return int32(uint8(f(m).(ast.CharVal))) }
+		}
+		return func(m *machine) int32 { return int32(f(m).(ast.IntVal)) }
+	}
+	panic(fmt.Sprintf("interp: cannot compile %v as int", e))
+}
+
+// ftoi converts f to an int as eval.Convert does.
+func ftoi(f float64) int32 {
+	f = math.Trunc(f)
+	if f != f || f < math.MinInt32 || f > math.MaxInt32 {
+		return math.MinInt32
+	}
+	return int32(f)
+}
+
+// float compiles e, of type float or int.
+func (c *compiler) float(e ast.Expr) floatExpr {
+	if c.tm.TypeOf(e) != ast.FLOAT_TYPE {
+		x := c.int(e)
+		return func(m *machine) float64 { return float64(x(m)) }
+	}
+	switch e := e.(type) {
+	case ast.Variable:
+		if k, ok := c.consts[e]; ok {
+			return c.float(k)
+		}
+		n := c.slot[e][0]
+		return func(m *machine) float64 { return m.floats[n] }
+	case *ast.Selector:
+		if x := unfold(e); x != ast.Expr(e) {
+			return c.float(x)
+		}
+		n := c.slots(e)[0]
+		return func(m *machine) float64 { return m.floats[n] }
+	case ast.FloatVal:
+		k := float64(e)
+		return func(*machine) float64 { return k }
+	case *ast.Binary:
+		x, y := c.float(e.Term1), c.float(e.Term2)
+		switch e.Op {
+		case "+":
+			return func(m *machine) float64 { return x(m) + y(m) }
+		case "-":
+			return func(m *machine) float64 { return x(m) - y(m) }
+		case "*":
+			return func(m *machine) float64 { return x(m) * y(m) }
+		case "/":
+			return func(m *machine) float64 { return x(m) / y(m) }
+		}
+	case *ast.Unary:
+		switch e.Op {
+		case "-":
+			x := c.float(e.Term)
+			return func(m *machine) float64 { return -x(m) }
+		case "float":
+			return c.float(e.Term)
+		}
+	case *ast.Ternary:
+		test, x, y := c.bool(e.Test), c.float(e.Then), c.float(e.Else)
+		return func(m *machine) float64 {
+			if test(m) {
+				return x(m)
+			}
+			return y(m)
+		}
+	case *ast.Call:
+		f := c.call(e)
+		return func(m *machine) float64 { return float64(f(m).(ast.FloatVal)) }
+	}
+	panic(fmt.Sprintf("interp: cannot compile %v as float", e))
+}
+
+// bool compiles e, of type bool.
+func (c *compiler) bool(e ast.Expr) boolExpr {
+	switch e := e.(type) {
+	case ast.Variable:
+		if k, ok := c.consts[e]; ok {
+			return c.bool(k)
+		}
+		n := c.slot[e][0]
+		return func(m *machine) bool { return m.bools[n] }
+	case *ast.Selector:
+		if x := unfold(e); x != ast.Expr(e) {
+			return c.bool(x)
+		}
+		n := c.slots(e)[0]
+		return func(m *machine) bool { return m.bools[n] }
+	case ast.BoolVal:
+		k := bool(e)
+		return func(*machine) bool { return k }
+	case *ast.Binary:
+		switch e.Op {
+		case "&&":
+			x, y := c.bool(e.Term1), c.bool(e.Term2)
+			return func(m *machine) bool { return x(m) && y(m) }
+		case "||":
+			x, y := c.bool(e.Term1), c.bool(e.Term2)
+			return func(m *machine) bool { return x(m) || y(m) }
+		}
+		return c.compare(e)
+	case *ast.Unary:
+		switch e.Op {
+		case "!":
+			x := c.bool(e.Term)
+			return func(m *machine) bool { return !x(m) }
+		case "bool":
+			switch c.tm.TypeOf(e.Term) {
+			case ast.BOOL_TYPE:
+				return c.bool(e.Term)
+			case ast.FLOAT_TYPE:
+				x := c.float(e.Term)
+				return func(m *machine) bool { return x(m) != 0 }
+			}
+			x := c.int(e.Term)
+			return func(m *machine) bool { return x(m) != 0 }
+		}
+	case *ast.Ternary:
+		test, x, y := c.bool(e.Test), c.bool(e.Then), c.bool(e.Else)
+		return func(m *machine) bool {
+			if test(m) {
+				return x(m)
+			}
+			return y(m)
+		}
+	case *ast.Call:
+		f := c.call(e)
+		return func(m *machine) bool { return bool(f(m).(ast.BoolVal)) }
+	}
+	panic(fmt.Sprintf("interp: cannot compile %v as bool", e))
+}
+
+// string compiles e, of type string.
+func (c *compiler) string(e ast.Expr) stringExpr {
+	switch e := e.(type) {
+	case ast.Variable:
+		if k, ok := c.consts[e]; ok {
+			return c.string(k)
+		}
+		n := c.slot[e][0]
+		return func(m *machine) string { return m.strs[n] }
+	case *ast.Selector:
+		if x := unfold(e); x != ast.Expr(e) {
+			return c.string(x)
+		}
+		n := c.slots(e)[0]
+		return func(m *machine) string { return m.strs[n] }
+	case ast.StringVal:
+		k := string(e)
+		return func(*machine) string { return k }
+	case *ast.Binary:
+		if e.Op == "+" {
+			x, y := c.string(e.Term1), c.string(e.Term2)
+			return func(m *machine) string { return x(m) + y(m) }
+		}
+	case *ast.Unary:
+		if e.Op == "string" {
+			x := c.int(e.Term)
+			return func(m *machine) string { return string([]byte{uint8(x(m))}) }
+		}
+	case *ast.Ternary:
+		test, x, y := c.bool(e.Test), c.string(e.Then), c.string(e.Else)
+		return func(m *machine) string {
+			if test(m) {
+				return x(m)
+			}
+			return y(m)
+		}
+	case *ast.Call:
+		f := c.call(e)
+		return func(m *machine) string { return string(f(m).(ast.StringVal)) }
+	}
+	panic(fmt.Sprintf("interp: cannot compile %v as string", e))
+}
+
+// compare compiles the relational expression e, whose operands have
+// the same type.
+func (c *compiler) compare(e *ast.Binary) boolExpr {
+	switch c.tm.TypeOf(e.Term1) {
+	case ast.STRING_TYPE:
+		x, y := c.string(e.Term1), c.string(e.Term2)
+		switch e.Op {
+		case "<":
+			return func(m *machine) bool { return x(m) < y(m) }
+		case "<=":
+			return func(m *machine) bool { return x(m) <= y(m) }
+		case ">":
+			return func(m *machine) bool { return x(m) > y(m) }
+		case ">=":
+			return func(m *machine) bool { return x(m) >= y(m) }
+		case "==":
+			return func(m *machine) bool { return x(m) == y(m) }
+		case "!=":
+			return func(m *machine) bool { return x(m) != y(m) }
+		}
+	case ast.FLOAT_TYPE:
+		// comparisons involving NaN are false but for !=, as in
+		// eval
+		x, y := c.float(e.Term1), c.float(e.Term2)
+		switch e.Op {
+		case "<":
+			return func(m *machine) bool { return x(m) < y(m) }
+		case "<=":
+			return func(m *machine) bool { return x(m) <= y(m) }
+		case ">":
+			return func(m *machine) bool { return x(m) > y(m) }
+		case ">=":
+			return func(m *machine) bool { return x(m) >= y(m) }
+		case "==":
+			return func(m *machine) bool { return x(m) == y(m) }
+		case "!=":
+			return func(m *machine) bool { return x(m) != y(m) }
+		}
+	case ast.BOOL_TYPE:
+		// false is less than true; both operands are evaluated
+		x, y := c.bool(e.Term1), c.bool(e.Term2)
+		switch e.Op {
+		case "<":
+			return func(m *machine) bool {
+				a, b := x(m), y(m)
+				return !a && b
+			}
+		case "<=":
+			return func(m *machine) bool {
+				a, b := x(m), y(m)
+				return !a || b
+			}
+		case ">":
+			return func(m *machine) bool {
+				a, b := x(m), y(m)
+				return a && !b
+			}
+		case ">=":
+			return func(m *machine) bool {
+				a, b := x(m), y(m)
+				return a || !b
+			}
+		case "==":
+			return func(m *machine) bool { return x(m) == y(m) }
+		case "!=":
+			return func(m *machine) bool { return x(m) != y(m) }
+		}
+	default:
+		x, y := c.int(e.Term1), c.int(e.Term2)
+		switch e.Op {
+		case "<":
+			return func(m *machine) bool { return x(m) < y(m) }
+		case "<=":
+			return func(m *machine) bool { return x(m) <= y(m) }
+		case ">":
+			return func(m *machine) bool { return x(m) > y(m) }
+		case ">=":
+			return func(m *machine) bool { return x(m) >= y(m) }
+		case "==":
+			return func(m *machine) bool { return x(m) == y(m) }
+		case "!=":
+			return func(m *machine) bool { return x(m) != y(m) }
+		}
+	}
+	panic(fmt.Sprintf("interp: cannot compile %v", e))
+}
+
+// call compiles the call e of a builtin. The compiled call checks that
+// the builtin returns a value of its result type.
+func (c *compiler) call(e *ast.Call) func(*machine) ast.Value {
+	b := c.builtins[e.Name]
+	args := make([]func(*machine) ast.Value, len(e.Args))
+	for i, x := range e.Args {
+		switch b.Params[i] {
+		case ast.INT_TYPE:
+			x := c.int(x)
+			args[i] = func(m *machine) ast.Value { return ast.IntVal(x(m)) }
+		case ast.CHAR_TYPE:
+			x := c.int(x)
+			args[i] = func(m *machine) ast.Value { return ast.CharVal(x(m)) }
+		case ast.FLOAT_TYPE:
+			x := c.float(x)
+			args[i] = func(m *machine) ast.Value { return ast.FloatVal(x(m)) }
+		case ast.BOOL_TYPE:
+			x := c.bool(x)
+			args[i] = func(m *machine) ast.Value { return ast.BoolVal(x(m)) }
+		case ast.STRING_TYPE:
+			x := c.string(x)
+			args[i] = func(m *machine) ast.Value { return ast.StringVal(x(m)) }
+		}
+	}
+	name, pos := e.Name, c.pos
+	return func(m *machine) ast.Value {
+		vals := make([]ast.Value, len(args))
+		for i, x := range args {
+			vals[i] = x(m)
+		}
+		v, err := b.Func(vals)
+		if err != nil {
+			panic(&Error{pos, fmt.Errorf("%s: %v", name, err)})
+		}
+		if v == nil || v.GetType() != b.Result {
+			panic(&Error{pos, fmt.Errorf("%s returned %v, not a value of type %s", name, v, b.Result)})
+		}
+		return v
+	}
+}
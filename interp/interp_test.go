@@ -0,0 +1,547 @@
+package interp
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"math"
+	"math/rand"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mentalpumkins/clite-go/ast"
+	"github.com/mentalpumkins/clite-go/codegen"
+	"github.com/mentalpumkins/clite-go/eval"
+	"github.com/mentalpumkins/clite-go/ir"
+	"github.com/mentalpumkins/clite-go/parser"
+	"github.com/mentalpumkins/clite-go/types"
+)
+
+func compile(t testing.TB, src string) *Program {
+	p, err := Compile(parser.MustParse(src))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return p
+}
+
+func TestRun(t *testing.T) {
+	files, _ := filepath.Glob("../codegen/testdata/*.cl")
+	if len(files) == 0 {
+		t.Fatal("no test programs")
+	}
+	more, _ := filepath.Glob("../codegen/testdata/strings/*.cl")
+	files = append(files, more...)
+	more, _ = filepath.Glob("../codegen/testdata/structs/*.cl")
+	files = append(files, more...)
+	for _, file := range files {
+		src, err := ioutil.ReadFile(file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		want, err := ioutil.ReadFile(strings.TrimSuffix(file, ".cl") + ".out")
+		if err != nil {
+			t.Fatal(err)
+		}
+		p := compile(t, string(src))
+		vals, err := p.Run()
+		if err != nil {
+			t.Fatalf("%s: %v", file, err)
+		}
+		var buf bytes.Buffer
+		for i, v := range p.Vars() {
+			fmt.Fprintf(&buf, "%s = "+codegen.Format(v.Type)+"\n", v.Name, vals[i])
+		}
+		if buf.String() != string(want) {
+			t.Errorf("%s left\n%s\nwant\n%s", file, buf.String(), want)
+		}
+	}
+}
+
+// Programs whose results are checked against those of the three
+// address code evaluator, which defers to package eval.
+var programs = []string{
+	`int main() {
+	int i, j, q;
+	float x, y;
+	char c;
+	bool a, b, n;
+	i = 2147483647 + 1;
+	j = i / -1;
+	q = -7 / 2;
+	x = float(i) * 1.5 - 3;
+	y = -x / 0.0;
+	c = char(300);
+	j = j + int(c) + int(-29000000000.0) + int(y - y) + int(-3.7);
+	a = c > 'A' && x != x;
+	b = y > 0.0 || a < true;
+	n = (0.0 / 0.0) != (0.0 / 0.0);
+	if (!(i <= j)) q = q * 3; else q = 0;
+}`,
+	`int main() {
+	int i, n, s;
+	float f;
+	bool done;
+	n = 100;
+	while (i < n && !done) {
+		s = s + i * i - s / (i + 1);
+		f = f + float(s) / 3.0;
+		i = i + 1;
+		done = f >= 1000000.0;
+	}
+}`,
+	`int main() {
+	bool t, f, r1, r2, r3, r4;
+	t = true;
+	r1 = f < t;
+	r2 = t <= f;
+	r3 = t > f;
+	r4 = f >= f == t;
+}`,
+}
+
+func TestSemantics(t *testing.T) {
+	for _, src := range programs {
+		prog := parser.MustParse(src)
+		q, err := ir.Lower(prog)
+		if err != nil {
+			t.Fatal(err)
+		}
+		want, err := ir.Eval(q)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, err := compile(t, src).Run()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("got %v, want %v for\n%s", got, want, src)
+		}
+	}
+}
+
+func TestError(t *testing.T) {
+	p := compile(t, `int main() {
+	int x, y;
+	x = 1;
+	while (x < 10) {
+		x = x + 1;
+		if (x > 5)
+			y = 7 / (x - 6);
+	}
+}`)
+	for i := 0; i < 2; i++ {
+		vals, err := p.Run()
+		e, ok := err.(*Error)
+		if !ok || vals != nil {
+			t.Fatalf("got %v, %v; want an *Error", vals, err)
+		}
+		if e.Pos.Line != 7 || !errors.Is(err, eval.ErrDivByZero) {
+			t.Errorf("got error %v", err)
+		}
+	}
+
+	if _, err := Compile(parser.MustParse(`int main() { int x; x = true; }`)); err == nil {
+		t.Error("ill typed program compiled")
+	}
+}
+
+func TestLimits(t *testing.T) {
+	// 1 assignment, 3 tests and 2 iterations
+	p := compile(t, `int main() {
+	int x;
+	x = 1;
+	while (x < 3) x = x + 1;
+}`)
+	if _, err := p.RunContext(context.Background(), Options{MaxSteps: 6, MaxMemory: 4}); err != nil {
+		t.Errorf("run within limits failed: %v", err)
+	}
+	_, err := p.RunContext(context.Background(), Options{MaxSteps: 5})
+	if e, ok := err.(*LimitError); !ok || e.Err != ErrStepLimit || e.Loop == nil || e.Loop.Pos.Line != 4 {
+		t.Errorf("got %v, want a step limit error in the loop", err)
+	}
+	_, err = p.RunContext(context.Background(), Options{MaxMemory: 3})
+	if e, ok := err.(*LimitError); !ok || e.Err != ErrMemoryLimit || e.Loop != nil {
+		t.Errorf("got %v, want a memory limit error", err)
+	}
+
+	hang := compile(t, `int main() {
+	int i;
+	while (i < 10) {
+		i = i + 1;
+		while (true) ;
+	}
+}`)
+	_, err = hang.RunContext(context.Background(), Options{MaxSteps: 1 << 20})
+	if e, ok := err.(*LimitError); !ok || e.Err != ErrStepLimit || e.Loop.Pos.Line != 5 {
+		t.Errorf("got %v, want a step limit error in the inner loop", err)
+	} else if want := "step limit exceeded in loop at 5:2"; e.Error() != want {
+		t.Errorf("got message %q, want %q", e, want)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	_, err = hang.RunContext(ctx, Options{})
+	if e, ok := err.(*LimitError); !ok || !errors.Is(err, context.DeadlineExceeded) || e.Loop.Pos.Line != 5 {
+		t.Errorf("got %v, want a deadline error in the inner loop", err)
+	}
+	cancel()
+	_, err = p.RunContext(ctx, Options{})
+	if e, ok := err.(*LimitError); !ok || e.Err != context.Canceled && e.Err != context.DeadlineExceeded {
+		t.Errorf("got %v, want the error of the context", err)
+	}
+}
+
+func TestInputs(t *testing.T) {
+	p := compile(t, `int main() {
+	int i;
+	char c;
+	float x;
+	bool b;
+	i = i + 1;
+	c = char(int(c) + 1);
+	x = x * 2.0;
+	b = !b;
+}`)
+	in := map[ast.Variable]ast.Value{
+		"i": ast.IntVal(41),
+		"c": ast.CharVal('a'),
+		"x": ast.FloatVal(1.25),
+		"b": ast.BoolVal(true),
+	}
+	vals, err := p.RunContext(context.Background(), Options{Inputs: in})
+	want := []ast.Value{ast.IntVal(42), ast.CharVal('b'), ast.FloatVal(2.5), ast.BoolVal(false)}
+	if err != nil || !reflect.DeepEqual(vals, want) {
+		t.Errorf("got %v, %v; want %v", vals, err, want)
+	}
+
+	for _, in := range []map[ast.Variable]ast.Value{
+		{"y": ast.IntVal(1)},
+		{"x": ast.IntVal(1)},
+		{"i": nil},
+	} {
+		if _, err := p.RunContext(context.Background(), Options{Inputs: in}); err == nil {
+			t.Errorf("inputs %v accepted", in)
+		}
+	}
+}
+
+// builtins returns builtins for tests, with a random number generator
+// of their own.
+func builtins(seed int64) Builtins {
+	r := rand.New(rand.NewSource(seed))
+	return Builtins{
+		"sqrt": {
+			types.Signature{Params: []ast.Type{ast.FLOAT_TYPE}, Result: ast.FLOAT_TYPE},
+			func(args []ast.Value) (ast.Value, error) {
+				x := float64(args[0].(ast.FloatVal))
+				if x < 0 {
+					return nil, errors.New("negative argument")
+				}
+				return ast.FloatVal(math.Sqrt(x)), nil
+			},
+		},
+		"rand": {
+			types.Signature{Params: []ast.Type{ast.INT_TYPE}, Result: ast.INT_TYPE},
+			func(args []ast.Value) (ast.Value, error) {
+				return ast.IntVal(r.Intn(int(args[0].(ast.IntVal)))), nil
+			},
+		},
+		"upper": {
+			types.Signature{Params: []ast.Type{ast.CHAR_TYPE}, Result: ast.CHAR_TYPE},
+			func(args []ast.Value) (ast.Value, error) {
+				c := args[0].(ast.CharVal)
+				if 'a' <= c && c <= 'z' {
+					c -= 'a' - 'A'
+				}
+				return c, nil
+			},
+		},
+		"even": {
+			types.Signature{Params: []ast.Type{ast.INT_TYPE}, Result: ast.BOOL_TYPE},
+			func(args []ast.Value) (ast.Value, error) {
+				return ast.BoolVal(args[0].(ast.IntVal)%2 == 0), nil
+			},
+		},
+		"bad": {
+			types.Signature{Result: ast.INT_TYPE},
+			func(args []ast.Value) (ast.Value, error) {
+				return ast.FloatVal(1), nil
+			},
+		},
+	}
+}
+
+func TestStrings(t *testing.T) {
+	p := compile(t, `int main() {
+	string s;
+	int i;
+	s = s + "ab";
+	while (i < 4) {
+		s = s + string(s[i]);
+		i = i + 1;
+	}
+	i = int(s[len(s)]);
+}`)
+	_, err := p.RunContext(context.Background(), Options{Inputs: map[ast.Variable]ast.Value{"s": ast.StringVal("x")}})
+	var ie eval.IndexError
+	if e, ok := err.(*Error); !ok || e.Pos.Line != 9 || !errors.As(err, &ie) || ie != (eval.IndexError{Index: 7, Len: 7}) {
+		t.Errorf("got %v, want an index error at line 9", err)
+	}
+
+	// 4 bytes for i, then "x" and 6 chars appended
+	if _, err := p.RunContext(context.Background(), Options{MaxMemory: 10}); err != nil && !errors.As(err, &ie) {
+		t.Errorf("run within memory limit failed: %v", err)
+	}
+	_, err = p.RunContext(context.Background(), Options{MaxMemory: 9})
+	if e, ok := err.(*LimitError); !ok || e.Err != ErrMemoryLimit || e.Loop == nil {
+		t.Errorf("got %v, want a memory limit error in the loop", err)
+	}
+	_, err = p.RunContext(context.Background(), Options{MaxMemory: 8, Inputs: map[ast.Variable]ast.Value{"s": ast.StringVal("12345")}})
+	if e, ok := err.(*LimitError); !ok || e.Err != ErrMemoryLimit || e.Loop != nil {
+		t.Errorf("got %v, want a memory limit error before the run", err)
+	}
+}
+
+func TestBuiltins(t *testing.T) {
+	src := `int main() {
+	int i, r, evens;
+	float x;
+	char c;
+	bool ok;
+	x = sqrt(2 + 7) * 2.0;
+	c = upper('q');
+	while (i < 100) {
+		r = rand(10);
+		if (even(r)) evens = evens + 1;
+		ok = r >= 0 && r < 10;
+		i = i + 1;
+	}
+	ok = ok && sqrt(x) == sqrt(6.0);
+}`
+	p, err := CompileBuiltins(parser.MustParse(src), builtins(1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	vals, err := p.Run()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if vals[3] != ast.FloatVal(6) || vals[4] != ast.CharVal('Q') || vals[5] != ast.BoolVal(true) {
+		t.Errorf("got %v", vals)
+	}
+	// the same seed draws the same numbers
+	q, _ := CompileBuiltins(parser.MustParse(src), builtins(1))
+	if again, _ := q.Run(); !reflect.DeepEqual(again, vals) {
+		t.Errorf("got %v, then %v", vals, again)
+	}
+
+	for _, test := range []struct {
+		src, err string
+	}{
+		{"int main() { float x; x = sqrt(0.0 - 1.0); }", "1:22: sqrt: negative argument"},
+		{"int main() { int x; x = 1; x = bad(); }", "1:27: bad returned 1.000000, not a value of type int"},
+	} {
+		p, err := CompileBuiltins(parser.MustParse(test.src), builtins(1))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := p.Run(); err == nil || err.Error() != test.err {
+			t.Errorf("got %v, want %s", err, test.err)
+		}
+	}
+
+	// builtins are only known to the programs compiled with them
+	if _, err := Compile(parser.MustParse("int main() { float x; x = sqrt(x); }")); err == nil {
+		t.Error("call of unknown function compiled")
+	}
+
+	// struct values are not passed to or returned by builtins
+	src = "int main() { struct P { int x; }; struct P p; int i; i = f(p); }"
+	prog := parser.MustParse(src)
+	pt := prog.DecPart[0].(*ast.StructDecl).T
+	for _, sig := range []types.Signature{
+		{Params: []ast.Type{pt}, Result: ast.INT_TYPE},
+		{Params: []ast.Type{ast.INT_TYPE}, Result: pt},
+	} {
+		b := Builtins{"f": {sig, func([]ast.Value) (ast.Value, error) { return ast.IntVal(0), nil }}}
+		_, err := CompileBuiltins(prog, b)
+		if want := "builtin f: struct parameters and results are not supported"; err == nil || err.Error() != want {
+			t.Errorf("%v: got %v, want %s", sig, err, want)
+		}
+	}
+}
+
+func TestTrace(t *testing.T) {
+	p := compile(t, `int main() {
+	int i;
+	float x;
+	char c;
+	bool b;
+	while (i < 2) {
+		i = i + 1;
+		;
+	}
+	if (i > 5) b = true; else {
+		c = 'z';
+		x = 0.0 / 0.0;
+		x = x;
+	}
+}`)
+	var buf bytes.Buffer
+	vals, err := p.RunContext(context.Background(), Options{Trace: &buf})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want, _ := p.Run(); fmt.Sprint(vals) != fmt.Sprint(want) {
+		t.Errorf("traced run left %v, want %v", vals, want)
+	}
+	want := `{"step":1,"kind":"loop","line":6,"column":1,"branch":true}
+{"step":2,"kind":"block","line":6,"column":15}
+{"step":3,"kind":"assignment","line":7,"column":2,"changes":[{"var":"i","type":"int","old":0,"new":1}]}
+{"step":4,"kind":"skip","line":8,"column":2}
+{"step":5,"kind":"loop","line":6,"column":1,"branch":true}
+{"step":6,"kind":"block","line":6,"column":15}
+{"step":7,"kind":"assignment","line":7,"column":2,"changes":[{"var":"i","type":"int","old":1,"new":2}]}
+{"step":8,"kind":"skip","line":8,"column":2}
+{"step":9,"kind":"loop","line":6,"column":1,"branch":false}
+{"step":10,"kind":"conditional","line":10,"column":1,"branch":false}
+{"step":11,"kind":"block","line":10,"column":27}
+{"step":12,"kind":"assignment","line":11,"column":2,"changes":[{"var":"c","type":"char","old":"\u0000","new":"z"}]}
+{"step":13,"kind":"assignment","line":12,"column":2,"changes":[{"var":"x","type":"float","old":0,"new":"NaN"}]}
+{"step":14,"kind":"assignment","line":13,"column":2}
+`
+	if buf.String() != want {
+		t.Errorf("got trace\n%s\nwant\n%s", buf.String(), want)
+	}
+
+	// a switch records the case it chooses, or -1
+	p = compile(t, `int main() {
+	int i;
+	switch (i) { case 1: ; default: i = 2; }
+	switch (i) { case 0, 1: ; }
+}`)
+	buf.Reset()
+	if _, err := p.RunContext(context.Background(), Options{Trace: &buf}); err != nil {
+		t.Fatal(err)
+	}
+	want = `{"step":1,"kind":"switch","line":3,"column":1,"case":1}
+{"step":2,"kind":"assignment","line":3,"column":33,"changes":[{"var":"i","type":"int","old":0,"new":2}]}
+{"step":3,"kind":"switch","line":4,"column":1,"case":-1}
+`
+	if buf.String() != want {
+		t.Errorf("got trace\n%s\nwant\n%s", buf.String(), want)
+	}
+
+	// a struct assignment records a change per field it changes
+	p = compile(t, `int main() {
+	struct P { int x; float f; };
+	struct P p, q;
+	q.f = 1.5;
+	p = q;
+	p.x += 1;
+}`)
+	buf.Reset()
+	if _, err := p.RunContext(context.Background(), Options{Trace: &buf}); err != nil {
+		t.Fatal(err)
+	}
+	want = `{"step":1,"kind":"assignment","line":4,"column":1,"changes":[{"var":"q.f","type":"float","old":0,"new":1.5}]}
+{"step":2,"kind":"assignment","line":5,"column":1,"changes":[{"var":"p.f","type":"float","old":0,"new":1.5}]}
+{"step":3,"kind":"assignment","line":6,"column":1,"changes":[{"var":"p.x","type":"int","old":0,"new":1}]}
+`
+	if buf.String() != want {
+		t.Errorf("got trace\n%s\nwant\n%s", buf.String(), want)
+	}
+
+	// the run stops when the trace cannot be written
+	hang := compile(t, "int main() { while (true) ; }")
+	_, err = hang.RunContext(context.Background(), Options{Trace: failWriter{}})
+	if err == nil || !strings.Contains(err.Error(), "writing trace: full") {
+		t.Errorf("got %v, want a write error", err)
+	}
+}
+
+type failWriter struct{}
+
+func (failWriter) Write([]byte) (int, error) { return 0, errors.New("full") }
+
+// Loop heavy programs for the benchmarks.
+var benchmarks = []struct {
+	name, src string
+}{
+	{"count", `int main() {
+	int i, n;
+	n = 1000000;
+	while (i < n) i = i + 1;
+}`},
+	{"primes", `int main() {
+	int n, d, count;
+	bool prime;
+	n = 2;
+	while (n < 5000) {
+		prime = true;
+		d = 2;
+		while (d * d <= n && prime) {
+			if (n - n / d * d == 0) prime = false;
+			d = d + 1;
+		}
+		if (prime) count = count + 1;
+		n = n + 1;
+	}
+}`},
+	{"float", `int main() {
+	int i;
+	float x, s;
+	while (i < 200000) {
+		x = float(i) / 1000.0;
+		s = s + x * x - x / 2.0;
+		i = i + 1;
+	}
+}`},
+}
+
+func BenchmarkRun(b *testing.B) {
+	for _, bm := range benchmarks {
+		p := compile(b, bm.src)
+		b.Run(bm.name, func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				if _, err := p.Run(); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+// BenchmarkIR runs the benchmarks with the three address code
+// evaluator, for comparison.
+func BenchmarkIR(b *testing.B) {
+	for _, bm := range benchmarks {
+		p, err := ir.Lower(parser.MustParse(bm.src))
+		if err != nil {
+			b.Fatal(err)
+		}
+		b.Run(bm.name, func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				if _, err := ir.Eval(p); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+func BenchmarkCompile(b *testing.B) {
+	prog := parser.MustParse(benchmarks[1].src)
+	for i := 0; i < b.N; i++ {
+		if _, err := Compile(prog); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
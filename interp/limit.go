@@ -0,0 +1,103 @@
+package interp
+
+import (
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/mentalpumkins/clite-go/ast"
+)
+
+// Options control a run of a program. The zero Options set no limits.
+type Options struct {
+	// Inputs gives initial values to variables of the program,
+	// which must have the types of the variables. The others are
+	// initially zero.
+	Inputs map[ast.Variable]ast.Value
+	// MaxSteps limits the number of steps a run may take: each
+	// assignment, conditional, switch and evaluation of the test of
+	// a loop is a step.
+	MaxSteps int64
+	// MaxMemory limits the bytes of storage held by the variables
+	// of a program, strings taking a byte per char.
+	MaxMemory int64
+	// Trace, when not nil, receives the trace of the run: an Event
+	// for each statement executed, as it executes.
+	Trace io.Writer
+}
+
+// Errors reported by a LimitError.
+var (
+	ErrStepLimit   = errors.New("step limit exceeded")
+	ErrMemoryLimit = errors.New("memory limit exceeded")
+)
+
+// A LimitError is returned when a run is stopped before it completes,
+// either because it hit one of the limits set by its Options, in which
+// case Err is ErrStepLimit or ErrMemoryLimit, or because its context
+// was done, in which case Err is the error of the context.
+type LimitError struct {
+	Err  error
+	Loop *ast.Loop // the innermost loop running at the time, or nil
+}
+
+func (e *LimitError) Error() string {
+	if e.Loop == nil {
+		return e.Err.Error()
+	}
+	return fmt.Sprintf("%v in loop at %s", e.Err, e.Loop.Pos)
+}
+
+// Unwrap returns e.Err.
+func (e *LimitError) Unwrap() error { return e.Err }
+
+// poll is the number of steps between checks of whether the context
+// of a run is done.
+const poll = 1 << 10
+
+// tick accounts for one step of m.
+func (m *machine) tick() {
+	if m.budget--; m.budget < 0 {
+		m.refill()
+	}
+}
+
+// refill is called when m has run out of the steps granted to it. It
+// stops the run when it is over, and otherwise grants some more.
+func (m *machine) refill() {
+	if m.done != nil {
+		select {
+		case <-m.done:
+			panic(&LimitError{m.ctx.Err(), m.loop})
+		default:
+		}
+	}
+	n := int64(poll)
+	if m.left >= 0 {
+		if m.left == 0 {
+			panic(&LimitError{ErrStepLimit, m.loop})
+		}
+		if m.left < n {
+			n = m.left
+		}
+		m.left -= n
+	}
+	// one step is taken now
+	m.budget = n - 1
+}
+
+// memory returns the bytes of storage held by the variables of p, not
+// counting the chars of strings.
+func (p *Program) memory() int64 {
+	return int64(4*p.nints + 8*p.nfloats + p.nbools)
+}
+
+// alloc accounts for n more bytes held by the strings of m, which
+// may be negative.
+func (m *machine) alloc(n int) {
+	if m.memLeft >= 0 {
+		if m.memLeft -= int64(n); m.memLeft < 0 {
+			panic(&LimitError{ErrMemoryLimit, m.loop})
+		}
+	}
+}
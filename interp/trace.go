@@ -0,0 +1,200 @@
+package interp
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+
+	"github.com/mentalpumkins/clite-go/ast"
+	"github.com/mentalpumkins/clite-go/print"
+	"github.com/mentalpumkins/clite-go/token"
+)
+
+// An Event records the execution of a statement in the trace of a run.
+// The trace holds one event per line, encoded as JSON:
+//
+//	{"step":3,"kind":"assignment","line":4,"column":1,"changes":[{"var":"x","type":"int","old":0,"new":1}]}
+//	{"step":4,"kind":"loop","line":5,"column":1,"branch":true}
+//
+// Values are encoded as JSON numbers for ints and floats, except for
+// the floats NaN, +Inf and -Inf which are encoded as strings, as one
+// character strings for chars, as strings for strings and as booleans
+// for bools.
+type Event struct {
+	Step int64  `json:"step"` // counting from 1
+	Kind string `json:"kind"` // assignment, conditional, loop, switch, block or skip
+	Line int    `json:"line"`
+	// Column counts from 0, like the columns of token.Position.
+	Column int `json:"column"`
+	// Changes lists the variables whose value the statement changed.
+	// The fields of struct variables are listed like variables, by
+	// their selector, as in p.x, each field of a nested struct on its
+	// own.
+	Changes []Change `json:"changes,omitempty"`
+	// Branch is the value of the test of a conditional, or of a
+	// loop, for which an event is recorded each time the test is
+	// evaluated.
+	Branch *bool `json:"branch,omitempty"`
+	// Case is the index of the case of a switch whose body runs
+	// first, counting from 0, or -1 when none does.
+	Case *int `json:"case,omitempty"`
+}
+
+// A Change is the change of value of a variable.
+type Change struct {
+	Var  string `json:"var"`
+	Type string `json:"type"`
+	Old  Value  `json:"old"`
+	New  Value  `json:"new"`
+}
+
+// A Value is an ast.Value encoded as JSON as described by Event.
+type Value struct {
+	ast.Value
+}
+
+func (v Value) MarshalJSON() ([]byte, error) {
+	switch x := v.Value.(type) {
+	case ast.IntVal:
+		return json.Marshal(int32(x))
+	case ast.FloatVal:
+		f := float64(x)
+		switch {
+		case math.IsNaN(f):
+			return []byte(`"NaN"`), nil
+		case math.IsInf(f, 1):
+			return []byte(`"+Inf"`), nil
+		case math.IsInf(f, -1):
+			return []byte(`"-Inf"`), nil
+		}
+		return json.Marshal(f)
+	case ast.CharVal:
+		return json.Marshal(string(rune(x)))
+	case ast.BoolVal:
+		return json.Marshal(bool(x))
+	case ast.StringVal:
+		return json.Marshal(x.String())
+	}
+	return nil, fmt.Errorf("interp: cannot encode %v", v.Value)
+}
+
+// A tracer writes the trace of a run.
+type tracer struct {
+	enc  *json.Encoder
+	step int64
+}
+
+// A traceError is an error writing the trace, which stops the run.
+type traceError struct {
+	err error
+}
+
+// emit writes the event for the statement of kind at pos.
+func (m *machine) emit(kind string, pos token.Position, changes []Change, branch *bool) {
+	m.record(Event{Kind: kind, Line: pos.Line, Column: pos.Column, Changes: changes, Branch: branch})
+}
+
+// record writes e, numbering it.
+func (m *machine) record(e Event) {
+	t := m.trace
+	t.step++
+	e.Step = t.step
+	if err := t.enc.Encode(&e); err != nil {
+		panic(traceError{err})
+	}
+}
+
+// traced returns the body of p compiled to record its trace, compiling
+// it the first time.
+func (p *Program) traced() stmt {
+	p.once.Do(func() {
+		c := *p.c
+		c.trace = true
+		p.tracedBody = c.block(p.prog.Body)
+	})
+	return p.tracedBody
+}
+
+// A watch is a variable, or a field of a struct variable, of a basic
+// type, whose changes are traced.
+type watch struct {
+	name string
+	t    ast.Type
+	slot []int
+}
+
+// watches returns the watches of the value named name, of type t,
+// held in slots: the value itself or, for a struct, its fields.
+func watches(name string, t ast.Type, slots []int) []watch {
+	st, ok := t.(*ast.Struct)
+	if !ok {
+		return []watch{{name, t, slots}}
+	}
+	var w []watch
+	for _, f := range st.Fields {
+		n := len(leaves(f.T))
+		w = append(w, watches(name+"."+f.Name, f.T, slots[:n])...)
+		slots = slots[n:]
+	}
+	return w
+}
+
+// traceStmt wraps the compiled statement s so that it records its
+// execution.
+func (c *compiler) traceStmt(s ast.Stmt, run stmt) stmt {
+	switch s := s.(type) {
+	case *ast.Assignment:
+		pos, lv := s.Pos, s.Lvalue()
+		w := watches(print.String(lv), c.tm.TypeOf(lv), c.slots(lv))
+		return func(m *machine) {
+			old := make([]ast.Value, len(w))
+			for i, x := range w {
+				old[i] = m.value(x.t, x.slot)
+			}
+			run(m)
+			var changes []Change
+			for i, x := range w {
+				// NaN never equals itself but is no change
+				if v := m.value(x.t, x.slot); v != old[i] && !(isNaN(v) && isNaN(old[i])) {
+					changes = append(changes, Change{x.name, x.t.String(), Value{old[i]}, Value{v}})
+				}
+			}
+			m.emit("assignment", pos, changes, nil)
+		}
+	case *ast.Block:
+		pos := s.Pos
+		return func(m *machine) {
+			m.emit("block", pos, nil, nil)
+			run(m)
+		}
+	case *ast.Skip:
+		pos := s.Pos
+		return func(m *machine) { m.emit("skip", pos, nil, nil) }
+	}
+	return run
+}
+
+// traceTest wraps the compiled test of the conditional or loop at pos
+// so that it records the branch taken.
+func (c *compiler) traceTest(kind string, pos token.Position, test boolExpr) boolExpr {
+	return func(m *machine) bool {
+		b := test(m)
+		m.emit(kind, pos, nil, &b)
+		return b
+	}
+}
+
+// traceCase wraps the compiled choice of a case of the switch at pos
+// so that it records the case chosen.
+func (c *compiler) traceCase(pos token.Position, choose func(*machine) int) func(*machine) int {
+	return func(m *machine) int {
+		i := choose(m)
+		m.record(Event{Kind: "switch", Line: pos.Line, Column: pos.Column, Case: &i})
+		return i
+	}
+}
+
+func isNaN(v ast.Value) bool {
+	f, ok := v.(ast.FloatVal)
+	return ok && f != f
+}
@@ -0,0 +1,87 @@
+package ir
+
+import (
+	"fmt"
+
+	"github.com/mentalpumkins/clite-go/ast"
+	"github.com/mentalpumkins/clite-go/eval"
+)
+
+// Eval runs p, with all variables initially zero, and returns the final
+// values of p.Vars. Operators have the meaning given to them by package
+// eval.
+func Eval(p *Program) ([]ast.Value, error) {
+	labels := make(map[string]int)
+	for i, in := range p.Code {
+		if in.Op == Label {
+			labels[in.Target] = i
+		}
+	}
+	env := make(map[*Var]ast.Value)
+	value := func(x Value) ast.Value {
+		switch x := x.(type) {
+		case Const:
+			return x.Val
+		case *Var:
+			if v, ok := env[x]; ok {
+				return v
+			}
+			return eval.Zero(x.T)
+		}
+		return nil
+	}
+	jump := func(l string) (int, error) {
+		pc, ok := labels[l]
+		if !ok {
+			return 0, fmt.Errorf("ir: undefined label %s", l)
+		}
+		return pc, nil
+	}
+
+	for pc := 0; pc < len(p.Code); pc++ {
+		in := p.Code[pc]
+		var err error
+		switch in.Op {
+		case Label:
+		case Jump:
+			pc, err = jump(in.Target)
+		case If:
+			l := in.Else
+			if value(in.Args[0]) == ast.BoolVal(true) {
+				l = in.Target
+			}
+			pc, err = jump(l)
+		default:
+			args := make([]ast.Value, len(in.Args))
+			for i, x := range in.Args {
+				args[i] = value(x)
+			}
+			env[in.Dst], err = Apply(in.Op, in.Dst.T, args)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	vals := make([]ast.Value, len(p.Vars))
+	for i, x := range p.Vars {
+		vals[i] = value(x)
+	}
+	return vals, nil
+}
+
+// Apply returns the result of the operation op, with result type t, on
+// the operand values args.
+func Apply(op Op, t ast.Type, args []ast.Value) (ast.Value, error) {
+	switch {
+	case op == Copy:
+		return args[0], nil
+	case op == Conv:
+		return eval.Convert(args[0], t), nil
+	case op.IsBinary():
+		return eval.Binary(op.Operator(), args[0], args[1])
+	case op == Neg || op == Not || op == Compl:
+		return eval.Unary(op.Operator(), args[0])
+	}
+	return nil, fmt.Errorf("ir: cannot apply %s", op)
+}
@@ -0,0 +1,193 @@
+// Package ir defines a three-address code for clite programs.
+//
+// A Program is a list of instructions, each of which applies at most
+// one operator to at most two operands and stores the result in a
+// variable, or transfers control to a label. Operands are constants
+// and variables, which are either the variables of the clite program
+// or temporaries introduced by Lower. Every variable has a type, and
+// the implicit conversions of clite are explicit: the operands of an
+// operator always have the same type.
+//
+// The text form of a program, written by Fprint and read by Parse,
+// declares its variables and then lists its instructions:
+//
+//	var i int
+//	temp %1 bool
+//	L1:
+//		%1 = lt i, 10
+//		if %1 goto L2 else L3
+//	L2:
+//		i = add i, 1
+//		goto L1
+//	L3:
+package ir
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/mentalpumkins/clite-go/ast"
+	"github.com/mentalpumkins/clite-go/ast/operators"
+)
+
+// A Program is a lowered clite program.
+type Program struct {
+	Vars  []*Var // variables of the clite program, in declaration order
+	Temps []*Var // temporaries
+	Code  []*Instr
+}
+
+// A Value is an operand: a *Var or a Const.
+type Value interface {
+	Type() ast.Type
+	String() string
+}
+
+// A Var is a variable. The names of temporaries start with %, which
+// no clite identifier does.
+type Var struct {
+	Name string
+	T    ast.Type
+}
+
+func (v *Var) Type() ast.Type { return v.T }
+func (v *Var) String() string { return v.Name }
+
+// IsTemp reports whether v is a temporary.
+func (v *Var) IsTemp() bool { return strings.HasPrefix(v.Name, "%") }
+
+// A Const is a constant operand.
+type Const struct {
+	Val ast.Value
+}
+
+func (c Const) Type() ast.Type { return c.Val.GetType() }
+
+func (c Const) String() string {
+	switch v := c.Val.(type) {
+	case ast.IntVal:
+		return strconv.Itoa(int(v))
+	case ast.CharVal:
+		return strconv.QuoteRuneToASCII(rune(v))
+	case ast.BoolVal:
+		return strconv.FormatBool(bool(v))
+	case ast.FloatVal:
+		s := strconv.FormatFloat(float64(v), 'g', -1, 64)
+		if !strings.ContainsAny(s, ".eIN") {
+			s += ".0"
+		}
+		return s
+	}
+	return "?"
+}
+
+// An Op is the operation of an instruction.
+type Op int
+
+const (
+	Copy Op = iota // Dst = Args[0]
+
+	// Dst = Args[0] op Args[1]
+	Add
+	Sub
+	Mul
+	Div
+	Rem
+	And
+	Or
+	Xor
+	Shl
+	Shr
+	Lt
+	Le
+	Gt
+	Ge
+	Eq
+	Ne
+
+	// Dst = op Args[0]
+	Neg
+	Not
+	Compl
+	Conv // conversion to the type of Dst
+
+	Label // Target:
+	Jump  // goto Target
+	If    // if Args[0] goto Target else Else
+)
+
+var opNames = [...]string{
+	Copy:  "copy",
+	Add:   "add",
+	Sub:   "sub",
+	Mul:   "mul",
+	Div:   "div",
+	Rem:   "rem",
+	And:   "and",
+	Or:    "or",
+	Xor:   "xor",
+	Shl:   "shl",
+	Shr:   "shr",
+	Lt:    "lt",
+	Le:    "le",
+	Gt:    "gt",
+	Ge:    "ge",
+	Eq:    "eq",
+	Ne:    "ne",
+	Neg:   "neg",
+	Not:   "not",
+	Compl: "compl",
+	Conv:  "conv",
+	Label: "label",
+	Jump:  "goto",
+	If:    "if",
+}
+
+func (op Op) String() string {
+	if 0 <= op && op < Op(len(opNames)) {
+		return opNames[op]
+	}
+	return "op(" + strconv.Itoa(int(op)) + ")"
+}
+
+// IsBinary reports whether op has two operands.
+func (op Op) IsBinary() bool { return Add <= op && op <= Ne }
+
+// IsCompare reports whether op is a comparison, whose result is a
+// bool.
+func (op Op) IsCompare() bool { return Lt <= op && op <= Ne }
+
+// Operator returns the clite operator applied by op.
+func (op Op) Operator() operators.Operator {
+	return opOperators[op]
+}
+
+var opOperators = map[Op]operators.Operator{
+	Add: "+", Sub: "-", Mul: "*", Div: "/", Rem: "%",
+	And: "&", Or: "|", Xor: "^", Shl: "<<", Shr: ">>",
+	Lt: "<", Le: "<=", Gt: ">", Ge: ">=", Eq: "==", Ne: "!=",
+	Neg: operators.NEG, Not: operators.NOT, Compl: operators.COMPL,
+}
+
+// An Instr is an instruction.
+type Instr struct {
+	Op     Op
+	Dst    *Var
+	Args   []Value
+	Target string // label defined by a Label, or jumped to
+	Else   string // label jumped to by an If when its operand is false
+}
+
+// IsJump reports whether in transfers control elsewhere.
+func (in *Instr) IsJump() bool { return in.Op == Jump || in.Op == If }
+
+// Targets returns the labels in may jump to.
+func (in *Instr) Targets() []string {
+	switch in.Op {
+	case Jump:
+		return []string{in.Target}
+	case If:
+		return []string{in.Target, in.Else}
+	}
+	return nil
+}
@@ -0,0 +1,133 @@
+package ir
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/mentalpumkins/clite-go/ast"
+	"github.com/mentalpumkins/clite-go/codegen"
+	"github.com/mentalpumkins/clite-go/parser"
+)
+
+func TestLower(t *testing.T) {
+	p, err := Lower(parser.MustParse(`int main() {
+	int i;
+	float x;
+	bool b;
+	while (i < 10)
+		i = i + 1;
+	x = i * 0.5;
+	b = b || x > 2.0;
+}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `var i int
+var x float
+var b bool
+temp %1 bool
+temp %2 float
+temp %3 bool
+L1:
+	%1 = lt i, 10
+	if %1 goto L2 else L3
+L2:
+	i = add i, 1
+	goto L1
+L3:
+	%2 = float i
+	x = mul %2, 0.5
+	%3 = b
+	if %3 goto L5 else L4
+L4:
+	%3 = gt x, 2.0
+L5:
+	b = %3
+`
+	if got := p.String(); got != want {
+		t.Errorf("got\n%s\nwant\n%s", got, want)
+	}
+}
+
+// state returns the final state of p as printed by compiled programs.
+func state(p *Program, vals []ast.Value) string {
+	var buf bytes.Buffer
+	for i, v := range p.Vars {
+		fmt.Fprintf(&buf, "%s = "+codegen.Format(v.T)+"\n", v, vals[i])
+	}
+	return buf.String()
+}
+
+func TestEval(t *testing.T) {
+	files, _ := filepath.Glob("../codegen/testdata/*.cl")
+	if len(files) == 0 {
+		t.Fatal("no test programs")
+	}
+	for _, file := range files {
+		src, err := ioutil.ReadFile(file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		want, err := ioutil.ReadFile(strings.TrimSuffix(file, ".cl") + ".out")
+		if err != nil {
+			t.Fatal(err)
+		}
+		p, err := Lower(parser.MustParse(string(src)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		vals, err := Eval(p)
+		if err != nil {
+			t.Fatalf("%s: %v", file, err)
+		}
+		if got := state(p, vals); got != string(want) {
+			t.Errorf("%s left\n%s\nwant\n%s", file, got, want)
+		}
+
+		// the text form reads back as the same program
+		q, err := Parse([]byte(p.String()))
+		if err != nil {
+			t.Fatalf("%s: %v\n%s", file, err, p)
+		}
+		if q.String() != p.String() {
+			t.Errorf("%s: read back as\n%s\nwant\n%s", file, q, p)
+		}
+	}
+}
+
+func TestParse(t *testing.T) {
+	src := `var goto char
+var f float
+temp %1 int
+	goto = 'a'
+	%1 = int goto
+	%1 = div %1, 0
+	f = neg -0.0
+`
+	p, err := Parse([]byte(src))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.String() != src {
+		t.Errorf("got\n%s\nwant\n%s", p, src)
+	}
+	if _, err := Eval(p); err == nil {
+		t.Error("division by zero succeeded")
+	}
+
+	for _, test := range []struct{ src, err string }{
+		{"var x int\n\tx = add x, 1.5\n", "line 2: operand 1.5 has type float, want int"},
+		{"var x int\n\tx = y\n", "line 2: bad operand \"y\""},
+		{"var x bool\n\tif x goto L1 else L2\nL1:\n", "undefined label L2"},
+		{"temp x int\n", "line 1: bad temp name \"x\""},
+		{"var x int\n\tx = float x\n", "line 2: conversion to float assigned to int x"},
+	} {
+		if _, err := Parse([]byte(test.src)); err == nil || err.Error() != test.err {
+			t.Errorf("%q: got error %v, want %s", test.src, err, test.err)
+		}
+	}
+}
@@ -0,0 +1,264 @@
+package ir
+
+import (
+	"fmt"
+
+	"github.com/mentalpumkins/clite-go/ast"
+	"github.com/mentalpumkins/clite-go/codegen"
+	"github.com/mentalpumkins/clite-go/eval"
+	"github.com/mentalpumkins/clite-go/types"
+)
+
+// Lower type checks prog and translates it to three-address code.
+func Lower(prog *ast.Program) (*Program, error) {
+	tm, consts, vars, err := codegen.Check(prog)
+	if err != nil {
+		return nil, err
+	}
+	if codegen.UsesStrings(prog, vars) {
+		return nil, fmt.Errorf("ir: strings are not supported")
+	}
+	if codegen.UsesStructs(vars) {
+		return nil, fmt.Errorf("ir: structs are not supported")
+	}
+	l := &lowerer{p: new(Program), tm: tm, consts: consts, vars: make(map[ast.Variable]*Var)}
+	for _, v := range vars {
+		x := &Var{Name: string(v.Name), T: v.Type}
+		l.vars[v.Name] = x
+		l.p.Vars = append(l.p.Vars, x)
+	}
+	for _, s := range prog.Body {
+		l.stmt(s)
+	}
+	if l.err != nil {
+		return nil, l.err
+	}
+	return l.p, nil
+}
+
+type lowerer struct {
+	p      *Program
+	tm     *types.TypeMap
+	consts types.Consts
+	vars   map[ast.Variable]*Var
+	labels int
+	err    error
+}
+
+func (l *lowerer) errorf(format string, args ...interface{}) {
+	if l.err == nil {
+		l.err = fmt.Errorf(format, args...)
+	}
+}
+
+func (l *lowerer) emit(in *Instr) {
+	l.p.Code = append(l.p.Code, in)
+}
+
+func (l *lowerer) temp(t ast.Type) *Var {
+	v := &Var{Name: fmt.Sprintf("%%%d", len(l.p.Temps)+1), T: t}
+	l.p.Temps = append(l.p.Temps, v)
+	return v
+}
+
+func (l *lowerer) label() string {
+	l.labels++
+	return fmt.Sprintf("L%d", l.labels)
+}
+
+func (l *lowerer) stmt(s ast.Stmt) {
+	switch s := s.(type) {
+	case nil, *ast.Skip:
+	case *ast.Block:
+		for _, m := range s.Members {
+			l.stmt(m)
+		}
+	case *ast.Assignment:
+		l.exprTo(s.Source, l.vars[s.Target])
+	case *ast.Conditional:
+		then, els, done := l.label(), l.label(), l.label()
+		if s.Else == nil {
+			els = done
+		}
+		l.emit(&Instr{Op: If, Args: []Value{l.expr(s.Test)}, Target: then, Else: els})
+		l.emit(&Instr{Op: Label, Target: then})
+		l.stmt(s.Body)
+		if s.Else != nil {
+			l.emit(&Instr{Op: Jump, Target: done})
+			l.emit(&Instr{Op: Label, Target: els})
+			l.stmt(s.Else)
+		}
+		l.emit(&Instr{Op: Label, Target: done})
+	case *ast.Loop:
+		head, body, done := l.label(), l.label(), l.label()
+		l.emit(&Instr{Op: Label, Target: head})
+		l.emit(&Instr{Op: If, Args: []Value{l.expr(s.Test)}, Target: body, Else: done})
+		l.emit(&Instr{Op: Label, Target: body})
+		l.stmt(s.Body)
+		l.emit(&Instr{Op: Jump, Target: head})
+		l.emit(&Instr{Op: Label, Target: done})
+	case *ast.Switch:
+		l.switchStmt(s)
+	default:
+		l.errorf("ir: unsupported statement %T", s)
+	}
+}
+
+// switchStmt tests the tag of s against the labels of each case in
+// turn, jumping to the body of the first that matches, and lays out
+// the bodies in order so that a fallthrough needs no jump.
+func (l *lowerer) switchStmt(s *ast.Switch) {
+	t := l.tm.TypeOf(s.Tag)
+	tag := l.expr(s.Tag)
+	bodies := make([]string, len(s.Cases))
+	done := l.label()
+	def := done
+	for i, c := range s.Cases {
+		bodies[i] = l.label()
+		if len(c.Labels) == 0 {
+			def = bodies[i]
+		}
+		for _, lab := range c.Labels {
+			lo, hi, _ := eval.Label(lab, l.consts.Lookup)
+			next := l.label()
+			if lo == hi {
+				l.branch(Eq, tag, lo, t, bodies[i], next)
+			} else {
+				in := l.label()
+				l.branch(Ge, tag, lo, t, in, next)
+				l.emit(&Instr{Op: Label, Target: in})
+				l.branch(Le, tag, hi, t, bodies[i], next)
+			}
+			l.emit(&Instr{Op: Label, Target: next})
+		}
+	}
+	l.emit(&Instr{Op: Jump, Target: def})
+	for i, c := range s.Cases {
+		l.emit(&Instr{Op: Label, Target: bodies[i]})
+		for _, m := range c.Body {
+			l.stmt(m)
+		}
+		if !c.Fallthrough {
+			l.emit(&Instr{Op: Jump, Target: done})
+		}
+	}
+	l.emit(&Instr{Op: Label, Target: done})
+}
+
+// branch jumps to then when the comparison op of x with the constant
+// k of type t holds, and to els otherwise.
+func (l *lowerer) branch(op Op, x Value, k int64, t ast.Type, then, els string) {
+	r := l.temp(ast.BOOL_TYPE)
+	l.emit(&Instr{Op: op, Dst: r, Args: []Value{x, Const{eval.Convert(ast.IntVal(k), t)}}})
+	l.emit(&Instr{Op: If, Args: []Value{r}, Target: then, Else: els})
+}
+
+// expr returns an operand holding the value of e.
+func (l *lowerer) expr(e ast.Expr) Value {
+	switch e := e.(type) {
+	case ast.Variable:
+		if k, ok := l.consts[e]; ok {
+			return Const{k}
+		}
+		return l.vars[e]
+	case ast.Value:
+		return Const{e}
+	}
+	t := l.temp(l.tm.TypeOf(e))
+	l.exprTo(e, t)
+	return t
+}
+
+// exprAs returns an operand holding the value of e converted to type t.
+func (l *lowerer) exprAs(e ast.Expr, t ast.Type) Value {
+	v := l.expr(e)
+	if v.Type() == t {
+		return v
+	}
+	r := l.temp(t)
+	l.emit(&Instr{Op: Conv, Dst: r, Args: []Value{v}})
+	return r
+}
+
+var binaryOps = map[string]Op{
+	"+": Add, "-": Sub, "*": Mul, "/": Div, "%": Rem,
+	"&": And, "|": Or, "^": Xor, "<<": Shl, ">>": Shr,
+	"<": Lt, "<=": Le, ">": Gt, ">=": Ge, "==": Eq, "!=": Ne,
+}
+
+// exprTo stores the value of e, converted to the type of dst, in dst.
+func (l *lowerer) exprTo(e ast.Expr, dst *Var) {
+	if l.tm.TypeOf(e) != dst.T {
+		l.emit(&Instr{Op: Conv, Dst: dst, Args: []Value{l.expr(e)}})
+		return
+	}
+	switch e := e.(type) {
+	case *ast.Binary:
+		op := string(e.Op)
+		if op == "&&" || op == "||" {
+			l.logical(e, dst)
+			return
+		}
+		code, ok := binaryOps[op]
+		if !ok {
+			l.errorf("ir: unsupported operator %s", op)
+			return
+		}
+		t := l.tm.TypeOf(e)
+		if code.IsCompare() {
+			// operands have the same type, or are promoted to float
+			t = l.tm.TypeOf(e.Term1)
+			if l.tm.TypeOf(e.Term2) == ast.FLOAT_TYPE {
+				t = ast.FLOAT_TYPE
+			}
+		}
+		x, y := l.exprAs(e.Term1, t), l.exprAs(e.Term2, t)
+		l.emit(&Instr{Op: code, Dst: dst, Args: []Value{x, y}})
+	case *ast.Unary:
+		switch e.Op {
+		case "!":
+			l.emit(&Instr{Op: Not, Dst: dst, Args: []Value{l.expr(e.Term)}})
+		case "-":
+			l.emit(&Instr{Op: Neg, Dst: dst, Args: []Value{l.expr(e.Term)}})
+		case "~":
+			l.emit(&Instr{Op: Compl, Dst: dst, Args: []Value{l.exprAs(e.Term, dst.T)}})
+		default:
+			l.exprTo(e.Term, dst)
+		}
+	case *ast.Ternary:
+		// each branch stores its own value, converted, in dst
+		then, els, done := l.label(), l.label(), l.label()
+		l.emit(&Instr{Op: If, Args: []Value{l.expr(e.Test)}, Target: then, Else: els})
+		l.emit(&Instr{Op: Label, Target: then})
+		l.exprTo(e.Then, dst)
+		l.emit(&Instr{Op: Jump, Target: done})
+		l.emit(&Instr{Op: Label, Target: els})
+		l.exprTo(e.Else, dst)
+		l.emit(&Instr{Op: Label, Target: done})
+	default:
+		l.emit(&Instr{Op: Copy, Dst: dst, Args: []Value{l.expr(e)}})
+	}
+}
+
+// logical stores the value of the && or || expression e in dst,
+// evaluating its second operand only when needed.
+func (l *lowerer) logical(e *ast.Binary, dst *Var) {
+	r := dst
+	if !dst.IsTemp() {
+		// dst may be an operand of e
+		r = l.temp(ast.BOOL_TYPE)
+	}
+	rhs, done := l.label(), l.label()
+	l.exprTo(e.Term1, r)
+	if e.Op == "&&" {
+		l.emit(&Instr{Op: If, Args: []Value{r}, Target: rhs, Else: done})
+	} else {
+		l.emit(&Instr{Op: If, Args: []Value{r}, Target: done, Else: rhs})
+	}
+	l.emit(&Instr{Op: Label, Target: rhs})
+	l.exprTo(e.Term2, r)
+	l.emit(&Instr{Op: Label, Target: done})
+	if r != dst {
+		l.emit(&Instr{Op: Copy, Dst: dst, Args: []Value{r}})
+	}
+}
@@ -0,0 +1,301 @@
+package ir
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/mentalpumkins/clite-go/ast"
+)
+
+// A SyntaxError reports a malformed line of the text form of a
+// program.
+type SyntaxError struct {
+	Line int
+	Msg  string
+}
+
+func (e *SyntaxError) Error() string {
+	return fmt.Sprintf("line %d: %s", e.Line, e.Msg)
+}
+
+var typeNames = map[string]ast.Type{
+	"int":   ast.INT_TYPE,
+	"float": ast.FLOAT_TYPE,
+	"char":  ast.CHAR_TYPE,
+	"bool":  ast.BOOL_TYPE,
+}
+
+var opsByName = make(map[string]Op)
+
+func init() {
+	for op := Add; op <= Compl; op++ {
+		opsByName[op.String()] = op
+	}
+}
+
+// Parse reads a program in the text form written by Fprint. Variables
+// must be declared before they are used, and every label jumped to
+// must be defined.
+func Parse(src []byte) (*Program, error) {
+	p := &textParser{prog: new(Program), vars: make(map[string]*Var), labels: make(map[string]bool)}
+	sc := bufio.NewScanner(bytes.NewReader(src))
+	for sc.Scan() {
+		p.line++
+		p.toks = tokens(sc.Text())
+		p.pos = 0
+		if len(p.toks) == 0 {
+			continue
+		}
+		if err := p.parseLine(); err != nil {
+			return nil, err
+		}
+	}
+	for _, in := range p.prog.Code {
+		for _, l := range in.Targets() {
+			if !p.labels[l] {
+				return nil, fmt.Errorf("undefined label %s", l)
+			}
+		}
+	}
+	return p.prog, nil
+}
+
+type textParser struct {
+	prog   *Program
+	vars   map[string]*Var
+	labels map[string]bool
+	line   int
+	toks   []string
+	pos    int
+}
+
+// tokens splits a line into words, quoted chars and the punctuation
+// marks = , and :.
+func tokens(s string) []string {
+	var toks []string
+	for i := 0; i < len(s); {
+		c := s[i]
+		switch {
+		case c == ' ' || c == '\t':
+			i++
+		case c == '=' || c == ',' || c == ':':
+			toks = append(toks, s[i:i+1])
+			i++
+		case c == '\'':
+			j := i + 1
+			for j < len(s) && s[j] != '\'' {
+				if s[j] == '\\' {
+					j++
+				}
+				j++
+			}
+			if j < len(s) {
+				j++
+			}
+			toks = append(toks, s[i:j])
+			i = j
+		default:
+			j := i
+			for j < len(s) && !strings.ContainsRune(" \t=,:'", rune(s[j])) {
+				j++
+			}
+			toks = append(toks, s[i:j])
+			i = j
+		}
+	}
+	return toks
+}
+
+func (p *textParser) errorf(format string, args ...interface{}) error {
+	return &SyntaxError{p.line, fmt.Sprintf(format, args...)}
+}
+
+func (p *textParser) next() string {
+	if p.pos == len(p.toks) {
+		return ""
+	}
+	p.pos++
+	return p.toks[p.pos-1]
+}
+
+func (p *textParser) expect(tok string) error {
+	if got := p.next(); got != tok {
+		return p.errorf("found %q, expected %q", got, tok)
+	}
+	return nil
+}
+
+func (p *textParser) parseLine() error {
+	first := p.next()
+	var in *Instr
+	if p.pos < len(p.toks) && p.toks[p.pos] == "=" {
+		// variables may be named like keywords
+		var err error
+		if in, err = p.assignment(first); err != nil {
+			return err
+		}
+		first = ""
+	}
+	switch first {
+	case "":
+	case "var", "temp":
+		name, tname := p.next(), p.next()
+		t, ok := typeNames[tname]
+		if !ok {
+			return p.errorf("unknown type %q", tname)
+		}
+		if (first == "temp") != strings.HasPrefix(name, "%") || name == "" || name == "%" {
+			return p.errorf("bad %s name %q", first, name)
+		}
+		if p.vars[name] != nil {
+			return p.errorf("%s redeclared", name)
+		}
+		v := &Var{Name: name, T: t}
+		p.vars[name] = v
+		if first == "var" {
+			p.prog.Vars = append(p.prog.Vars, v)
+		} else {
+			p.prog.Temps = append(p.prog.Temps, v)
+		}
+	case "goto":
+		in = &Instr{Op: Jump, Target: p.next()}
+	case "if":
+		x, err := p.operand()
+		if err != nil {
+			return err
+		}
+		if x.Type() != ast.BOOL_TYPE {
+			return p.errorf("non-bool condition %s", x)
+		}
+		in = &Instr{Op: If, Args: []Value{x}}
+		if err := p.expect("goto"); err != nil {
+			return err
+		}
+		in.Target = p.next()
+		if err := p.expect("else"); err != nil {
+			return err
+		}
+		in.Else = p.next()
+	default:
+		if p.pos < len(p.toks) && p.toks[p.pos] == ":" {
+			p.next()
+			if p.labels[first] {
+				return p.errorf("label %s redefined", first)
+			}
+			p.labels[first] = true
+			in = &Instr{Op: Label, Target: first}
+			break
+		}
+		var err error
+		if in, err = p.assignment(first); err != nil {
+			return err
+		}
+	}
+	if in != nil {
+		if in.IsJump() && (in.Target == "" || in.Op == If && in.Else == "") {
+			return p.errorf("missing label")
+		}
+		p.prog.Code = append(p.prog.Code, in)
+	}
+	if p.pos != len(p.toks) {
+		return p.errorf("unexpected %q", p.toks[p.pos])
+	}
+	return nil
+}
+
+func (p *textParser) assignment(dst string) (*Instr, error) {
+	in := &Instr{Dst: p.vars[dst]}
+	if in.Dst == nil {
+		return nil, p.errorf("undeclared variable %s", dst)
+	}
+	if err := p.expect("="); err != nil {
+		return nil, err
+	}
+	if p.pos+1 == len(p.toks) {
+		x, err := p.operand()
+		if err != nil {
+			return nil, err
+		}
+		in.Op, in.Args = Copy, []Value{x}
+		return in, p.check(in)
+	}
+	name := p.next()
+	if _, ok := typeNames[name]; ok {
+		in.Op = Conv
+		if name != in.Dst.T.String() {
+			return nil, p.errorf("conversion to %s assigned to %s %s", name, in.Dst.T, in.Dst)
+		}
+	} else if op, ok := opsByName[name]; ok {
+		in.Op = op
+	} else {
+		return nil, p.errorf("unknown operation %q", name)
+	}
+	x, err := p.operand()
+	if err != nil {
+		return nil, err
+	}
+	in.Args = []Value{x}
+	if in.Op.IsBinary() {
+		if err := p.expect(","); err != nil {
+			return nil, err
+		}
+		y, err := p.operand()
+		if err != nil {
+			return nil, err
+		}
+		in.Args = append(in.Args, y)
+	}
+	return in, p.check(in)
+}
+
+// check checks the types of the operands of in.
+func (p *textParser) check(in *Instr) error {
+	want := in.Dst.T
+	switch {
+	case in.Op == Conv:
+		return nil
+	case in.Op.IsCompare():
+		if in.Dst.T != ast.BOOL_TYPE {
+			return p.errorf("comparison assigned to %s %s", in.Dst.T, in.Dst)
+		}
+		want = in.Args[0].Type()
+	case in.Op == Not:
+		want = ast.BOOL_TYPE
+	}
+	for _, x := range in.Args {
+		if x.Type() != want {
+			return p.errorf("operand %s has type %s, want %s", x, x.Type(), want)
+		}
+	}
+	return nil
+}
+
+func (p *textParser) operand() (Value, error) {
+	tok := p.next()
+	switch {
+	case tok == "":
+		return nil, p.errorf("missing operand")
+	case tok == "true" || tok == "false":
+		return Const{ast.BoolVal(tok == "true")}, nil
+	case tok[0] == '\'':
+		s, err := strconv.Unquote(tok)
+		r := []rune(s)
+		if err != nil || len(r) != 1 || r[0] > 0xff {
+			return nil, p.errorf("bad char %s", tok)
+		}
+		return Const{ast.CharVal(r[0])}, nil
+	}
+	if v := p.vars[tok]; v != nil {
+		return v, nil
+	}
+	if i, err := strconv.ParseInt(tok, 10, 32); err == nil {
+		return Const{ast.IntVal(i)}, nil
+	}
+	if f, err := strconv.ParseFloat(tok, 64); err == nil && strings.ContainsAny(tok, ".eIN") {
+		return Const{ast.FloatVal(f)}, nil
+	}
+	return nil, p.errorf("bad operand %q", tok)
+}
@@ -0,0 +1,53 @@
+package ir
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+)
+
+// Fprint writes the text form of p to w.
+func Fprint(w io.Writer, p *Program) error {
+	bw := bufio.NewWriter(w)
+	for _, v := range p.Vars {
+		fmt.Fprintf(bw, "var %s %s\n", v, v.T)
+	}
+	for _, v := range p.Temps {
+		fmt.Fprintf(bw, "temp %s %s\n", v, v.T)
+	}
+	for _, in := range p.Code {
+		if in.Op == Label {
+			fmt.Fprintf(bw, "%s:\n", in.Target)
+		} else {
+			fmt.Fprintf(bw, "\t%s\n", in)
+		}
+	}
+	return bw.Flush()
+}
+
+// String returns the text form of p.
+func (p *Program) String() string {
+	var buf bytes.Buffer
+	Fprint(&buf, p)
+	return buf.String()
+}
+
+func (in *Instr) String() string {
+	switch {
+	case in.Op == Label:
+		return in.Target + ":"
+	case in.Op == Jump:
+		return "goto " + in.Target
+	case in.Op == If:
+		return fmt.Sprintf("if %s goto %s else %s", in.Args[0], in.Target, in.Else)
+	case in.Op == Copy:
+		return fmt.Sprintf("%s = %s", in.Dst, in.Args[0])
+	case in.Op == Conv:
+		// named after the type converted to
+		return fmt.Sprintf("%s = %s %s", in.Dst, in.Dst.T, in.Args[0])
+	case in.Op.IsBinary():
+		return fmt.Sprintf("%s = %s %s, %s", in.Dst, in.Op, in.Args[0], in.Args[1])
+	}
+	return fmt.Sprintf("%s = %s %s", in.Dst, in.Op, in.Args[0])
+}
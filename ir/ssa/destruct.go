@@ -0,0 +1,124 @@
+package ssa
+
+import (
+	"github.com/mentalpumkins/clite-go/ir"
+)
+
+// Program translates f back to three-address code. Each phi becomes
+// a temporary assigned at the end of the predecessors of its block
+// and copied to the phi's variable at the start of the block, which
+// keeps phis reading one another's variables correct. Edges from a
+// block with two successors to a block with phis are split to hold
+// these copies. The program ends by assigning the variables of the
+// program their final values.
+func (f *Func) Program() *ir.Program {
+	p := &ir.Program{Vars: f.Vars}
+	seen := make(map[*ir.Var]bool)
+	for _, v := range f.Vars {
+		seen[v] = true
+	}
+	emit := func(in *ir.Instr) {
+		if in.Dst != nil && !seen[in.Dst] {
+			seen[in.Dst] = true
+			p.Temps = append(p.Temps, in.Dst)
+		}
+		p.Code = append(p.Code, in)
+	}
+	copyTo := func(dst *ir.Var, x ir.Value) {
+		emit(&ir.Instr{Op: ir.Copy, Dst: dst, Args: []ir.Value{x}})
+	}
+
+	phiIn := make(map[*Phi]*ir.Var)
+	for _, b := range f.Blocks {
+		for _, phi := range b.Phis {
+			phiIn[phi] = &ir.Var{Name: phi.Dst.Name + ".in", T: phi.Dst.T}
+		}
+	}
+	// edgeCopies emits the copies for the edge from b to its i-th
+	// successor.
+	edgeCopies := func(b *Block, i int) {
+		s := b.Succs[i]
+		j := 0
+		for n := 0; n <= i; n++ {
+			if b.Succs[n] == s {
+				j++ // the j-th edge from b to s
+			}
+		}
+		for k, pred := range s.Preds {
+			if pred == b {
+				if j--; j == 0 {
+					for _, phi := range s.Phis {
+						copyTo(phiIn[phi], phi.Args[k])
+					}
+					return
+				}
+			}
+		}
+	}
+
+	type split struct {
+		label string
+		b     *Block
+		i     int
+	}
+	var splits []split
+	order := make([]*Block, 0, len(f.Blocks))
+	for _, b := range f.Blocks {
+		if b != f.Exit {
+			order = append(order, b)
+		}
+	}
+	order = append(order, f.Exit)
+	for n, b := range order {
+		if n > 0 {
+			emit(&ir.Instr{Op: ir.Label, Target: b.Label})
+		}
+		for _, phi := range b.Phis {
+			copyTo(phi.Dst, phiIn[phi])
+		}
+		for _, in := range b.Instrs {
+			c := *in
+			c.Args = append([]ir.Value(nil), in.Args...)
+			emit(&c)
+		}
+		switch {
+		case b == f.Exit:
+			for i, v := range f.Vars {
+				copyTo(v, f.Results[i])
+			}
+		case b.Cond != nil:
+			targets := make([]string, 2)
+			for i, s := range b.Succs {
+				targets[i] = s.Label
+				if len(s.Phis) > 0 {
+					l := f.newLabel()
+					splits = append(splits, split{l, b, i})
+					targets[i] = l
+				}
+			}
+			emit(&ir.Instr{Op: ir.If, Args: []ir.Value{b.Cond}, Target: targets[0], Else: targets[1]})
+		default:
+			edgeCopies(b, 0)
+			if s := b.Succs[0]; n+1 == len(order) || order[n+1] != s || len(splits) > 0 && n+2 == len(order) {
+				emit(&ir.Instr{Op: ir.Jump, Target: s.Label})
+			}
+		}
+		if n+2 == len(order) {
+			// split edges go before the exit block
+			for _, sp := range splits {
+				emit(&ir.Instr{Op: ir.Label, Target: sp.label})
+				edgeCopies(sp.b, sp.i)
+				emit(&ir.Instr{Op: ir.Jump, Target: sp.b.Succs[sp.i].Label})
+			}
+			splits = nil
+		}
+	}
+	return p
+}
+
+// newLabel returns a label not yet used in f.
+func (f *Func) newLabel() string {
+	b := f.newBlock("")
+	f.Blocks = f.Blocks[:len(f.Blocks)-1]
+	return b.Label
+}
@@ -0,0 +1,505 @@
+package ssa
+
+import (
+	"math"
+
+	"github.com/mentalpumkins/clite-go/ast"
+	"github.com/mentalpumkins/clite-go/ir"
+)
+
+// A Pass transforms a Func, reporting whether it changed anything.
+type Pass func(f *Func) bool
+
+// Passes lists the optimization passes in the order Optimize runs
+// them.
+var Passes = []Pass{ConstProp, CopyProp, CSE, LICM, DCE}
+
+// Optimize runs the optimization passes over f until none of them
+// changes it any more.
+func Optimize(f *Func) {
+	for changed := true; changed; {
+		changed = false
+		for _, pass := range Passes {
+			if pass(f) {
+				changed = true
+			}
+		}
+	}
+}
+
+// key returns a comparable key identifying the operand x. Unlike x
+// itself, keys tell 0.0 from -0.0 and equal each other for NaN.
+func key(x ir.Value) interface{} {
+	if c, ok := x.(ir.Const); ok {
+		if f, ok := c.Val.(ast.FloatVal); ok {
+			return math.Float64bits(float64(f))
+		}
+		return c.Val
+	}
+	return x
+}
+
+// unique returns the single value of the arguments of phi, ignoring
+// phi's own variable, if there is one.
+func (phi *Phi) unique() (ir.Value, bool) {
+	var v ir.Value
+	for _, x := range phi.Args {
+		if x == ir.Value(phi.Dst) {
+			continue
+		}
+		if v != nil && key(x) != key(v) {
+			return nil, false
+		}
+		v = x
+	}
+	return v, v != nil
+}
+
+// mayTrap reports whether in may fail at run time: it is an int
+// division or remainder whose divisor is not a nonzero constant.
+func mayTrap(in *ir.Instr) bool {
+	if in.Op != ir.Div && in.Op != ir.Rem || in.Dst.T == ast.FLOAT_TYPE {
+		return false
+	}
+	c, ok := in.Args[1].(ir.Const)
+	return !ok || c.Val == ast.IntVal(0)
+}
+
+// substitute replaces the uses of the variables in m with their
+// values.
+func (f *Func) substitute(m map[*ir.Var]ir.Value) {
+	if len(m) == 0 {
+		return
+	}
+	resolve := func(x ir.Value) ir.Value {
+		for {
+			v, ok := x.(*ir.Var)
+			if !ok || m[v] == nil {
+				return x
+			}
+			x = m[v]
+		}
+	}
+	for _, b := range f.Blocks {
+		for _, phi := range b.Phis {
+			for i, x := range phi.Args {
+				phi.Args[i] = resolve(x)
+			}
+		}
+		for _, in := range b.Instrs {
+			for i, x := range in.Args {
+				in.Args[i] = resolve(x)
+			}
+		}
+		if b.Cond != nil {
+			b.Cond = resolve(b.Cond)
+		}
+	}
+	for i, x := range f.Results {
+		f.Results[i] = resolve(x)
+	}
+}
+
+// ConstProp evaluates the instructions and phis whose operands are
+// constants, replacing their variables with the results, and turns
+// conditional jumps on constants into jumps, removing the blocks that
+// become unreachable and merging those left in a straight line.
+// Operations that fail, such as divisions by zero, are left to fail at
+// run time.
+func ConstProp(f *Func) bool {
+	changed := f.merge()
+	for {
+		m := make(map[*ir.Var]ir.Value)
+		pruned := false
+		for _, b := range f.Blocks {
+			phis := b.Phis[:0]
+			for _, phi := range b.Phis {
+				if v, ok := phi.unique(); ok {
+					if c, ok := v.(ir.Const); ok {
+						m[phi.Dst] = c
+						continue
+					}
+				}
+				phis = append(phis, phi)
+			}
+			b.Phis = phis
+
+			instrs := b.Instrs[:0]
+			for _, in := range b.Instrs {
+				if v, ok := fold(in); ok {
+					m[in.Dst] = ir.Const{Val: v}
+					continue
+				}
+				instrs = append(instrs, in)
+			}
+			b.Instrs = instrs
+
+			if c, ok := b.Cond.(ir.Const); ok {
+				taken, other := b.Succs[0], b.Succs[1]
+				if c.Val != ast.BoolVal(true) {
+					taken, other = other, taken
+				}
+				for i, p := range other.Preds {
+					if p == b {
+						other.removePredAt(i)
+						break
+					}
+				}
+				b.Succs = []*Block{taken}
+				b.Cond = nil
+				pruned = true
+			}
+		}
+		if len(m) == 0 && !pruned {
+			return changed
+		}
+		changed = true
+		f.substitute(m)
+		if pruned {
+			f.removeUnreachable()
+			f.merge()
+		}
+	}
+}
+
+// merge merges each block whose only predecessor has no other
+// successor into that predecessor, reporting whether there were any.
+func (f *Func) merge() bool {
+	merged := make(map[*Block]bool)
+	for _, b := range f.Blocks {
+		for !merged[b] && len(b.Succs) == 1 {
+			s := b.Succs[0]
+			if s == b || len(s.Preds) != 1 {
+				break
+			}
+			m := make(map[*ir.Var]ir.Value)
+			for _, phi := range s.Phis {
+				m[phi.Dst] = phi.Args[0]
+			}
+			b.Instrs = append(b.Instrs, s.Instrs...)
+			b.Cond, b.Succs = s.Cond, s.Succs
+			for _, t := range s.Succs {
+				for i, p := range t.Preds {
+					if p == s {
+						t.Preds[i] = b
+					}
+				}
+			}
+			if s == f.Exit {
+				f.Exit = b
+			}
+			merged[s] = true
+			s.Phis = nil
+			f.substitute(m)
+		}
+	}
+	if len(merged) == 0 {
+		return false
+	}
+	blocks := f.Blocks[:0]
+	for _, b := range f.Blocks {
+		if !merged[b] {
+			b.Index = len(blocks)
+			blocks = append(blocks, b)
+		}
+	}
+	f.Blocks = blocks
+	return true
+}
+
+// fold returns the value computed by in if its operands are constants.
+func fold(in *ir.Instr) (ast.Value, bool) {
+	args := make([]ast.Value, len(in.Args))
+	for i, x := range in.Args {
+		c, ok := x.(ir.Const)
+		if !ok {
+			return nil, false
+		}
+		args[i] = c.Val
+	}
+	v, err := ir.Apply(in.Op, in.Dst.T, args)
+	return v, err == nil
+}
+
+// CopyProp replaces the uses of variables assigned by copies, and of
+// the variables of phis whose arguments are all the same value, with
+// the values copied.
+func CopyProp(f *Func) bool {
+	changed := false
+	for {
+		m := make(map[*ir.Var]ir.Value)
+		for _, b := range f.Blocks {
+			phis := b.Phis[:0]
+			for _, phi := range b.Phis {
+				if v, ok := phi.unique(); ok {
+					m[phi.Dst] = v
+					continue
+				}
+				phis = append(phis, phi)
+			}
+			b.Phis = phis
+
+			instrs := b.Instrs[:0]
+			for _, in := range b.Instrs {
+				if in.Op == ir.Copy {
+					m[in.Dst] = in.Args[0]
+					continue
+				}
+				instrs = append(instrs, in)
+			}
+			b.Instrs = instrs
+		}
+		if len(m) == 0 {
+			return changed
+		}
+		changed = true
+		f.substitute(m)
+	}
+}
+
+// CSE removes the instructions that compute the same operation on the
+// same operands as an instruction dominating them, replacing the uses
+// of their variables with the variable of the dominating instruction.
+func CSE(f *Func) bool {
+	type expr struct {
+		op   ir.Op
+		t    ast.Type
+		x, y interface{}
+	}
+	t := f.dominators()
+	avail := make(map[expr]*ir.Var)
+	m := make(map[*ir.Var]ir.Value)
+	var walk func(b *Block)
+	walk = func(b *Block) {
+		var added []expr
+		instrs := b.Instrs[:0]
+		for _, in := range b.Instrs {
+			for i, x := range in.Args {
+				if v, ok := x.(*ir.Var); ok && m[v] != nil {
+					in.Args[i] = m[v]
+				}
+			}
+			e := expr{op: in.Op, t: in.Dst.T, x: key(in.Args[0])}
+			if len(in.Args) > 1 {
+				e.y = key(in.Args[1])
+			}
+			if v := avail[e]; v != nil {
+				m[in.Dst] = v
+				continue
+			}
+			avail[e] = in.Dst
+			added = append(added, e)
+			instrs = append(instrs, in)
+		}
+		b.Instrs = instrs
+		for _, c := range t.children[b.Index] {
+			walk(c)
+		}
+		for _, e := range added {
+			delete(avail, e)
+		}
+	}
+	walk(f.Blocks[0])
+	f.substitute(m)
+	return len(m) > 0
+}
+
+// DCE removes the instructions and phis whose variables are not used
+// to compute a condition or a final value, except for those that may
+// fail at run time.
+func DCE(f *Func) bool {
+	defs := make(map[*ir.Var][]ir.Value) // operands of the definition of each variable
+	live := make(map[*ir.Var]bool)
+	var work []*ir.Var
+	use := func(x ir.Value) {
+		if v, ok := x.(*ir.Var); ok && !live[v] {
+			live[v] = true
+			work = append(work, v)
+		}
+	}
+	for _, b := range f.Blocks {
+		for _, phi := range b.Phis {
+			defs[phi.Dst] = phi.Args
+		}
+		for _, in := range b.Instrs {
+			defs[in.Dst] = in.Args
+			if mayTrap(in) {
+				use(in.Dst)
+			}
+		}
+		if b.Cond != nil {
+			use(b.Cond)
+		}
+	}
+	for _, x := range f.Results {
+		use(x)
+	}
+	for len(work) > 0 {
+		v := work[len(work)-1]
+		work = work[:len(work)-1]
+		for _, x := range defs[v] {
+			use(x)
+		}
+	}
+
+	changed := false
+	for _, b := range f.Blocks {
+		phis := b.Phis[:0]
+		for _, phi := range b.Phis {
+			if live[phi.Dst] {
+				phis = append(phis, phi)
+			}
+		}
+		instrs := b.Instrs[:0]
+		for _, in := range b.Instrs {
+			if live[in.Dst] {
+				instrs = append(instrs, in)
+			}
+		}
+		if len(phis) < len(b.Phis) || len(instrs) < len(b.Instrs) {
+			changed = true
+		}
+		b.Phis, b.Instrs = phis, instrs
+	}
+	return changed
+}
+
+// LICM moves the instructions of a loop whose operands do not change
+// within the loop to a block executed just before entering it, which
+// is inserted when the loop has none. Only instructions that cannot
+// fail are moved, as they are then executed even if the loop body is
+// not.
+func LICM(f *Func) bool {
+	changed := false
+	for f.hoist() {
+		changed = true
+	}
+	return changed
+}
+
+// hoist moves the invariant instructions of one loop out of it,
+// reporting whether there were any.
+func (f *Func) hoist() bool {
+	t := f.dominators()
+	for _, h := range t.order {
+		// the natural loop of h: the blocks reaching a back edge to h
+		// without going through h
+		body := map[*Block]bool{h: true}
+		loop := false
+		for _, p := range h.Preds {
+			if !t.dominates(h, p) {
+				continue
+			}
+			loop = true
+			work := []*Block{p}
+			for len(work) > 0 {
+				b := work[len(work)-1]
+				work = work[:len(work)-1]
+				if !body[b] {
+					body[b] = true
+					work = append(work, b.Preds...)
+				}
+			}
+		}
+		if !loop {
+			continue
+		}
+
+		inLoop := make(map[*ir.Var]bool)
+		for b := range body {
+			for _, phi := range b.Phis {
+				inLoop[phi.Dst] = true
+			}
+			for _, in := range b.Instrs {
+				inLoop[in.Dst] = true
+			}
+		}
+		var hoisted []*ir.Instr
+		for _, b := range t.order {
+			if !body[b] {
+				continue
+			}
+			instrs := b.Instrs[:0]
+			for _, in := range b.Instrs {
+				invariant := !mayTrap(in)
+				for _, x := range in.Args {
+					if v, ok := x.(*ir.Var); ok && inLoop[v] {
+						invariant = false
+					}
+				}
+				if invariant {
+					hoisted = append(hoisted, in)
+					delete(inLoop, in.Dst)
+					continue
+				}
+				instrs = append(instrs, in)
+			}
+			b.Instrs = instrs
+		}
+		if len(hoisted) == 0 {
+			continue
+		}
+		pre := f.preheader(h, body)
+		pre.Instrs = append(pre.Instrs, hoisted...)
+		return true
+	}
+	return false
+}
+
+// preheader returns the block through which control enters the loop
+// with header h and blocks body, inserting one if needed.
+func (f *Func) preheader(h *Block, body map[*Block]bool) *Block {
+	var outside []int // indexes in h.Preds
+	for i, p := range h.Preds {
+		if !body[p] {
+			outside = append(outside, i)
+		}
+	}
+	if len(outside) == 1 {
+		if p := h.Preds[outside[0]]; len(p.Succs) == 1 {
+			return p
+		}
+	}
+
+	pre := f.newBlock("")
+	var preds []*Block
+	var args [][]ir.Value // arguments of the phis of h, by predecessor
+	for i, p := range h.Preds {
+		var a []ir.Value
+		for _, phi := range h.Phis {
+			a = append(a, phi.Args[i])
+		}
+		if body[p] {
+			preds = append(preds, p)
+			args = append(args, a)
+			continue
+		}
+		for j, s := range p.Succs {
+			if s == h {
+				p.Succs[j] = pre
+				break
+			}
+		}
+		pre.Preds = append(pre.Preds, p)
+		for k, phi := range h.Phis {
+			if len(pre.Phis) <= k {
+				pre.Phis = append(pre.Phis, &Phi{Dst: f.version(phi.Dst)})
+			}
+			pre.Phis[k].Args = append(pre.Phis[k].Args, a[k])
+		}
+	}
+	var a []ir.Value
+	for _, phi := range pre.Phis {
+		a = append(a, phi.Dst)
+	}
+	h.Preds = append(preds, pre)
+	args = append(args, a)
+	for k, phi := range h.Phis {
+		phi.Args = phi.Args[:0]
+		for _, a := range args {
+			phi.Args = append(phi.Args, a[k])
+		}
+	}
+	pre.Succs = []*Block{h}
+	return pre
+}
@@ -0,0 +1,472 @@
+// Package ssa converts three-address code to static single assignment
+// form, optimizes it and converts it back.
+//
+// Build splits an ir.Program into basic blocks and renames its
+// variables so that each is assigned exactly once, placing phi
+// functions at the dominance frontiers of the assignments. Variables
+// read before any assignment reaching them read their zero value,
+// which is then a constant. The passes ConstProp, CopyProp, CSE, DCE
+// and LICM transform a Func in place without changing the result of
+// the program, and Program translates it back to an ir.Program whose
+// evaluation yields the same final state as the original.
+package ssa
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/mentalpumkins/clite-go/eval"
+	"github.com/mentalpumkins/clite-go/ir"
+)
+
+// A Func is a program in SSA form.
+type Func struct {
+	Blocks  []*Block   // Blocks[0] is the entry block
+	Exit    *Block     // the block at which the program ends
+	Vars    []*ir.Var  // variables of the program
+	Results []ir.Value // final value of each of Vars
+
+	labels   map[string]bool // labels in use
+	versions map[string]int  // number of versions of each variable
+}
+
+// A Block is a basic block. A Block with a Cond has two successors:
+// Succs[0] is taken when Cond is true, Succs[1] when it is false.
+// Otherwise it has a single successor, except for the exit block.
+type Block struct {
+	Index  int
+	Label  string
+	Phis   []*Phi
+	Instrs []*ir.Instr // neither labels nor jumps
+	Cond   ir.Value
+	Succs  []*Block
+	Preds  []*Block
+}
+
+// A Phi selects the value of Args[i] when control comes from the i-th
+// predecessor of its block.
+type Phi struct {
+	Dst  *ir.Var
+	Args []ir.Value
+}
+
+func (f *Func) newBlock(label string) *Block {
+	if label == "" {
+		for i := len(f.Blocks); ; i++ {
+			label = fmt.Sprintf("B%d", i)
+			if !f.labels[label] {
+				break
+			}
+		}
+	}
+	f.labels[label] = true
+	b := &Block{Index: len(f.Blocks), Label: label}
+	f.Blocks = append(f.Blocks, b)
+	return b
+}
+
+func addEdge(from, to *Block) {
+	from.Succs = append(from.Succs, to)
+	to.Preds = append(to.Preds, from)
+}
+
+// version returns a new version of v. Versions are temporaries, so
+// that the variables of the program are only assigned when the
+// program ends.
+func (f *Func) version(v *ir.Var) *ir.Var {
+	name := v.Name
+	if !v.IsTemp() {
+		name = "%" + name
+	}
+	f.versions[name]++
+	return &ir.Var{Name: fmt.Sprintf("%s.%d", name, f.versions[name]), T: v.T}
+}
+
+// Build converts p to SSA form. p itself is left unchanged.
+func Build(p *ir.Program) *Func {
+	f := &Func{Vars: p.Vars, labels: make(map[string]bool), versions: make(map[string]int)}
+	for _, in := range p.Code {
+		if in.Op == ir.Label {
+			f.labels[in.Target] = true
+		}
+	}
+
+	// split into basic blocks
+	byLabel := make(map[string]*Block)
+	block := func(l string) *Block {
+		if byLabel[l] == nil {
+			byLabel[l] = &Block{Label: l}
+		}
+		return byLabel[l]
+	}
+	targets := make(map[*Block][]string)
+	f.Blocks = nil
+	cur := f.newBlock("")
+	for _, in := range p.Code {
+		switch in.Op {
+		case ir.Label:
+			b := block(in.Target)
+			b.Index = len(f.Blocks)
+			f.Blocks = append(f.Blocks, b)
+			if cur != nil {
+				targets[cur] = []string{in.Target}
+			}
+			cur = b
+		case ir.Jump, ir.If:
+			if cur == nil {
+				continue // unreachable
+			}
+			if in.Op == ir.If {
+				cur.Cond = in.Args[0]
+			}
+			targets[cur] = in.Targets()
+			cur = nil
+		default:
+			if cur == nil {
+				cur = f.newBlock("") // unreachable
+			}
+			c := *in
+			c.Args = append([]ir.Value(nil), in.Args...)
+			cur.Instrs = append(cur.Instrs, &c)
+		}
+	}
+	f.Exit = f.newBlock("")
+	if cur != nil {
+		addEdge(cur, f.Exit)
+	}
+	for _, b := range f.Blocks {
+		for _, l := range targets[b] {
+			addEdge(b, block(l))
+		}
+	}
+	f.removeUnreachable()
+
+	f.placePhis()
+	f.rename()
+	return f
+}
+
+// reachable returns the blocks reachable from the entry block, in
+// reverse postorder.
+func (f *Func) reachable() []*Block {
+	seen := make(map[*Block]bool)
+	var post []*Block
+	var visit func(b *Block)
+	visit = func(b *Block) {
+		seen[b] = true
+		for _, s := range b.Succs {
+			if !seen[s] {
+				visit(s)
+			}
+		}
+		post = append(post, b)
+	}
+	visit(f.Blocks[0])
+	for i, j := 0, len(post)-1; i < j; i, j = i+1, j-1 {
+		post[i], post[j] = post[j], post[i]
+	}
+	return post
+}
+
+// removeUnreachable removes the blocks, other than the exit block,
+// that cannot be reached from the entry block, and renumbers the
+// others.
+func (f *Func) removeUnreachable() {
+	live := make(map[*Block]bool)
+	for _, b := range f.reachable() {
+		live[b] = true
+	}
+	live[f.Exit] = true
+	var blocks []*Block
+	for _, b := range f.Blocks {
+		if live[b] {
+			b.Index = len(blocks)
+			blocks = append(blocks, b)
+			continue
+		}
+		for _, s := range b.Succs {
+			s.removePred(b)
+		}
+	}
+	f.Blocks = blocks
+}
+
+// removePred removes p from the predecessors of b, along with the
+// corresponding phi arguments.
+func (b *Block) removePred(p *Block) {
+	for i := 0; i < len(b.Preds); i++ {
+		if b.Preds[i] == p {
+			b.removePredAt(i)
+			i--
+		}
+	}
+}
+
+// removePredAt removes the i-th predecessor of b.
+func (b *Block) removePredAt(i int) {
+	b.Preds = append(b.Preds[:i], b.Preds[i+1:]...)
+	for _, phi := range b.Phis {
+		phi.Args = append(phi.Args[:i], phi.Args[i+1:]...)
+	}
+}
+
+// domTree holds the immediate dominators of the reachable blocks.
+type domTree struct {
+	order    []*Block // reverse postorder
+	idom     []*Block // indexed by Block.Index; nil for the entry block and unreachable blocks
+	children [][]*Block
+}
+
+// dominators computes the dominator tree of f with the algorithm of
+// Cooper, Harvey and Kennedy.
+func (f *Func) dominators() *domTree {
+	t := &domTree{order: f.reachable(), idom: make([]*Block, len(f.Blocks)), children: make([][]*Block, len(f.Blocks))}
+	rpo := make([]int, len(f.Blocks))
+	for i, b := range t.order {
+		rpo[b.Index] = i
+	}
+	entry := f.Blocks[0]
+	t.idom[entry.Index] = entry
+	intersect := func(a, b *Block) *Block {
+		for a != b {
+			for rpo[a.Index] > rpo[b.Index] {
+				a = t.idom[a.Index]
+			}
+			for rpo[b.Index] > rpo[a.Index] {
+				b = t.idom[b.Index]
+			}
+		}
+		return a
+	}
+	for changed := true; changed; {
+		changed = false
+		for _, b := range t.order[1:] {
+			var idom *Block
+			for _, p := range b.Preds {
+				if t.idom[p.Index] == nil {
+					continue
+				}
+				if idom == nil {
+					idom = p
+				} else {
+					idom = intersect(p, idom)
+				}
+			}
+			if t.idom[b.Index] != idom {
+				t.idom[b.Index] = idom
+				changed = true
+			}
+		}
+	}
+	t.idom[entry.Index] = nil
+	for _, b := range t.order[1:] {
+		d := t.idom[b.Index]
+		t.children[d.Index] = append(t.children[d.Index], b)
+	}
+	return t
+}
+
+// dominates reports whether a dominates b.
+func (t *domTree) dominates(a, b *Block) bool {
+	for ; b != nil; b = t.idom[b.Index] {
+		if a == b {
+			return true
+		}
+	}
+	return false
+}
+
+// frontiers returns the dominance frontier of each block.
+func (t *domTree) frontiers(f *Func) [][]*Block {
+	df := make([][]*Block, len(f.Blocks))
+	for _, b := range t.order {
+		if len(b.Preds) < 2 {
+			continue
+		}
+		for _, p := range b.Preds {
+			for r := p; r != nil && r != t.idom[b.Index]; r = t.idom[r.Index] {
+				if n := len(df[r.Index]); n == 0 || df[r.Index][n-1] != b {
+					df[r.Index] = append(df[r.Index], b)
+				}
+			}
+		}
+	}
+	return df
+}
+
+// placePhis inserts a phi for a variable at the iterated dominance
+// frontier of the blocks assigning it. The phis assign the original
+// variable, and their arguments are left to rename. Only the variables
+// read in some block before being assigned in it, or read when the
+// program ends, may need phis.
+func (f *Func) placePhis() {
+	df := f.dominators().frontiers(f)
+	var vars []*ir.Var
+	defs := make(map[*ir.Var][]*Block)
+	global := make(map[*ir.Var]bool)
+	for _, v := range f.Vars {
+		global[v] = true
+	}
+	for _, b := range f.Blocks {
+		local := make(map[*ir.Var]bool)
+		use := func(x ir.Value) {
+			if v, ok := x.(*ir.Var); ok && !local[v] {
+				global[v] = true
+			}
+		}
+		for _, in := range b.Instrs {
+			for _, x := range in.Args {
+				use(x)
+			}
+			local[in.Dst] = true
+			if defs[in.Dst] == nil {
+				vars = append(vars, in.Dst)
+			}
+			if n := len(defs[in.Dst]); n == 0 || defs[in.Dst][n-1] != b {
+				defs[in.Dst] = append(defs[in.Dst], b)
+			}
+		}
+		if b.Cond != nil {
+			use(b.Cond)
+		}
+	}
+	for _, v := range vars {
+		if !global[v] {
+			continue
+		}
+		has := make(map[*Block]bool)
+		work := append([]*Block(nil), defs[v]...)
+		defined := make(map[*Block]bool)
+		for _, b := range work {
+			defined[b] = true
+		}
+		for len(work) > 0 {
+			b := work[len(work)-1]
+			work = work[:len(work)-1]
+			for _, d := range df[b.Index] {
+				if has[d] {
+					continue
+				}
+				has[d] = true
+				d.Phis = append(d.Phis, &Phi{Dst: v, Args: make([]ir.Value, len(d.Preds))})
+				if !defined[d] {
+					defined[d] = true
+					work = append(work, d)
+				}
+			}
+		}
+	}
+}
+
+// rename gives each assignment its own version of the variable it
+// assigns, and replaces each use of a variable with the version
+// reaching it.
+func (f *Func) rename() {
+	t := f.dominators()
+	stacks := make(map[*ir.Var][]ir.Value)
+	top := func(x ir.Value) ir.Value {
+		v, ok := x.(*ir.Var)
+		if !ok {
+			return x
+		}
+		if s := stacks[v]; len(s) > 0 {
+			return s[len(s)-1]
+		}
+		return ir.Const{Val: eval.Zero(v.T)}
+	}
+	orig := make(map[*Phi]*ir.Var)
+	for _, b := range f.Blocks {
+		for _, phi := range b.Phis {
+			orig[phi] = phi.Dst
+		}
+	}
+
+	f.Results = make([]ir.Value, len(f.Vars))
+	for i, v := range f.Vars {
+		f.Results[i] = top(v)
+	}
+	var walk func(b *Block)
+	walk = func(b *Block) {
+		var pushed []*ir.Var
+		define := func(v *ir.Var) *ir.Var {
+			nv := f.version(v)
+			stacks[v] = append(stacks[v], nv)
+			pushed = append(pushed, v)
+			return nv
+		}
+		for _, phi := range b.Phis {
+			phi.Dst = define(phi.Dst)
+		}
+		for _, in := range b.Instrs {
+			for i, x := range in.Args {
+				in.Args[i] = top(x)
+			}
+			if in.Dst != nil {
+				in.Dst = define(in.Dst)
+			}
+		}
+		if b.Cond != nil {
+			b.Cond = top(b.Cond)
+		}
+		for _, s := range b.Succs {
+			for j, p := range s.Preds {
+				if p != b {
+					continue
+				}
+				for _, phi := range s.Phis {
+					phi.Args[j] = top(orig[phi])
+				}
+			}
+		}
+		if b == f.Exit {
+			for i, v := range f.Vars {
+				f.Results[i] = top(v)
+			}
+		}
+		for _, c := range t.children[b.Index] {
+			walk(c)
+		}
+		for _, v := range pushed {
+			stacks[v] = stacks[v][:len(stacks[v])-1]
+		}
+	}
+	walk(f.Blocks[0])
+}
+
+// String returns a textual representation of f.
+func (f *Func) String() string {
+	var buf bytes.Buffer
+	for _, b := range f.Blocks {
+		fmt.Fprintf(&buf, "%s:", b.Label)
+		if len(b.Preds) > 0 {
+			fmt.Fprintf(&buf, " // preds")
+			for _, p := range b.Preds {
+				fmt.Fprintf(&buf, " %s", p.Label)
+			}
+		}
+		fmt.Fprintf(&buf, "\n")
+		for _, phi := range b.Phis {
+			fmt.Fprintf(&buf, "\t%s = phi", phi.Dst)
+			for i, x := range phi.Args {
+				if i > 0 {
+					fmt.Fprintf(&buf, ",")
+				}
+				fmt.Fprintf(&buf, " %s", x)
+			}
+			fmt.Fprintf(&buf, "\n")
+		}
+		for _, in := range b.Instrs {
+			fmt.Fprintf(&buf, "\t%s\n", in)
+		}
+		switch {
+		case b.Cond != nil:
+			fmt.Fprintf(&buf, "\tif %s goto %s else %s\n", b.Cond, b.Succs[0].Label, b.Succs[1].Label)
+		case len(b.Succs) == 1:
+			fmt.Fprintf(&buf, "\tgoto %s\n", b.Succs[0].Label)
+		}
+	}
+	for i, v := range f.Vars {
+		fmt.Fprintf(&buf, "\t%s = %s\n", v, f.Results[i])
+	}
+	return buf.String()
+}
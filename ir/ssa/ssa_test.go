@@ -0,0 +1,310 @@
+package ssa
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/mentalpumkins/clite-go/ast"
+	"github.com/mentalpumkins/clite-go/ir"
+	"github.com/mentalpumkins/clite-go/lexer"
+	"github.com/mentalpumkins/clite-go/parser"
+)
+
+func lower(t *testing.T, src string) *ir.Program {
+	var l lexer.Lexer
+	l.Init([]byte(src))
+	var p parser.Parser
+	p.Init(l)
+	prog, err := ir.Lower(p.Program())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return prog
+}
+
+var programs = []string{
+	// loop invariant and common subexpressions
+	`int main() {
+	int i, n, a, b, s, k;
+	n = 10;
+	a = 3;
+	b = 4;
+	while (i < n) {
+		k = a * b + 1;
+		s = s + k + a * b;
+		i = i + 1;
+	}
+}`,
+	// constant conditions and dead code
+	`int main() {
+	int x, y;
+	bool t;
+	x = 2 * 3 + 1;
+	t = x > 5;
+	if (t) y = x; else y = 0 - x;
+	if (!t) { y = y / 0; }
+	while (false) x = x + 1;
+}`,
+	// swaps through phis, nested loops and short circuits
+	`int main() {
+	int a, b, c, i, j;
+	float f;
+	bool done;
+	a = 1;
+	b = 2;
+	while (i < 5) {
+		c = a;
+		a = b;
+		b = c;
+		j = 0;
+		while (j < i && !done) {
+			f = f + float(i * j) / 2.0;
+			j = j + 1;
+			done = f > 20.0;
+		}
+		i = i + 1;
+	}
+}`,
+	// a division that may fail must stay
+	`int main() {
+	int x, z;
+	x = 5 / z;
+	x = 7;
+}`,
+}
+
+// corpus returns the test programs and those shared by the code
+// generators, lowered.
+func corpus(t *testing.T) []*ir.Program {
+	var progs []*ir.Program
+	for _, src := range programs {
+		progs = append(progs, lower(t, src))
+	}
+	files, _ := filepath.Glob("../../codegen/testdata/*.cl")
+	for _, file := range files {
+		src, err := ioutil.ReadFile(file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		progs = append(progs, lower(t, string(src)))
+	}
+	return progs
+}
+
+type result struct {
+	vals []ast.Value
+	err  error
+}
+
+// equal is like reflect.DeepEqual, but for NaNs being equal.
+func (r result) equal(s result) bool {
+	if len(r.vals) != len(s.vals) || !reflect.DeepEqual(r.err, s.err) {
+		return false
+	}
+	for i, v := range r.vals {
+		x, ok1 := v.(ast.FloatVal)
+		y, ok2 := s.vals[i].(ast.FloatVal)
+		if ok1 && ok2 && x != x && y != y {
+			continue
+		}
+		if !reflect.DeepEqual(v, s.vals[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+func run(p *ir.Program) result {
+	vals, err := ir.Eval(p)
+	return result{vals, err}
+}
+
+// checkSSA checks that each variable of f is assigned once.
+func checkSSA(t *testing.T, f *Func) {
+	defs := make(map[*ir.Var]bool)
+	def := func(v *ir.Var) {
+		if defs[v] {
+			t.Errorf("%s assigned twice in\n%s", v, f)
+		}
+		defs[v] = true
+	}
+	for _, b := range f.Blocks {
+		for _, phi := range b.Phis {
+			def(phi.Dst)
+			if len(phi.Args) != len(b.Preds) {
+				t.Errorf("phi %s has %d arguments for %d predecessors", phi.Dst, len(phi.Args), len(b.Preds))
+			}
+		}
+		for _, in := range b.Instrs {
+			def(in.Dst)
+		}
+	}
+}
+
+func TestPasses(t *testing.T) {
+	passes := map[string]Pass{
+		"ConstProp": ConstProp,
+		"CopyProp":  CopyProp,
+		"CSE":       CSE,
+		"DCE":       DCE,
+		"LICM":      LICM,
+		"Optimize":  func(f *Func) bool { Optimize(f); return true },
+	}
+	for _, p := range corpus(t) {
+		want := run(p)
+		f := Build(p)
+		checkSSA(t, f)
+		if got := run(f.Program()); !got.equal(want) {
+			t.Errorf("SSA form yields %v, want %v:\n%s", got, want, f)
+		}
+		for name, pass := range passes {
+			f := Build(p)
+			pass(f)
+			checkSSA(t, f)
+			q := f.Program()
+			if got := run(q); !got.equal(want) {
+				t.Errorf("after %s: got %v, want %v:\n%s", name, got, want, f)
+			}
+			// the result is valid three-address code
+			if _, err := ir.Parse([]byte(q.String())); err != nil {
+				t.Errorf("after %s: %v\n%s", name, err, q)
+			}
+		}
+	}
+}
+
+func TestBuild(t *testing.T) {
+	f := Build(lower(t, `int main() {
+	int i, s;
+	while (i < 3) {
+		s = s + i;
+		i = i + 1;
+	}
+}`))
+	want := `B0:
+	goto L1
+L1: // preds B0 L2
+	%s.1 = phi 0, %s.2
+	%i.1 = phi 0, %i.2
+	%1.1 = lt %i.1, 3
+	if %1.1 goto L2 else L3
+L2: // preds L1
+	%s.2 = add %s.1, %i.1
+	%i.2 = add %i.1, 1
+	goto L1
+L3: // preds L1
+	goto B4
+B4: // preds L3
+	i = %i.1
+	s = %s.1
+`
+	if got := f.String(); got != want {
+		t.Errorf("got\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestOptimize(t *testing.T) {
+	progs := corpus(t)
+
+	f := Build(progs[0])
+	Optimize(f)
+	// a * b is folded, the loop stays
+	if strings.Contains(f.String(), "mul") || f.Results[1] != (ir.Const{Val: ast.IntVal(10)}) || len(f.Blocks) != 4 {
+		t.Errorf("loop not optimized:\n%s", f)
+	}
+
+	f = Build(progs[1])
+	Optimize(f)
+	if len(f.Blocks) != 1 || !reflect.DeepEqual(f.Results, []ir.Value{ir.Const{Val: ast.IntVal(7)}, ir.Const{Val: ast.IntVal(7)}, ir.Const{Val: ast.BoolVal(true)}}) {
+		t.Errorf("constant program not folded:\n%s", f)
+	}
+
+	f = Build(progs[3])
+	Optimize(f)
+	if !strings.Contains(f.String(), "div 5, 0") {
+		t.Errorf("failing division removed:\n%s", f)
+	}
+}
+
+const loop = `int main() {
+	int i, n, k, s;
+	n = 10;
+	while (i < n) {
+		k = n * n;
+		s = s + i * i + k;
+		s = s - i * i;
+		i = i + 1;
+	}
+}`
+
+func TestCSE(t *testing.T) {
+	f := Build(lower(t, loop))
+	CSE(f)
+	if n := strings.Count(f.String(), "mul %i"); n != 1 {
+		t.Errorf("i * i computed %d times:\n%s", n, f)
+	}
+}
+
+func TestLICM(t *testing.T) {
+	f := Build(lower(t, loop))
+	LICM(f)
+	// n * n moves to the entry block, which precedes the loop
+	for _, b := range f.Blocks {
+		for _, in := range b.Instrs {
+			if in.Op == ir.Mul && (b.Index == 0) != (in.Args[0] == in.Args[1] && strings.HasPrefix(in.Args[0].String(), "%n")) {
+				t.Errorf("%s in %s:\n%s", in, b.Label, f)
+			}
+		}
+	}
+}
+
+// A loop entered from several blocks gets a preheader, with phis
+// merging the values n, i and k have on entry.
+const entries = `var n int
+var m int
+var i int
+var k int
+var c bool
+temp %1 bool
+	m = add n, 3
+	c = lt n, 1
+	if c goto L1 else L2
+L1:
+	n = 5
+	goto L3
+L2:
+	n = 7
+	if c goto L3 else L9
+L9:
+L3:
+	%1 = lt i, n
+	if %1 goto L4 else L5
+L4:
+	k = mul m, m
+	i = add i, 1
+	goto L3
+L5:
+`
+
+func TestPreheader(t *testing.T) {
+	p, err := ir.Parse([]byte(entries))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := run(p)
+	f := Build(p)
+	if !LICM(f) {
+		t.Fatalf("nothing hoisted:\n%s", f)
+	}
+	checkSSA(t, f)
+	pre := f.Blocks[len(f.Blocks)-1]
+	if len(pre.Phis) != 3 || len(pre.Preds) != 3 || len(pre.Instrs) != 1 || pre.Instrs[0].Op != ir.Mul {
+		t.Errorf("bad preheader %s:\n%s", pre.Label, f)
+	}
+	if got := run(f.Program()); !got.equal(want) {
+		t.Errorf("got %v, want %v:\n%s", got, want, f.Program())
+	}
+}
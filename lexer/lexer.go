@@ -3,6 +3,8 @@ package lexer
 import (
 	"fmt"
 	"os"
+	"unicode"
+	"unicode/utf8"
 
 	"github.com/mentalpumkins/clite-go/token"
 )
@@ -27,6 +29,10 @@ type Lexer struct {
 	ErrorCount int
 }
 
+const bom = 0xFEFF // byte order mark, only permitted as very first character
+
+// next reads the next character of the source, decoding UTF-8, into
+// l.ch.
 func (l *Lexer) next() {
 	if l.rdOffset < len(l.src) {
 		l.offset = l.rdOffset
@@ -35,8 +41,17 @@ func (l *Lexer) next() {
 			l.lineOffset = l.offset
 		}
 		r, w := rune(l.src[l.rdOffset]), 1
-		if r == 0 {
+		switch {
+		case r == 0:
 			l.error("illegal character NUL")
+		case r >= utf8.RuneSelf:
+			// not ASCII
+			r, w = utf8.DecodeRune(l.src[l.rdOffset:])
+			if r == utf8.RuneError && w == 1 {
+				l.error("illegal UTF-8 encoding")
+			} else if r == bom {
+				l.error("illegal byte order mark")
+			}
 		}
 		l.rdOffset += w
 		l.ch = r
@@ -63,7 +78,7 @@ scanAgain:
 		} else {
 			tok = token.IDENTIFIER
 		}
-	case isDigit(ch):
+	case isDigit(ch) || ch == '.' && isDigit(rune(l.peek())):
 		tok, lit = l.scanNumber()
 	default: //don't strictly know why I to do two switches
 		l.next() //here but it does in the go one so here gos
@@ -75,21 +90,43 @@ scanAgain:
 			tok = token.SEMICOLON
 		case ',':
 			tok = token.COMMA
+		case ':':
+			tok = token.COLON
+		case '?':
+			tok = token.QUESTION
+		case '.':
+			switch {
+			case l.ch != '.':
+				tok = token.PERIOD
+			case l.peek() == '.':
+				l.next()
+				l.next()
+				tok = token.ELLIPSIS
+			default:
+				// .. is neither a selector nor a range
+				l.error("Illegal character")
+			}
 		case '\'':
-			lit = string(l.ch)
-			l.next() // get the trailing "'"
-			l.next() // move to space after
+			lit = l.scanChar()
 			tok = token.CHARLITERAL
+		case '"':
+			lit = l.scanString()
+			tok = token.STRINGLITERAL
 		// arithmetic operators
 		case '+':
-			lit = string(ch)
-			tok = token.PLUS
+			if tok = l.double('+', token.PLUS, token.INCREMENT); tok == token.PLUS {
+				tok, lit = l.switch2(token.PLUS, token.PLUSASSIGN)
+			} else {
+				lit = tok.String()
+			}
 		case '-':
-			lit = string(ch)
-			tok = token.MINUS
+			if tok = l.double('-', token.MINUS, token.DECREMENT); tok == token.MINUS {
+				tok, lit = l.switch2(token.MINUS, token.MINUSASSIGN)
+			} else {
+				lit = tok.String()
+			}
 		case '*':
-			lit = string(ch)
-			tok = token.MULTIPLY
+			tok, lit = l.switch2(token.MULTIPLY, token.MULTIPLYASSIGN)
 		case '/':
 			if l.ch == '/' {
 				// in a comment
@@ -99,8 +136,7 @@ scanAgain:
 				}
 				goto scanAgain
 			} else {
-				lit = string(ch)
-				tok = token.DIVIDE
+				tok, lit = l.switch2(token.DIVIDE, token.DIVIDEASSIGN)
 			}
 		// seperators
 		case '{':
@@ -116,21 +152,35 @@ scanAgain:
 		case ']':
 			tok = token.RIGHTBRACKET
 
+		case '%':
+			tok, lit = l.switch2(token.MODULO, token.MODULOASSIGN)
+		case '^':
+			lit = string(ch)
+			tok = token.BITXOR
+		case '~':
+			lit = string(ch)
+			tok = token.BITNOT
 		case '&':
-			l.match('&')
-			tok = token.AND
+			tok = l.double('&', token.BITAND, token.AND)
 			lit = tok.String()
 		case '|':
-			l.match('|')
-			tok = token.OR
+			tok = l.double('|', token.BITOR, token.OR)
 			lit = tok.String()
 
 		case '=':
 			tok, lit = l.switch2(token.ASSIGN, token.EQUALS)
 		case '<':
-			tok, lit = l.switch2(token.LESS, token.LESSEQUAL)
+			if tok = l.double('<', token.LESS, token.SHIFTLEFT); tok == token.LESS {
+				tok, lit = l.switch2(token.LESS, token.LESSEQUAL)
+			} else {
+				lit = tok.String()
+			}
 		case '>':
-			tok, lit = l.switch2(token.GREATER, token.GREATEREQUAL)
+			if tok = l.double('>', token.GREATER, token.SHIFTRIGHT); tok == token.GREATER {
+				tok, lit = l.switch2(token.GREATER, token.GREATEREQUAL)
+			} else {
+				lit = tok.String()
+			}
 		case '!':
 			tok, lit = l.switch2(token.NOT, token.NOTEQUAL)
 		default:
@@ -151,15 +201,43 @@ func (l *Lexer) Init(src []byte) {
 	l.ErrorCount = 0
 
 	l.err = DefaultErrorHandler
+
+	// skip the byte order mark, if any
+	if r, w := utf8.DecodeRune(src); r == bom {
+		l.rdOffset = w
+		l.lineOffset = w
+	}
+}
+
+// SetErrorHandler makes l report errors to h instead of
+// DefaultErrorHandler. It must be called after Init.
+func (l *Lexer) SetErrorHandler(h ErrorHandler) {
+	l.err = h
 }
 
+// Pos returns the position of the current character. Columns count
+// characters, not bytes, from 0.
 func (l *Lexer) Pos() token.Position {
-	return token.Position{l.offset, l.lineCount, (l.offset - l.lineOffset)}
+	column := utf8.RuneCount(l.src[l.lineOffset:l.offset])
+	return token.Position{l.offset, l.lineCount, column}
+}
+
+// peek returns the byte following the current character, or 0 at the
+// end of the source.
+func (l *Lexer) peek() byte {
+	if l.rdOffset < len(l.src) {
+		return l.src[l.rdOffset]
+	}
+	return 0
 }
 
 func (l *Lexer) error(msg string) {
+	l.errorAt(l.Pos(), msg)
+}
+
+func (l *Lexer) errorAt(pos token.Position, msg string) {
 	l.ErrorCount++
-	l.err(l.Pos(), msg)
+	l.err(pos, msg)
 }
 
 func (l *Lexer) AtEof() bool {
@@ -172,36 +250,250 @@ func (l *Lexer) skipWhitespace() {
 	}
 }
 
+// scanNumber scans an int or a float literal, which may start with a
+// '.'. Ints are decimal, or hexadecimal, octal or binary with a 0x, 0o
+// (or just 0) or 0b prefix; floats are decimal with a fraction, an
+// exponent or both. Underscores may separate digits. Malformed
+// literals are reported at their position; the parser converts the
+// literal, prefix and underscores included.
 func (l *Lexer) scanNumber() (token.Token, string) {
-	offs := l.offset
+	pos, offs := l.Pos(), l.offset
 	tok := token.INTLITERAL
-	for isDigit(l.ch) {
+	base, prefix := 10, rune(0)
+	invalid := -1 // offset of the first digit not in base
+
+	if l.ch != '.' {
+		if l.ch == '0' {
+			l.next()
+			switch lower(l.ch) {
+			case 'x':
+				l.next()
+				base, prefix = 16, 'x'
+			case 'o':
+				l.next()
+				base, prefix = 8, 'o'
+			case 'b':
+				l.next()
+				base, prefix = 2, 'b'
+			default:
+				base, prefix = 8, '0'
+			}
+		}
+		if !l.digits(base, &invalid) && prefix != 0 && prefix != '0' {
+			l.errorAt(pos, litName(prefix)+" literal has no digits")
+		}
+	}
+	if l.ch == '.' && l.peek() != '.' {
+		// 1...5 is a range, not the float 1. followed by ..
+		tok = token.FLOATLITERAL
+		if prefix != 0 && prefix != '0' {
+			l.errorAt(pos, "invalid radix point in "+litName(prefix)+" literal")
+		}
 		l.next()
+		l.digits(10, &invalid)
 	}
-	if l.ch == '.' {
+	if lower(l.ch) == 'e' {
 		tok = token.FLOATLITERAL
+		if prefix != 0 && prefix != '0' {
+			l.errorAt(pos, "'e' exponent requires decimal mantissa")
+		}
 		l.next()
-		for isDigit(l.ch) {
+		if l.ch == '+' || l.ch == '-' {
 			l.next()
 		}
+		if !l.digits(10, nil) {
+			l.errorAt(pos, "exponent has no digits")
+		}
+	}
+
+	lit := string(l.src[offs:l.offset])
+	if tok == token.INTLITERAL && invalid >= 0 {
+		l.errorAt(pos, fmt.Sprintf("invalid digit %q in %s literal", l.src[invalid], litName(prefix)))
+	}
+	if invalidSep(lit) >= 0 {
+		l.errorAt(pos, "'_' must separate successive digits")
 	}
-	return tok, string(l.src[offs:l.offset])
+	return tok, lit
+}
+
+// digits scans the digits, and underscores, of a number in base, and
+// reports whether there were any digits. For bases up to 10 it scans
+// all decimal digits, recording in *invalid the offset of the first
+// one not in base, as a float may hold them.
+func (l *Lexer) digits(base int, invalid *int) bool {
+	ok := false
+	for digitVal(l.ch) < base || base <= 10 && isDigit(l.ch) || l.ch == '_' {
+		if l.ch != '_' {
+			ok = true
+			if digitVal(l.ch) >= base && invalid != nil && *invalid < 0 {
+				*invalid = l.offset
+			}
+		}
+		l.next()
+	}
+	return ok
+}
+
+func litName(prefix rune) string {
+	switch prefix {
+	case 'x':
+		return "hexadecimal"
+	case 'o', '0':
+		return "octal"
+	case 'b':
+		return "binary"
+	}
+	return "decimal"
+}
+
+// invalidSep returns the index of the first underscore in the number
+// x that does not separate digits, or -1. A prefix counts as a digit.
+func invalidSep(x string) int {
+	x1 := ' ' // prefix letter
+	d := '.'  // kind of the last character: '_', '0' for a digit or '.'
+	i := 0
+	if len(x) >= 2 && x[0] == '0' {
+		x1 = lower(rune(x[1]))
+		if x1 == 'x' || x1 == 'o' || x1 == 'b' {
+			d = '0'
+			i = 2
+		}
+	}
+	for ; i < len(x); i++ {
+		p := d
+		d = rune(x[i])
+		switch {
+		case d == '_':
+			if p != '0' {
+				return i
+			}
+		case isDigit(d) || x1 == 'x' && digitVal(d) < 16:
+			d = '0'
+		default:
+			if p == '_' {
+				return i - 1
+			}
+			d = '.'
+		}
+	}
+	if d == '_' {
+		return len(x) - 1
+	}
+	return -1
+}
+
+// scanChar scans a char literal after its opening quote and returns
+// the character it denotes, with escape sequences decoded, so that the
+// literal of a CHARLITERAL token is its value.
+func (l *Lexer) scanChar() string {
+	var r rune
+	switch l.ch {
+	case '\'':
+		l.error("empty char literal")
+		l.next()
+		return string(r)
+	case '\n', -1:
+		l.error("char literal not terminated")
+		return string(r)
+	case '\\':
+		l.next()
+		r = l.scanEscape()
+	default:
+		r = l.ch
+		if r > 0xFF {
+			l.error(fmt.Sprintf("char literal %q out of range", r))
+		}
+		l.next()
+	}
+	if l.ch != '\'' {
+		// skip the rest of the literal, if it is on this line
+		for l.ch != '\'' && l.ch != '\n' && l.ch >= 0 {
+			l.next()
+		}
+		if l.ch != '\'' {
+			l.error("char literal not terminated")
+			return string(r)
+		}
+		l.error("more than one character in char literal")
+	}
+	l.next()
+	return string(r)
+}
+
+// scanString scans a string literal after its opening quote and
+// returns the text it denotes, with escape sequences decoded.
+func (l *Lexer) scanString() string {
+	var s []rune
+	for l.ch != '"' {
+		switch l.ch {
+		case '\n', -1:
+			l.error("string literal not terminated")
+			return string(s)
+		case '\\':
+			l.next()
+			s = append(s, l.scanEscape())
+		default:
+			if l.ch > 0xFF {
+				l.error(fmt.Sprintf("string literal character %q out of range", l.ch))
+			}
+			s = append(s, l.ch)
+			l.next()
+		}
+	}
+	l.next()
+	return string(s)
+}
+
+// scanEscape scans an escape sequence after its backslash and returns
+// the character it denotes.
+func (l *Lexer) scanEscape() rune {
+	switch ch := l.ch; ch {
+	case 'n':
+		l.next()
+		return '\n'
+	case 't':
+		l.next()
+		return '\t'
+	case '0':
+		l.next()
+		return 0
+	case '\\', '\'', '"':
+		l.next()
+		return ch
+	case 'x':
+		l.next()
+		var r rune
+		for i := 0; i < 2; i++ {
+			d := digitVal(l.ch)
+			if d >= 16 {
+				l.error("illegal hexadecimal digit in escape sequence")
+				return r
+			}
+			r = r*16 + rune(d)
+			l.next()
+		}
+		return r
+	}
+	l.error("unknown escape sequence")
+	return 0
 }
 
 func (l *Lexer) scanIdentifier() string {
 	offs := l.offset
-	for isLetter(l.ch) || isDigit(l.ch) {
+	for isLetter(l.ch) || isDigit(l.ch) || l.ch >= utf8.RuneSelf && unicode.IsDigit(l.ch) {
 		l.next()
 	}
 	return string(l.src[offs:l.offset])
 }
 
-func (l *Lexer) match(r rune) {
-	if l.ch != r {
-		l.error("couldn't match a character")
-	} else {
+// double returns tok1 for an operator whose character is doubled,
+// consuming the second one, and tok0 otherwise.
+func (l *Lexer) double(ch rune, tok0, tok1 token.Token) token.Token {
+	if l.ch == ch {
 		l.next()
+		return tok1
 	}
+	return tok0
 }
 
 func (l *Lexer) switch2(tok0, tok1 token.Token) (token.Token, string) {
@@ -214,16 +506,23 @@ func (l *Lexer) switch2(tok0, tok1 token.Token) (token.Token, string) {
 }
 
 func digitVal(ch rune) int {
-	if isDigit(ch) {
+	switch {
+	case isDigit(ch):
 		return int(ch - '0')
-	} else {
-		return 16
+	case 'a' <= ch && ch <= 'f':
+		return int(ch - 'a' + 10)
+	case 'A' <= ch && ch <= 'F':
+		return int(ch - 'A' + 10)
 	}
+	return 16
 }
+func lower(ch rune) rune { return ('a' - 'A') | ch }
+
 func isDigit(ch rune) bool {
 	return '0' <= ch && ch <= '9'
 }
 
 func isLetter(ch rune) bool {
-	return ('a' <= ch && ch <= 'z') || ('A' <= ch && ch <= 'Z')
+	return ('a' <= ch && ch <= 'z') || ('A' <= ch && ch <= 'Z') ||
+		ch >= utf8.RuneSelf && unicode.IsLetter(ch)
 }
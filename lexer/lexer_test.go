@@ -23,6 +23,60 @@ var tests = [...]test{
 	{";", token.SEMICOLON, ""},
 	{"'a'", token.CHARLITERAL, "a"},
 	{"// some comments \n alpha", token.IDENTIFIER, "alpha"},
+	{"'é'", token.CHARLITERAL, "é"},
+	{"größe2", token.IDENTIFIER, "größe2"},
+	{"\uFEFFx", token.IDENTIFIER, "x"},
+	{"π١", token.IDENTIFIER, "π١"},
+	{`'\n'`, token.CHARLITERAL, "\n"},
+	{`'\t'`, token.CHARLITERAL, "\t"},
+	{`'\0'`, token.CHARLITERAL, "\x00"},
+	{`'\\'`, token.CHARLITERAL, `\`},
+	{`'\''`, token.CHARLITERAL, "'"},
+	{`'\x41'`, token.CHARLITERAL, "A"},
+	{`'\xE9'`, token.CHARLITERAL, "é"},
+	{"0x1F", token.INTLITERAL, "0x1F"},
+	{"0XaB_cd", token.INTLITERAL, "0XaB_cd"},
+	{"0o17", token.INTLITERAL, "0o17"},
+	{"017", token.INTLITERAL, "017"},
+	{"0b1010", token.INTLITERAL, "0b1010"},
+	{"1_000_000", token.INTLITERAL, "1_000_000"},
+	{"0", token.INTLITERAL, "0"},
+	{"1.", token.FLOATLITERAL, "1."},
+	{".5", token.FLOATLITERAL, ".5"},
+	{"1e-3", token.FLOATLITERAL, "1e-3"},
+	{"2.5E+10", token.FLOATLITERAL, "2.5E+10"},
+	{"09.5", token.FLOATLITERAL, "09.5"},
+	{"1_0.2_5e1_0", token.FLOATLITERAL, "1_0.2_5e1_0"},
+	{`"héllo"`, token.STRINGLITERAL, "héllo"},
+	{`""`, token.STRINGLITERAL, ""},
+	{`"a\"b\n\xE9'"`, token.STRINGLITERAL, "a\"b\né'"},
+	{"len", token.LEN, "len"},
+	{"%", token.MODULO, "%"},
+	{"& &", token.BITAND, "&"},
+	{"|x", token.BITOR, "|"},
+	{"^", token.BITXOR, "^"},
+	{"~", token.BITNOT, "~"},
+	{"<<=", token.SHIFTLEFT, "<<"},
+	{">>", token.SHIFTRIGHT, ">>"},
+	{"<=", token.LESSEQUAL, "<="},
+	{"> >", token.GREATER, ">"},
+	{"+=", token.PLUSASSIGN, "+="},
+	{"-=", token.MINUSASSIGN, "-="},
+	{"*=", token.MULTIPLYASSIGN, "*="},
+	{"/=", token.DIVIDEASSIGN, "/="},
+	{"%=", token.MODULOASSIGN, "%="},
+	{"++", token.INCREMENT, "++"},
+	{"--x", token.DECREMENT, "--"},
+	{"- -", token.MINUS, "-"},
+	{"string", token.STRING, "string"},
+	{":", token.COLON, ""},
+	{"...", token.ELLIPSIS, ""},
+	{"?", token.QUESTION, ""},
+	{". x", token.PERIOD, ""},
+	{"struct", token.STRUCT, "struct"},
+	{"switch", token.SWITCH, "switch"},
+	{"const", token.CONST, "const"},
+	{"fallthrough", token.FALLTHROUGH, "fallthrough"},
 }
 
 func TestLexer(ts *testing.T) {
@@ -35,3 +89,75 @@ func TestLexer(ts *testing.T) {
 		}
 	}
 }
+
+func TestPos(t *testing.T) {
+	var l Lexer
+	l.Init([]byte("\uFEFFé = 'ü';\n  ß"))
+	for _, want := range []token.Position{
+		{3, 1, 0}, {6, 1, 2}, {8, 1, 4}, {12, 1, 7}, {16, 2, 2},
+	} {
+		if pos, tok, _ := l.Lex(); pos != want {
+			t.Errorf("%s at %#v, want %#v", tok, pos, want)
+		}
+	}
+}
+
+func TestRange(t *testing.T) {
+	var l Lexer
+	l.Init([]byte("1...5 1.5...2."))
+	for _, want := range []struct {
+		tok token.Token
+		lit string
+	}{
+		{token.INTLITERAL, "1"}, {token.ELLIPSIS, ""}, {token.INTLITERAL, "5"},
+		{token.FLOATLITERAL, "1.5"}, {token.ELLIPSIS, ""}, {token.FLOATLITERAL, "2."},
+		{token.EOF, ""},
+	} {
+		if _, tok, lit := l.Lex(); tok != want.tok || lit != want.lit {
+			t.Errorf("got %s %q, want %s %q", tok, lit, want.tok, want.lit)
+		}
+	}
+}
+
+func TestErrors(t *testing.T) {
+	for _, test := range []struct {
+		src, msg string
+	}{
+		{"a\xffb", "illegal UTF-8 encoding"},
+		{"a\uFEFF", "illegal byte order mark"},
+		{"''", "empty char literal"},
+		{"'a", "char literal not terminated"},
+		{"'\n'", "char literal not terminated"},
+		{"'ab' x", "more than one character in char literal"},
+		{"'Ā'", "char literal 'Ā' out of range"},
+		{`'\q'`, "unknown escape sequence"},
+		{`'\x4'`, "illegal hexadecimal digit in escape sequence"},
+		{"0x", "hexadecimal literal has no digits"},
+		{"0b102", "invalid digit '2' in binary literal"},
+		{"089", "invalid digit '8' in octal literal"},
+		{"0x1.5", "invalid radix point in hexadecimal literal"},
+		{"0b1e3", "'e' exponent requires decimal mantissa"},
+		{"1e+", "exponent has no digits"},
+		{"1__0", "'_' must separate successive digits"},
+		{"10_", "'_' must separate successive digits"},
+		{"1_.5", "'_' must separate successive digits"},
+		{`"abc`, "string literal not terminated"},
+		{"\"a\nb\"", "string literal not terminated"},
+		{`"aĀb"`, "string literal character 'Ā' out of range"},
+		{`"\q"`, "unknown escape sequence"},
+		{"1 .. 2", "Illegal character"},
+	} {
+		var l Lexer
+		l.Init([]byte(test.src))
+		var msgs []string
+		l.SetErrorHandler(func(pos token.Position, msg string) {
+			msgs = append(msgs, msg)
+		})
+		for !l.AtEof() {
+			l.Lex()
+		}
+		if len(msgs) == 0 || msgs[0] != test.msg {
+			t.Errorf("%q: errors %q, want %q", test.src, msgs, test.msg)
+		}
+	}
+}
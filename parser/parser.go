@@ -4,6 +4,8 @@ import (
 	"fmt"
 	"os"
 	"strconv"
+	"strings"
+	"unicode/utf8"
 
 	"github.com/mentalpumkins/clite-go/ast"
 	"github.com/mentalpumkins/clite-go/ast/operators"
@@ -16,13 +18,68 @@ type Parser struct {
 	lit string
 	lex lexer.Lexer
 	pos token.Position
+	err lexer.ErrorHandler
+
+	structs map[string]*ast.Struct // declared so far, by name
 }
 
 func (p *Parser) Init(l lexer.Lexer) {
 	p.lex = l
+	p.err = nil
+	p.structs = make(map[string]*ast.Struct)
 	p.nextTok()
 }
 
+// SetErrorHandler makes p report syntax errors to h instead of
+// printing them and exiting. It must be called after Init; errors of
+// the lexer go to the handler of the lexer given to Init. Parsing
+// continues after h returns, so h normally does not.
+func (p *Parser) SetErrorHandler(h lexer.ErrorHandler) {
+	p.err = h
+}
+
+// An Error is a syntax error.
+type Error struct {
+	Pos token.Position
+	Msg string
+}
+
+func (e *Error) Error() string { return fmt.Sprintf("%s: %s", e.Pos, e.Msg) }
+
+// Parse parses the clite program src. Unlike Parser, it returns the
+// first syntax error as an *Error instead of exiting.
+func Parse(src []byte) (prog *ast.Program, err error) {
+	bailout := func(pos token.Position, msg string) {
+		panic(&Error{pos, strings.TrimSpace(msg)})
+	}
+	defer func() {
+		if r := recover(); r != nil {
+			e, ok := r.(*Error)
+			if !ok {
+				panic(r)
+			}
+			prog, err = nil, e
+		}
+	}()
+	var l lexer.Lexer
+	l.Init(src)
+	l.SetErrorHandler(bailout)
+	var p Parser
+	p.Init(l)
+	p.SetErrorHandler(bailout)
+	return p.Program(), nil
+}
+
+// MustParse is like Parse but panics on a syntax error. It simplifies
+// parsing programs known to be valid, as in tests.
+func MustParse(src string) *ast.Program {
+	prog, err := Parse([]byte(src))
+	if err != nil {
+		panic(err)
+	}
+	return prog
+}
+
 func (p *Parser) Program() *ast.Program {
 	boilerPlate := []token.Token{ // int main ()
 		token.INT, token.MAIN, token.LEFTPAREN, token.RIGHTPAREN,
@@ -43,12 +100,30 @@ func (p *Parser) Program() *ast.Program {
 }
 
 func (p *Parser) declarations() []ast.Decl {
-	var decls []ast.Decl = make([]ast.Decl, 5)
-	for isType(p.tok) {
-		t := p.sType()
+	var decls []ast.Decl
+	for isType(p.tok) || p.tok == token.CONST || p.tok == token.STRUCT {
+		if p.tok == token.CONST {
+			decls = p.constDecl(decls)
+			continue
+		}
+		var t ast.Type
+		if p.tok == token.STRUCT {
+			pos := p.pos
+			p.match(token.STRUCT)
+			namePos := p.pos
+			name := p.identifier()
+			if p.tok == token.LEFTBRACE {
+				decls = append(decls, p.structDecl(pos, name))
+				continue
+			}
+			t = p.structType(namePos, name)
+		} else {
+			t = p.sType()
+		}
 		for p.tok != token.SEMICOLON {
+			pos := p.pos
 			name := ast.Variable(p.identifier())
-			dec := &ast.VariableDecl{name, t}
+			dec := &ast.VariableDecl{Var: name, T: t, Pos: pos}
 			decls = append(decls, ast.Decl(dec))
 			if p.tok == token.COMMA {
 				p.match(token.COMMA)
@@ -59,6 +134,55 @@ func (p *Parser) declarations() []ast.Decl {
 	return decls
 }
 
+// constDecl appends the constants of a declaration
+//
+//	const Type Identifier = Expression { , Identifier = Expression } ;
+//
+// to decls.
+func (p *Parser) constDecl(decls []ast.Decl) []ast.Decl {
+	p.match(token.CONST)
+	t := p.sType()
+	for {
+		pos := p.pos
+		name := ast.Variable(p.identifier())
+		p.match(token.ASSIGN)
+		decls = append(decls, &ast.ConstDecl{Var: name, T: t, Value: p.expression(), Pos: pos})
+		if p.tok != token.COMMA {
+			break
+		}
+		p.match(token.COMMA)
+	}
+	p.match(token.SEMICOLON)
+	return decls
+}
+
+// structDecl parses the rest of the declaration of the struct type
+// name, at pos,
+//
+//	struct Identifier { { Type Identifier { , Identifier } ; } } ;
+//
+// which the types declared after it may refer to. Declaring a struct
+// of a name already declared is an error, reported by the type checker.
+func (p *Parser) structDecl(pos token.Position, name string) *ast.StructDecl {
+	d := &ast.StructDecl{T: &ast.Struct{Name: name}, Pos: pos}
+	p.match(token.LEFTBRACE)
+	for p.tok != token.RIGHTBRACE {
+		t := p.sType()
+		for {
+			d.T.Fields = append(d.T.Fields, &ast.Field{Name: p.identifier(), T: t})
+			if p.tok != token.COMMA {
+				break
+			}
+			p.match(token.COMMA)
+		}
+		p.match(token.SEMICOLON)
+	}
+	p.match(token.RIGHTBRACE)
+	p.match(token.SEMICOLON)
+	p.structs[d.T.Name] = d.T
+	return d
+}
+
 func (p *Parser) identifier() string {
 	s := p.lit
 	p.match(token.IDENTIFIER)
@@ -66,7 +190,7 @@ func (p *Parser) identifier() string {
 }
 
 func (p *Parser) statements() []ast.Stmt {
-	s := make([]ast.Stmt, 3)
+	var s []ast.Stmt
 	for p.tok != token.RIGHTBRACE {
 		s = append(s, p.statement())
 	}
@@ -74,9 +198,8 @@ func (p *Parser) statements() []ast.Stmt {
 }
 
 func (p *Parser) block() *ast.Block {
-	b := &ast.Block{}
+	b := &ast.Block{Pos: p.pos}
 
-	fmt.Println("block")
 	p.match(token.LEFTBRACE)
 
 	b.Members = p.statements()
@@ -93,11 +216,13 @@ func (p *Parser) statement() (s ast.Stmt) {
 		s = p.ifstmt()
 	case token.WHILE:
 		s = p.loop()
+	case token.SWITCH:
+		s = p.switchStmt()
 	case token.IDENTIFIER:
 		s = p.assignment()
 	case token.SEMICOLON:
+		s = &ast.Skip{Pos: p.pos}
 		p.match(token.SEMICOLON)
-		s = &ast.Skip{}
 	default:
 		p.error(fmt.Sprintf("expecting stmt found %s", p.tok))
 	}
@@ -105,12 +230,33 @@ func (p *Parser) statement() (s ast.Stmt) {
 }
 
 func (p *Parser) assignment() *ast.Assignment {
-	v := ast.Variable(p.identifier())
-	p.match(token.ASSIGN)
-	return &ast.Assignment{v, p.expression()}
+	pos := p.pos
+	a := &ast.Assignment{Target: ast.Variable(p.identifier()), Pos: pos}
+	for p.tok == token.PERIOD {
+		p.match(token.PERIOD)
+		a.Fields = append(a.Fields, p.identifier())
+	}
+	switch t := p.tok; {
+	case isAssignOp(t):
+		// x op= y is x = x op y
+		a.Op = operators.Operator(strings.TrimSuffix(p.lit, "="))
+		p.match(t)
+		a.Source = &ast.Binary{a.Op, a.Lvalue(), p.expression()}
+	case t == token.INCREMENT || t == token.DECREMENT:
+		// x++ is x = x + 1
+		a.Op = operators.Operator(p.lit)
+		p.match(t)
+		a.Source = &ast.Binary{a.Op[:1], a.Lvalue(), ast.IntVal(1)}
+	default:
+		p.match(token.ASSIGN)
+		a.Source = p.expression()
+	}
+	p.match(token.SEMICOLON)
+	return a
 }
 
 func (p *Parser) ifstmt() (c *ast.Conditional) {
+	pos := p.pos
 	p.match(token.IF)
 	p.match(token.LEFTPAREN)
 
@@ -123,14 +269,15 @@ func (p *Parser) ifstmt() (c *ast.Conditional) {
 	if p.tok == token.ELSE {
 		p.match(p.tok)
 		s1 := p.statement()
-		c = &ast.Conditional{e, s, s1}
+		c = &ast.Conditional{Test: e, Body: s, Else: s1, Pos: pos}
 	} else {
-		c = &ast.Conditional{e, s, nil}
+		c = &ast.Conditional{Test: e, Body: s, Pos: pos}
 	}
 	return
 }
 
 func (p *Parser) loop() *ast.Loop {
+	pos := p.pos
 	p.match(token.WHILE)
 	p.match(token.LEFTPAREN)
 
@@ -140,7 +287,53 @@ func (p *Parser) loop() *ast.Loop {
 
 	s := p.statement()
 
-	return &ast.Loop{e, s}
+	return &ast.Loop{Test: e, Body: s, Pos: pos}
+}
+
+func (p *Parser) switchStmt() *ast.Switch {
+	s := &ast.Switch{Pos: p.pos}
+	p.match(token.SWITCH)
+	p.match(token.LEFTPAREN)
+	s.Tag = p.expression()
+	p.match(token.RIGHTPAREN)
+	p.match(token.LEFTBRACE)
+	for p.tok != token.RIGHTBRACE {
+		s.Cases = append(s.Cases, p.caseClause())
+	}
+	p.match(token.RIGHTBRACE)
+	return s
+}
+
+func (p *Parser) caseClause() *ast.Case {
+	c := &ast.Case{Pos: p.pos}
+	if p.tok == token.DEFAULT {
+		p.match(token.DEFAULT)
+	} else {
+		p.match(token.CASE)
+		for {
+			l := &ast.CaseLabel{Lo: p.expression()}
+			if p.tok == token.ELLIPSIS {
+				p.match(token.ELLIPSIS)
+				l.Hi = p.expression()
+			}
+			c.Labels = append(c.Labels, l)
+			if p.tok != token.COMMA {
+				break
+			}
+			p.match(token.COMMA)
+		}
+	}
+	p.match(token.COLON)
+	for !isCaseEnd(p.tok) {
+		c.Body = append(c.Body, p.statement())
+	}
+	if p.tok == token.FALLTHROUGH {
+		// only allowed as the last statement of a case
+		p.match(token.FALLTHROUGH)
+		p.match(token.SEMICOLON)
+		c.Fallthrough = true
+	}
+	return c
 }
 
 func (p *Parser) sType() ast.Type {
@@ -154,13 +347,41 @@ func (p *Parser) sType() ast.Type {
 		t = ast.CHAR_TYPE
 	case token.BOOL:
 		t = ast.BOOL_TYPE
+	case token.STRING:
+		t = ast.STRING_TYPE
+	case token.STRUCT:
+		p.match(token.STRUCT)
+		pos := p.pos
+		return p.structType(pos, p.identifier())
 	default:
 		p.error("Expecting Type")
 	}
 	p.match(p.tok)
 	return t
 }
+
+// structType returns the struct type name, named at pos.
+func (p *Parser) structType(pos token.Position, name string) *ast.Struct {
+	t, ok := p.structs[name]
+	if !ok {
+		p.errorAt(pos, fmt.Sprintf("undeclared struct %s", name))
+		t = &ast.Struct{Name: name}
+	}
+	return t
+}
 func (p *Parser) expression() ast.Expr {
+	e := p.disjunction()
+	if p.tok == token.QUESTION {
+		// right associative: a ? b : c ? d : e is a ? b : (c ? d : e)
+		p.match(token.QUESTION)
+		t := &ast.Ternary{Test: e, Then: p.expression()}
+		p.match(token.COLON)
+		t.Else = p.expression()
+		e = t
+	}
+	return e
+}
+func (p *Parser) disjunction() ast.Expr {
 	e := p.conjunction()
 	for p.tok == token.OR {
 		op := operators.Operator(p.lit)
@@ -171,8 +392,38 @@ func (p *Parser) expression() ast.Expr {
 	return e
 }
 func (p *Parser) conjunction() ast.Expr {
-	e := p.equality()
+	e := p.bitOr()
 	for p.tok == token.AND {
+		op := operators.Operator(p.lit)
+		p.match(p.tok)
+		term2 := p.bitOr()
+		e = &ast.Binary{op, e, term2}
+	}
+	return e
+}
+func (p *Parser) bitOr() ast.Expr {
+	e := p.bitXor()
+	for p.tok == token.BITOR {
+		op := operators.Operator(p.lit)
+		p.match(p.tok)
+		term2 := p.bitXor()
+		e = &ast.Binary{op, e, term2}
+	}
+	return e
+}
+func (p *Parser) bitXor() ast.Expr {
+	e := p.bitAnd()
+	for p.tok == token.BITXOR {
+		op := operators.Operator(p.lit)
+		p.match(p.tok)
+		term2 := p.bitAnd()
+		e = &ast.Binary{op, e, term2}
+	}
+	return e
+}
+func (p *Parser) bitAnd() ast.Expr {
+	e := p.equality()
+	for p.tok == token.BITAND {
 		op := operators.Operator(p.lit)
 		p.match(p.tok)
 		term2 := p.equality()
@@ -182,7 +433,7 @@ func (p *Parser) conjunction() ast.Expr {
 }
 func (p *Parser) equality() ast.Expr {
 	e := p.relation()
-	if isRelOp(p.tok) {
+	if isEqOp(p.tok) {
 		op := operators.Operator(p.lit)
 		p.match(p.tok)
 		term2 := p.relation()
@@ -191,8 +442,18 @@ func (p *Parser) equality() ast.Expr {
 	return e
 }
 func (p *Parser) relation() ast.Expr {
-	e := p.addition()
+	e := p.shift()
 	if isRelOp(p.tok) {
+		op := operators.Operator(p.lit)
+		p.match(p.tok)
+		term2 := p.shift()
+		e = &ast.Binary{op, e, term2}
+	}
+	return e
+}
+func (p *Parser) shift() ast.Expr {
+	e := p.addition()
+	for isShiftOp(p.tok) {
 		op := operators.Operator(p.lit)
 		p.match(p.tok)
 		term2 := p.addition()
@@ -234,15 +495,20 @@ func (p *Parser) primary() ast.Expr {
 	var e ast.Expr
 	switch t := p.tok; {
 	case t == token.IDENTIFIER:
-		e = ast.Variable(p.lit)
-		p.match(token.IDENTIFIER)
+		name := p.identifier()
+		if p.tok == token.LEFTPAREN {
+			e = p.call(name)
+		} else {
+			e = ast.Variable(name)
+		}
 	case isLiteral(t):
 		e = p.literal()
 	case t == token.LEFTPAREN:
 		p.match(token.LEFTPAREN)
 		e = p.expression()
 		p.match(token.RIGHTPAREN)
-	case isType(t):
+	case isType(t) || t == token.LEN:
+		// a type conversion or len
 		op := operators.Operator(p.lit)
 		p.match(p.tok)
 		p.match(token.LEFTPAREN)
@@ -252,23 +518,60 @@ func (p *Parser) primary() ast.Expr {
 	default:
 		p.error("Expecting primary expression")
 	}
+	for p.tok == token.LEFTBRACKET || p.tok == token.PERIOD {
+		if p.tok == token.PERIOD {
+			p.match(token.PERIOD)
+			e = &ast.Selector{X: e, Field: p.identifier()}
+			continue
+		}
+		p.match(token.LEFTBRACKET)
+		i := p.expression()
+		p.match(token.RIGHTBRACKET)
+		e = &ast.Index{X: e, Index: i}
+	}
 	return e
 }
 
+func (p *Parser) call(name string) *ast.Call {
+	c := &ast.Call{Name: name}
+	p.match(token.LEFTPAREN)
+	for p.tok != token.RIGHTPAREN {
+		c.Args = append(c.Args, p.expression())
+		if p.tok != token.RIGHTPAREN {
+			p.match(token.COMMA)
+		}
+	}
+	p.match(token.RIGHTPAREN)
+	return c
+}
+
 func (p *Parser) literal() ast.Value {
 	var t ast.Value
 	switch p.tok {
 	case token.INTLITERAL:
-		v, _ := strconv.ParseInt(p.lit, 10, 32)
+		// the lexer has checked the syntax, prefix and underscores
+		// included, which ParseInt accepts in base 0
+		v, err := strconv.ParseInt(p.lit, 0, 32)
+		if isRangeErr(err) {
+			p.error(fmt.Sprintf("integer literal %s out of range", p.lit))
+		}
 		t = ast.IntVal(v)
 	case token.FLOATLITERAL:
-		v, _ := strconv.ParseFloat(p.lit, 64)
+		v, err := strconv.ParseFloat(p.lit, 64)
+		if isRangeErr(err) {
+			p.error(fmt.Sprintf("float literal %s out of range", p.lit))
+		}
 		t = ast.FloatVal(v)
 	case token.TRUE, token.FALSE:
 		v, _ := strconv.ParseBool(p.lit)
 		t = ast.BoolVal(v)
 	case token.CHARLITERAL:
-		t = ast.CharVal(p.lit[0])
+		// the lexer has decoded the literal to its character
+		r, _ := utf8.DecodeRuneInString(p.lit)
+		t = ast.CharVal(r)
+	case token.STRINGLITERAL:
+		// and the characters of strings, which it has checked
+		t, _ = ast.StringOf(p.lit)
 	default:
 		p.error("Expecting Literal")
 	}
@@ -290,26 +593,59 @@ func (p *Parser) match(t token.Token) {
 }
 
 func (p *Parser) error(msg string) {
-	fmt.Fprintf(os.Stderr, "error %s at %s\n", msg, p.pos)
+	p.errorAt(p.pos, msg)
+}
+
+func (p *Parser) errorAt(pos token.Position, msg string) {
+	if p.err != nil {
+		p.err(pos, msg)
+		return
+	}
+	fmt.Fprintf(os.Stderr, "error %s at %s\n", msg, pos)
 	os.Exit(1)
 }
 
+func isRangeErr(err error) bool {
+	e, ok := err.(*strconv.NumError)
+	return ok && e.Err == strconv.ErrRange
+}
+
 func isLiteral(t token.Token) bool {
 	return t == token.INTLITERAL ||
 		t == token.FLOATLITERAL ||
 		t == token.CHARLITERAL ||
+		t == token.STRINGLITERAL ||
 		t == token.TRUE ||
 		t == token.FALSE
 }
 
+func isCaseEnd(t token.Token) bool {
+	return t == token.CASE ||
+		t == token.DEFAULT ||
+		t == token.FALLTHROUGH ||
+		t == token.RIGHTBRACE
+}
+func isAssignOp(t token.Token) bool {
+	return t == token.PLUSASSIGN ||
+		t == token.MINUSASSIGN ||
+		t == token.MULTIPLYASSIGN ||
+		t == token.DIVIDEASSIGN ||
+		t == token.MODULOASSIGN
+}
 func isAddOp(t token.Token) bool {
 	return t == token.PLUS || t == token.MINUS
 }
 func isMulOp(t token.Token) bool {
-	return t == token.MULTIPLY || t == token.DIVIDE
+	return t == token.MULTIPLY || t == token.DIVIDE || t == token.MODULO
+}
+func isShiftOp(t token.Token) bool {
+	return t == token.SHIFTLEFT || t == token.SHIFTRIGHT
 }
 func isUnaryOp(t token.Token) bool {
-	return t == token.NOT || t == token.MINUS
+	return t == token.NOT || t == token.MINUS || t == token.BITNOT
+}
+func isEqOp(t token.Token) bool {
+	return t == token.EQUALS || t == token.NOTEQUAL
 }
 func isRelOp(t token.Token) bool {
 	return t == token.LESS ||
@@ -321,5 +657,6 @@ func isType(t token.Token) bool {
 	return t == token.INT ||
 		t == token.BOOL ||
 		t == token.CHAR ||
-		t == token.FLOAT
+		t == token.FLOAT ||
+		t == token.STRING
 }
@@ -1,6 +1,7 @@
 package parser
 
 import (
+	"fmt"
 	"testing"
 
 	"github.com/mentalpumkins/clite-go/ast"
@@ -13,3 +14,250 @@ var tests struct {
 func TestParser(t *testing.T) {
 
 }
+
+func TestParse(t *testing.T) {
+	prog, err := Parse([]byte("int main() { int x; x = 1; }"))
+	if err != nil || len(prog.DecPart) != 1 || len(prog.Body) != 1 {
+		t.Errorf("got %v, %v", prog, err)
+	}
+	for _, test := range []struct {
+		src, err string
+	}{
+		{"int main() { int x; x = ; }", "1:24: Expecting primary expression"},
+		{"int main() {\n\tint x;\n\tx = 1\n}", "4:0: Expecting ; found }"},
+		{"int main() { int x; x = 1 # 2; }", "1:27: Illegal character"},
+		{"int main() { int x;", "1:19: expecting stmt found <EOF>"},
+		{"main() {}", "1:0: Expecting int found main"},
+		{"int main() { int x; x = f(1 2); }", "1:28: Expecting , found INT"},
+		{"int main() { char c; c = 'Ā'; }", "1:26: char literal 'Ā' out of range"},
+		{"int main() { int é; é = 1 \xff; }", "1:26: illegal UTF-8 encoding"},
+		{"int main() { int x; x = 99999999999; }", "1:24: integer literal 99999999999 out of range"},
+		{"int main() { int x; x = 0x8000_0000; }", "1:24: integer literal 0x8000_0000 out of range"},
+		{"int main() { float x; x = 1e400; }", "1:26: float literal 1e400 out of range"},
+		{"int main() { int x; x = 1 + 0x; }", "1:28: hexadecimal literal has no digits"},
+		{"int main() { string s; s = \"a\" + \"Ā\"; }", "1:34: string literal character 'Ā' out of range"},
+		{"int main() { char c; c = \"ab\"[1; }", "1:31: Expecting ] found ;"},
+	} {
+		prog, err := Parse([]byte(test.src))
+		if _, ok := err.(*Error); !ok || prog != nil || err.Error() != test.err {
+			t.Errorf("%q: got %v, want %s", test.src, err, test.err)
+		}
+	}
+}
+
+func TestMustParse(t *testing.T) {
+	if prog := MustParse("int main() { int x; x = 1; }"); len(prog.Body) != 1 {
+		t.Errorf("got %v", prog)
+	}
+	defer func() {
+		if err, ok := recover().(*Error); !ok || err.Error() != "1:24: Expecting primary expression" {
+			t.Errorf("got panic %v", err)
+		}
+	}()
+	MustParse("int main() { int x; x = ; }")
+}
+
+func TestCall(t *testing.T) {
+	prog, err := Parse([]byte("int main() { float x; x = max(f(), g(x, 1) + 2, -h(x)) * 2.0; }"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	a := prog.Body[0].(*ast.Assignment)
+	max := a.Source.(*ast.Binary).Term1.(*ast.Call)
+	if max.Name != "max" || len(max.Args) != 3 {
+		t.Fatalf("got %#v", max)
+	}
+	if f := max.Args[0].(*ast.Call); f.Name != "f" || len(f.Args) != 0 {
+		t.Errorf("got %#v", f)
+	}
+	if g := max.Args[1].(*ast.Binary).Term1.(*ast.Call); g.Name != "g" || len(g.Args) != 2 || g.Args[0] != ast.Variable("x") {
+		t.Errorf("got %#v", g)
+	}
+	if h := max.Args[2].(*ast.Unary).Term.(*ast.Call); h.Name != "h" || len(h.Args) != 1 {
+		t.Errorf("got %#v", h)
+	}
+}
+
+func TestIndex(t *testing.T) {
+	prog, err := Parse([]byte(`int main() { char c; c = (s + "ab")[len(s) - 1][0]; }`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	outer := prog.Body[0].(*ast.Assignment).Source.(*ast.Index)
+	if outer.Index != ast.IntVal(0) {
+		t.Errorf("got index %#v", outer.Index)
+	}
+	inner := outer.X.(*ast.Index)
+	if _, ok := inner.X.(*ast.Binary); !ok {
+		t.Errorf("got %#v", inner.X)
+	}
+	if l := inner.Index.(*ast.Binary).Term1.(*ast.Unary); l.Op != "len" || l.Term != ast.Variable("s") {
+		t.Errorf("got %#v", l)
+	}
+}
+
+func TestPrecedence(t *testing.T) {
+	for _, test := range []struct {
+		src, want string
+	}{
+		{"a | b ^ c & d", "(a | (b ^ (c & d)))"},
+		{"a & b == c", "(a & (b == c))"},
+		{"a << b + c < d", "((a << (b + c)) < d)"},
+		{"a >> b << c", "((a >> b) << c)"},
+		{"a + b % c * d", "(a + ((b % c) * d))"},
+		{"~a & -b", "(~a & -b)"},
+		{"a || b && c | d", "(a || (b && (c | d)))"},
+		{"a || b ? c + d : e", "((a || b) ? (c + d) : e)"},
+		{"a ? b : c ? d : e", "(a ? b : (c ? d : e))"},
+		{"a ? b ? c : d : e", "(a ? (b ? c : d) : e)"},
+	} {
+		prog, err := Parse([]byte("int main() { x = " + test.src + "; }"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := group(prog.Body[0].(*ast.Assignment).Source); got != test.want {
+			t.Errorf("%s parsed as %s, want %s", test.src, got, test.want)
+		}
+	}
+}
+
+// group returns e with each binary and conditional expression
+// parenthesized.
+func group(e ast.Expr) string {
+	switch e := e.(type) {
+	case *ast.Binary:
+		return "(" + group(e.Term1) + " " + string(e.Op) + " " + group(e.Term2) + ")"
+	case *ast.Unary:
+		return string(e.Op) + group(e.Term)
+	case *ast.Ternary:
+		return "(" + group(e.Test) + " ? " + group(e.Then) + " : " + group(e.Else) + ")"
+	}
+	return fmt.Sprint(e)
+}
+
+func TestCompoundAssignment(t *testing.T) {
+	prog, err := Parse([]byte("int main() { int i; i -= 2 * i; i++; i = 1; }"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for k, want := range []struct {
+		op, src string
+	}{
+		{"-", "(i - (2 * i))"},
+		{"++", "(i + 1)"},
+		{"", "1"},
+	} {
+		a := prog.Body[k].(*ast.Assignment)
+		if a.Target != "i" || string(a.Op) != want.op || group(a.Source) != want.src {
+			t.Errorf("%d: got %s %q %s", k, a.Target, a.Op, group(a.Source))
+		}
+	}
+
+	_, err = Parse([]byte("int main() { int i; i + 1; }"))
+	if err == nil || err.Error() != "1:22: Expecting = found +" {
+		t.Errorf("got %v", err)
+	}
+}
+
+func TestSwitch(t *testing.T) {
+	prog, err := Parse([]byte("int main() { int x; switch (x) { case 1, 3 ... 5: x = 0; fallthrough; default: case 2: ; } }"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := prog.Body[0].(*ast.Switch)
+	if len(s.Cases) != 3 {
+		t.Fatalf("got %d cases", len(s.Cases))
+	}
+	c := s.Cases[0]
+	if len(c.Labels) != 2 || c.Labels[0].Hi != nil || group(c.Labels[1].Hi) != "5" || len(c.Body) != 1 || !c.Fallthrough {
+		t.Errorf("case 0: got %+v", c)
+	}
+	if c := s.Cases[1]; c.Labels != nil || c.Body != nil || c.Fallthrough {
+		t.Errorf("default: got %+v", c)
+	}
+	if c := s.Cases[2]; len(c.Labels) != 1 || len(c.Body) != 1 {
+		t.Errorf("case 2: got %+v", c)
+	}
+
+	for _, test := range []struct {
+		src, err string
+	}{
+		{"int main() { int x; switch (x) { case 1: fallthrough; x = 1; } }", "1:54: Expecting case found IDENT"},
+		{"int main() { int x; switch (x) { x = 1; } }", "1:33: Expecting case found IDENT"},
+		{"int main() { int x; switch (x) { case 1 x = 1; } }", "1:40: Expecting : found IDENT"},
+	} {
+		_, err := Parse([]byte(test.src))
+		if err == nil || err.Error() != test.err {
+			t.Errorf("%q: got %v, want %s", test.src, err, test.err)
+		}
+	}
+}
+
+func TestConst(t *testing.T) {
+	prog, err := Parse([]byte("int main() { const int N = 1, M = N + 1; char c; const char A = 'a'; }"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, d := range prog.DecPart {
+		switch d := d.(type) {
+		case *ast.ConstDecl:
+			got = append(got, fmt.Sprintf("const %s %s = %s", d.T, d.Var, group(d.Value)))
+		case *ast.VariableDecl:
+			got = append(got, fmt.Sprintf("%s %s", d.T, d.Var))
+		}
+	}
+	if want := "[const int N = 1 const int M = (N + 1) char c const char A = a]"; fmt.Sprint(got) != want {
+		t.Errorf("got %v, want %s", got, want)
+	}
+
+	for _, test := range []struct {
+		src, err string
+	}{
+		{"int main() { const N = 1; }", "1:19: Expecting Type"},
+		{"int main() { const int N; }", "1:24: Expecting = found ;"},
+	} {
+		_, err := Parse([]byte(test.src))
+		if err == nil || err.Error() != test.err {
+			t.Errorf("%q: got %v, want %s", test.src, err, test.err)
+		}
+	}
+}
+
+func TestStruct(t *testing.T) {
+	prog, err := Parse([]byte("int main() { struct P { int x, y; float f; }; struct P p, q; bool b; p.x = 1; p.f += 2.0; p.y = (b ? p : q).x; }"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	sd := prog.DecPart[0].(*ast.StructDecl)
+	if sd.T.Name != "P" || len(sd.T.Fields) != 3 || sd.T.Field("f") != 2 || sd.T.Fields[2].T != ast.FLOAT_TYPE {
+		t.Errorf("got %+v", sd.T)
+	}
+	if d := prog.DecPart[1].(*ast.VariableDecl); d.T != sd.T {
+		t.Errorf("got type %v of %s", d.T, d.Var)
+	}
+	if a := prog.Body[0].(*ast.Assignment); a.Target != "p" || fmt.Sprint(a.Fields) != "[x]" {
+		t.Errorf("got %+v", a)
+	}
+	s := prog.Body[1].(*ast.Assignment).Source.(*ast.Binary)
+	if sel, ok := s.Term1.(*ast.Selector); !ok || sel.X != ast.Variable("p") || sel.Field != "f" {
+		t.Errorf("got %#v", s.Term1)
+	}
+	sel := prog.Body[2].(*ast.Assignment).Source.(*ast.Selector)
+	if _, ok := sel.X.(*ast.Ternary); !ok || sel.Field != "x" {
+		t.Errorf("got %#v", sel)
+	}
+
+	for _, test := range []struct {
+		src, err string
+	}{
+		{"int main() { struct P p; }", "1:20: undeclared struct P"},
+		{"int main() { struct P { int x }; }", "1:30: Expecting ; found }"},
+		{"int main() { struct P { int x; }; struct P p; p. = 1; }", "1:49: Expecting IDENT found ="},
+	} {
+		_, err := Parse([]byte(test.src))
+		if err == nil || err.Error() != test.err {
+			t.Errorf("%q: got %v, want %s", test.src, err, test.err)
+		}
+	}
+}
@@ -37,10 +37,32 @@ func (p PrettyPrinter) Visit(node Node) Visitor {
 		p.Printi("Loop: ")
 	case *Assignment:
 		p.Printi("Assignment: ")
+		for _, f := range n.Fields {
+			p.Print(".%s ", f)
+		}
+	case *Switch:
+		p.Printi("Switch: ")
+	case *Case:
+		p.Print("\n")
+		if n.Fallthrough {
+			p.Printi("Case (fallthrough): ")
+		} else {
+			p.Printi("Case: ")
+		}
+	case *CaseLabel:
+		p.Print("Label: ")
 	case *Binary:
 		p.Print("Binary: %s ", n.Op)
 	case *Unary:
 		p.Print("Unary: %s ", n.Op)
+	case *Call:
+		p.Print("Call: %s ", n.Name)
+	case *Index:
+		p.Print("Index: ")
+	case *Ternary:
+		p.Print("Ternary: ")
+	case *Selector:
+		p.Print("Selector: .%s ", n.Field)
 	case Value:
 		switch vt := n.(type) {
 		case IntVal:
@@ -51,6 +73,8 @@ func (p PrettyPrinter) Visit(node Node) Visitor {
 			p.Print("%s (%s) ", vt, n.GetType())
 		case BoolVal:
 			p.Print("%s (%s) ", vt, n.GetType())
+		case StringVal:
+			p.Print("%q (%s) ", vt.String(), n.GetType())
 		}
 	case *Skip:
 		p.Print("\n")
@@ -60,6 +84,16 @@ func (p PrettyPrinter) Visit(node Node) Visitor {
 	case *VariableDecl:
 		p.Printi("Decl: %s %s\n", n.Var, n.T)
 		return nil
+	case *ConstDecl:
+		p.Printi("Const: %s %s = %s\n", n.Var, n.T, String(n.Value))
+		return nil
+	case *StructDecl:
+		p.Printi("Struct: %s {", n.T.Name)
+		for _, f := range n.T.Fields {
+			p.Print(" %s %s;", f.T, f.Name)
+		}
+		p.Print(" }\n")
+		return nil
 	}
 	// set indent to one more
 	return PrettyPrinter{
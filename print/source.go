@@ -0,0 +1,359 @@
+package print
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"unicode"
+
+	. "github.com/mentalpumkins/clite-go/ast"
+)
+
+// Fprint writes node to w as clite source text. Expressions are
+// parenthesized only where the grammar requires it.
+func Fprint(w io.Writer, node Node) {
+	f := formatter{w: w, indent: "    "}
+	f.node(node)
+}
+
+// String returns the clite source text for node.
+func String(node Node) string {
+	var buf bytes.Buffer
+	Fprint(&buf, node)
+	return buf.String()
+}
+
+type formatter struct {
+	w      io.Writer
+	indent string
+	level  int
+}
+
+func (f *formatter) printf(s string, args ...interface{}) {
+	fmt.Fprintf(f.w, s, args...)
+}
+
+// line starts a new indented line.
+func (f *formatter) line() {
+	for i := 0; i < f.level; i++ {
+		f.printf("%s", f.indent)
+	}
+}
+
+func (f *formatter) node(node Node) {
+	switch n := node.(type) {
+	case *Program:
+		f.printf("int main() {\n")
+		f.level++
+		for _, d := range n.DecPart {
+			if d != nil {
+				f.line()
+				f.node(d)
+				f.printf("\n")
+			}
+		}
+		for _, s := range n.Body {
+			if s != nil {
+				f.line()
+				f.node(s)
+				f.printf("\n")
+			}
+		}
+		f.level--
+		f.printf("}\n")
+	case *VariableDecl:
+		f.printf("%s %s;", n.T, n.Var)
+	case *ConstDecl:
+		f.printf("const %s %s = ", n.T, n.Var)
+		f.expr(n.Value, 0)
+		f.printf(";")
+	case *StructDecl:
+		// a field per line
+		f.printf("struct %s {\n", n.T.Name)
+		f.level++
+		for _, x := range n.T.Fields {
+			f.line()
+			f.printf("%s %s;\n", x.T, x.Name)
+		}
+		f.level--
+		f.line()
+		f.printf("};")
+	case *Case:
+		f.caseClause(n)
+	case *CaseLabel:
+		f.expr(n.Lo, 0)
+		if n.Hi != nil {
+			f.printf(" ... ")
+			f.expr(n.Hi, 0)
+		}
+	case Stmt:
+		f.stmt(n)
+	case Expr:
+		f.expr(n, 0)
+	case Type:
+		f.printf("%s", n)
+	}
+}
+
+func (f *formatter) stmt(s Stmt) {
+	switch n := s.(type) {
+	case *Skip:
+		f.printf(";")
+	case *Assignment:
+		f.expr(n.Lvalue(), 0)
+		if y, ok := compound(n); ok {
+			if n.Op == "++" || n.Op == "--" {
+				f.printf("%s;", n.Op)
+				return
+			}
+			f.printf(" %s= ", n.Op)
+			f.expr(y, 0)
+			f.printf(";")
+			return
+		}
+		f.printf(" = ")
+		f.expr(n.Source, 0)
+		f.printf(";")
+	case *Block:
+		f.printf("{\n")
+		f.level++
+		for _, m := range n.Members {
+			if m != nil {
+				f.line()
+				f.stmt(m)
+				f.printf("\n")
+			}
+		}
+		f.level--
+		f.line()
+		f.printf("}")
+	case *Conditional:
+		f.printf("if (")
+		f.expr(n.Test, 0)
+		f.printf(") ")
+		f.stmt(n.Body)
+		if n.Else != nil {
+			f.printf(" else ")
+			f.stmt(n.Else)
+		}
+	case *Loop:
+		f.printf("while (")
+		f.expr(n.Test, 0)
+		f.printf(") ")
+		f.stmt(n.Body)
+	case *Switch:
+		// cases are indented like their switch
+		f.printf("switch (")
+		f.expr(n.Tag, 0)
+		f.printf(") {\n")
+		for _, c := range n.Cases {
+			f.line()
+			f.caseClause(c)
+		}
+		f.line()
+		f.printf("}")
+	}
+}
+
+// caseClause prints c, with a line for each statement of its body.
+func (f *formatter) caseClause(c *Case) {
+	if len(c.Labels) == 0 {
+		f.printf("default:\n")
+	} else {
+		f.printf("case ")
+		for i, l := range c.Labels {
+			if i > 0 {
+				f.printf(", ")
+			}
+			f.node(l)
+		}
+		f.printf(":\n")
+	}
+	f.level++
+	for _, s := range c.Body {
+		if s != nil {
+			f.line()
+			f.stmt(s)
+			f.printf("\n")
+		}
+	}
+	if c.Fallthrough {
+		f.line()
+		f.printf("fallthrough;\n")
+	}
+	f.level--
+}
+
+// compound reports whether a is still in the form recorded by its Op,
+// which rewrites of its Source may have undone, and returns the right
+// operand of the operator applied.
+func compound(a *Assignment) (Expr, bool) {
+	b, ok := a.Source.(*Binary)
+	if a.Op == "" || !ok || !assigns(a, b.Term1) {
+		return nil, false
+	}
+	switch a.Op {
+	case "++", "--":
+		return b.Term2, b.Op == a.Op[:1] && b.Term2 == Expr(IntVal(1))
+	}
+	return b.Term2, b.Op == a.Op
+}
+
+// assigns reports whether x is the variable, or the field of one,
+// assigned by a.
+func assigns(a *Assignment, x Expr) bool {
+	for i := len(a.Fields) - 1; i >= 0; i-- {
+		s, ok := x.(*Selector)
+		if !ok || s.Field != a.Fields[i] {
+			return false
+		}
+		x = s.X
+	}
+	return x == Expr(a.Target)
+}
+
+// Binary operator precedence, loosest first. Equality and
+// relational operators do not associate.
+func precedence(op string) int {
+	switch op {
+	case "||":
+		return 1
+	case "&&":
+		return 2
+	case "|":
+		return 3
+	case "^":
+		return 4
+	case "&":
+		return 5
+	case "==", "!=":
+		return 6
+	case "<", "<=", ">", ">=":
+		return 7
+	case "<<", ">>":
+		return 8
+	case "+", "-":
+		return 9
+	case "*", "/", "%":
+		return 10
+	}
+	return 0
+}
+
+const unaryPrec = 11
+
+// expr prints e inside a context of precedence prec; e is wrapped in
+// parentheses when it binds more loosely than its context.
+func (f *formatter) expr(e Expr, prec int) {
+	switch n := e.(type) {
+	case Variable:
+		f.printf("%s", n)
+	case CharVal:
+		f.printf("'%s'", escape(rune(n), '\''))
+	case StringVal:
+		f.printf("\"")
+		for i := 0; i < len(n); i++ {
+			f.printf("%s", escape(rune(n[i]), '"'))
+		}
+		f.printf("\"")
+	case BoolVal:
+		f.printf("%t", bool(n))
+	case FloatVal:
+		// every digit, and a float literal even when integral
+		s := strconv.FormatFloat(float64(n), 'g', -1, 64)
+		if !strings.ContainsAny(s, ".eIN") {
+			s += ".0"
+		}
+		f.printf("%s", s)
+	case Value:
+		f.printf("%s", n)
+	case *Binary:
+		p := precedence(string(n.Op))
+		nonassoc := p == 6 || p == 7
+		if p < prec {
+			f.printf("(")
+		}
+		left := p
+		if nonassoc {
+			left = p + 1
+		}
+		f.expr(n.Term1, left)
+		f.printf(" %s ", n.Op)
+		// binary operators are left associative
+		f.expr(n.Term2, p+1)
+		if p < prec {
+			f.printf(")")
+		}
+	case *Unary:
+		switch n.Op {
+		case "!", "-", "~":
+			// the grammar only allows a primary after a unary operator
+			if unaryPrec < prec {
+				f.printf("(")
+			}
+			f.printf("%s", n.Op)
+			f.expr(n.Term, unaryPrec+1)
+			if unaryPrec < prec {
+				f.printf(")")
+			}
+		default:
+			// type conversion or len
+			f.printf("%s(", n.Op)
+			f.expr(n.Term, 0)
+			f.printf(")")
+		}
+	case *Call:
+		f.printf("%s(", n.Name)
+		for i, x := range n.Args {
+			if i > 0 {
+				f.printf(", ")
+			}
+			f.expr(x, 0)
+		}
+		f.printf(")")
+	case *Index:
+		// the indexed expression must be a primary
+		f.expr(n.X, unaryPrec+1)
+		f.printf("[")
+		f.expr(n.Index, 0)
+		f.printf("]")
+	case *Selector:
+		// likewise
+		f.expr(n.X, unaryPrec+1)
+		f.printf(".%s", n.Field)
+	case *Ternary:
+		// looser than any binary operator, and right associative
+		if prec > 0 {
+			f.printf("(")
+		}
+		f.expr(n.Test, 1)
+		f.printf(" ? ")
+		f.expr(n.Then, 0)
+		f.printf(" : ")
+		f.expr(n.Else, 0)
+		if prec > 0 {
+			f.printf(")")
+		}
+	}
+}
+
+// escape returns the text of the char r inside a char or a string
+// literal, quoted by quote.
+func escape(r, quote rune) string {
+	switch r {
+	case '\n':
+		return `\n`
+	case '\t':
+		return `\t`
+	case 0:
+		return `\0`
+	case '\\', quote:
+		return `\` + string(r)
+	}
+	if !unicode.IsPrint(r) {
+		return fmt.Sprintf(`\x%02x`, r)
+	}
+	return string(r)
+}
@@ -0,0 +1,82 @@
+package print
+
+import (
+	"testing"
+
+	. "github.com/mentalpumkins/clite-go/ast"
+	"github.com/mentalpumkins/clite-go/parser"
+)
+
+func TestAssignment(t *testing.T) {
+	for _, src := range []string{
+		"x = x + 1;",
+		"x += 1;",
+		"x -= y * 2;",
+		"x *= y - 2;",
+		"x /= 2;",
+		"x %= 3;",
+		"x++;",
+		"x--;",
+		"x = a ? b : c ? d : e;",
+		"x = (a ? b : c) ? 1 : 2 + 3;",
+		"x = (a || b ? 1 : 2) * 3;",
+	} {
+		prog, err := parser.Parse([]byte("int main() { " + src + " }"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := String(prog.Body[0]); got != src {
+			t.Errorf("%s printed as %s", src, got)
+		}
+	}
+
+	// a Source no longer in the recorded form is printed as is
+	a := &Assignment{Target: "x", Source: &Binary{Op: "+", Term1: Variable("x"), Term2: IntVal(2)}, Op: "++"}
+	if got, want := String(a), "x = x + 2;"; got != want {
+		t.Errorf("got %s, want %s", got, want)
+	}
+}
+
+func TestSwitch(t *testing.T) {
+	src := `switch (x % 3) {
+case 0, 2 ... 4:
+    x = 1;
+    if (y) x = 2;
+    fallthrough;
+default:
+case 'a' - 1:
+    switch (y) {
+    case 1:
+        ;
+    }
+}`
+	prog, err := parser.Parse([]byte("int main() { " + src + " }"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := String(prog.Body[0]); got != src {
+		t.Errorf("printed as\n%s\nwant\n%s", got, src)
+	}
+}
+
+func TestConstDecl(t *testing.T) {
+	src := "int main() {\n    const int N = -1;\n    const char A = 'a';\n    int i;\n    i = N * 2;\n}\n"
+	prog, err := parser.Parse([]byte(src))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := String(prog); got != src {
+		t.Errorf("printed as\n%s\nwant\n%s", got, src)
+	}
+}
+
+func TestStructDecl(t *testing.T) {
+	src := "int main() {\n    struct P {\n        int x;\n        string s;\n    };\n    struct P p;\n    struct P q;\n    p = q;\n    p.x += (p.x > 0 ? p : q).x;\n    p.s = q.s + \"a\";\n    p.x++;\n}\n"
+	prog, err := parser.Parse([]byte(src))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := String(prog); got != src {
+		t.Errorf("printed as\n%s\nwant\n%s", got, src)
+	}
+}
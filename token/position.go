@@ -6,7 +6,7 @@ import "fmt"
 type Position struct {
 	Offset int // offset, starting at 0
 	Line   int // line number, starting at 1
-	Column int // column number, starting at 1 (byte count)
+	Column int // column number, starting at 0 (character count)
 }
 
 func (pos *Position) IsValid() bool { return pos.Line > 0 }
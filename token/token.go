@@ -13,13 +13,21 @@ const (
 	reserved_beg
 
 	BOOL
+	CASE
 	CHAR
+	CONST
+	DEFAULT
 	ELSE
+	FALLTHROUGH
 	FALSE
 	FLOAT
 	IF
 	INT
+	LEN
 	MAIN
+	STRING
+	STRUCT
+	SWITCH
 	TRUE
 	WHILE
 
@@ -34,7 +42,18 @@ const (
 
 	SEMICOLON
 	COMMA
+	COLON
+	ELLIPSIS
+	PERIOD
+	QUESTION
 	ASSIGN
+	PLUSASSIGN
+	MINUSASSIGN
+	MULTIPLYASSIGN
+	DIVIDEASSIGN
+	MODULOASSIGN
+	INCREMENT
+	DECREMENT
 
 	operator_beg
 
@@ -51,9 +70,17 @@ const (
 	MULTIPLY
 
 	DIVIDE
+	MODULO
 	AND
 	OR
 
+	BITAND
+	BITOR
+	BITXOR
+	BITNOT
+	SHIFTLEFT
+	SHIFTRIGHT
+
 	operator_end
 
 	IDENTIFIER
@@ -63,6 +90,7 @@ const (
 	INTLITERAL
 	FLOATLITERAL
 	CHARLITERAL
+	STRINGLITERAL
 
 	literal_end
 )
@@ -70,16 +98,24 @@ const (
 var tokens = [...]string{
 	EOF: "<EOF>",
 
-	BOOL:  "bool",
-	CHAR:  "char",
-	ELSE:  "else",
-	FALSE: "false",
-	FLOAT: "float",
-	IF:    "if",
-	INT:   "int",
-	MAIN:  "main",
-	TRUE:  "true",
-	WHILE: "while",
+	BOOL:        "bool",
+	CASE:        "case",
+	CHAR:        "char",
+	CONST:       "const",
+	DEFAULT:     "default",
+	ELSE:        "else",
+	FALLTHROUGH: "fallthrough",
+	FALSE:       "false",
+	FLOAT:       "float",
+	IF:          "if",
+	INT:         "int",
+	LEN:         "len",
+	MAIN:        "main",
+	STRING:      "string",
+	STRUCT:      "struct",
+	SWITCH:      "switch",
+	TRUE:        "true",
+	WHILE:       "while",
 
 	LEFTBRACE:    "{",
 	RIGHTBRACE:   "}",
@@ -90,8 +126,20 @@ var tokens = [...]string{
 
 	SEMICOLON: ";",
 	COMMA:     ",",
+	COLON:     ":",
+	ELLIPSIS:  "...",
+	PERIOD:    ".",
+	QUESTION:  "?",
 	ASSIGN:    "=",
 
+	PLUSASSIGN:     "+=",
+	MINUSASSIGN:    "-=",
+	MULTIPLYASSIGN: "*=",
+	DIVIDEASSIGN:   "/=",
+	MODULOASSIGN:   "%=",
+	INCREMENT:      "++",
+	DECREMENT:      "--",
+
 	EQUALS:       "==",
 	LESS:         "<",
 	LESSEQUAL:    "<=",
@@ -104,14 +152,23 @@ var tokens = [...]string{
 	MINUS:    "-",
 	MULTIPLY: "*",
 	DIVIDE:   "/",
+	MODULO:   "%",
 	AND:      "&&",
 	OR:       "||",
 
+	BITAND:     "&",
+	BITOR:      "|",
+	BITXOR:     "^",
+	BITNOT:     "~",
+	SHIFTLEFT:  "<<",
+	SHIFTRIGHT: ">>",
+
 	IDENTIFIER: "IDENT",
 
-	INTLITERAL:   "INT",
-	FLOATLITERAL: "FLOAT",
-	CHARLITERAL:  "CHAR",
+	INTLITERAL:    "INT",
+	FLOATLITERAL:  "FLOAT",
+	CHARLITERAL:   "CHAR",
+	STRINGLITERAL: "STRING",
 }
 
 func (tok Token) String() string {
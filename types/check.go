@@ -5,6 +5,8 @@ import (
 	"os"
 
 	. "github.com/mentalpumkins/clite-go/ast"
+	"github.com/mentalpumkins/clite-go/eval"
+	"github.com/mentalpumkins/clite-go/print"
 )
 
 type DuplicateDeclerationError string
@@ -14,30 +16,79 @@ func (e DuplicateDeclerationError) Error() string {
 }
 
 func Check(prog *Program) (bool, *TypeChecker, error) {
+	return CheckFuncs(prog, nil)
+}
+
+// CheckFuncs is like Check for a program that may call the functions
+// described by funcs.
+func CheckFuncs(prog *Program, funcs Funcs) (bool, *TypeChecker, error) {
 	tc := new(TypeChecker)
-	if err := tc.Init(prog); err != nil {
-		return false, tc, nil
+	if err := tc.InitFuncs(prog, funcs); err != nil {
+		return false, tc, err
 	}
 	Walk(tc, prog)
 
 	return (tc.ErrCount == 0), tc, nil
 }
 
+// A Signature gives the types of the parameters and of the result of
+// a function.
+type Signature struct {
+	Params []Type
+	Result Type
+}
+
+// Funcs maps the names of functions to their signatures.
+type Funcs map[string]Signature
+
 type ErrorHandler func(string, ...interface{})
 
+// Consts maps the names of the constants of a program to their values.
+// The TypeMap gives their types, like those of variables.
+type Consts map[Variable]Value
+
+// Lookup returns the value of the constant v. It may be passed to
+// eval.Expr to evaluate an expression over constants.
+func (c Consts) Lookup(v Variable) (Value, bool) {
+	x, ok := c[v]
+	return x, ok && x != nil
+}
+
 type TypeChecker struct {
-	tm *TypeMap
+	tm     *TypeMap
+	funcs  Funcs
+	consts Consts // nil values for constants not yet evaluated
 
 	ErrCount int
 	err      ErrorHandler
 }
 
 func (tc *TypeChecker) Init(prog *Program) error {
+	return tc.InitFuncs(prog, nil)
+}
+
+// InitFuncs is like Init for a program that may call the functions
+// described by funcs. Functions and variables share a name space: the
+// typing of the program gives the result type of each function.
+func (tc *TypeChecker) InitFuncs(prog *Program, funcs Funcs) error {
 	var err error
 	tc.tm, err = Typing(prog)
 	if err != nil {
 		return err
 	}
+	for name, sig := range funcs {
+		if _, duplicate := (*tc.tm)[Variable(name)]; duplicate {
+			return DuplicateDeclerationError(name)
+		}
+		(*tc.tm)[Variable(name)] = sig.Result
+	}
+	tc.funcs = funcs
+	tc.consts = make(Consts)
+	for _, d := range prog.DecPart {
+		if d, ok := d.(*ConstDecl); ok {
+			tc.consts[d.Var] = nil
+		}
+	}
 	tc.err = func(s string, args ...interface{}) {
 		fmt.Fprintf(os.Stderr, s, args...)
 	}
@@ -45,22 +96,155 @@ func (tc *TypeChecker) Init(prog *Program) error {
 	return nil
 }
 
+// SetErrorHandler makes tc report errors to h instead of printing
+// them to standard error. It must be called after Init.
+func (tc *TypeChecker) SetErrorHandler(h ErrorHandler) {
+	tc.err = h
+}
+
+// TypeMap returns the typing of the program being checked.
+func (tc *TypeChecker) TypeMap() *TypeMap { return tc.tm }
+
+// Consts returns the values of the constants of the program being
+// checked, once it has been walked.
+func (tc *TypeChecker) Consts() Consts { return tc.consts }
+
 func (tc *TypeChecker) Visit(node Node) Visitor {
 	if v, ok := node.(Variable); ok {
 		// don't like this pointer dereference syntax but whatever...
 		if _, inMap := (*(tc.tm))[v]; !inMap {
 			tc.error("Undefined Variable ref %s\n", v)
+		} else if _, isFunc := tc.funcs[string(v)]; isFunc {
+			tc.error("Function %s used as a variable\n", v)
 		}
 	}
+	if c, ok := node.(*Call); ok {
+		tc.call(c)
+	}
+	if a, ok := node.(*Assignment); ok {
+		if _, isConst := tc.consts[a.Target]; isConst {
+			tc.error("Cannot assign to constant %s at %s\n", a.Target, a.Pos)
+		}
+	}
+	if d, ok := node.(*ConstDecl); ok && tc.tm.IsTypeCorrect(d) {
+		tc.constDecl(d)
+	}
+	if s, ok := node.(*Switch); ok && tc.tm.IsTypeCorrect(s) {
+		tc.switchStmt(s)
+	}
 	ans := tc.tm.IsTypeCorrect(node)
 	if !ans {
-		tc.error("Bad typeing for %s", node)
+		tc.error("Bad typeing for %s\n", print.String(node))
 		return nil
 	}
 	// returns (copy?) itself
 	return tc
 }
 
+// call checks the number and the types of the arguments of c against
+// the signature of the function it calls.
+func (tc *TypeChecker) call(c *Call) {
+	sig, ok := tc.funcs[c.Name]
+	if !ok {
+		tc.error("Undefined function %s\n", c.Name)
+		return
+	}
+	if len(c.Args) != len(sig.Params) {
+		tc.error("%s called with %d arguments, want %d\n", c.Name, len(c.Args), len(sig.Params))
+		return
+	}
+	for i, x := range c.Args {
+		if tc.tm.IsTypeCorrect(x) && !assignable(sig.Params[i], tc.tm.typeOf(x)) {
+			tc.error("Bad typeing for argument %d of %s: %s, want %s\n", i+1, c.Name, tc.tm.typeOf(x), sig.Params[i])
+		}
+	}
+}
+
+// constDecl evaluates the value of the constant declared by d, which
+// may only refer to the constants declared before it.
+func (tc *TypeChecker) constDecl(d *ConstDecl) {
+	if !tc.constant(d.Value) {
+		tc.error("Value %s of constant %s at %s is not constant\n", print.String(d.Value), d.Var, d.Pos)
+		return
+	}
+	v, err := eval.Expr(d.Value, tc.consts.Lookup)
+	if err != nil {
+		tc.error("Constant %s at %s: %v\n", d.Var, d.Pos, err)
+		return
+	}
+	tc.consts[d.Var] = eval.Convert(v, d.T)
+}
+
+// switchStmt checks that the labels of the cases of s are constants
+// of the type of its tag, matching distinct values, that s has at
+// most one default case and that its last case does not fall through.
+func (tc *TypeChecker) switchStmt(s *Switch) {
+	type span struct {
+		lo, hi int64
+	}
+	t := tc.tm.typeOf(s.Tag)
+	var seen []span
+	defaults := 0
+	for i, c := range s.Cases {
+		if len(c.Labels) == 0 {
+			if defaults++; defaults > 1 {
+				tc.error("Multiple defaults in switch at %s\n", c.Pos)
+			}
+		}
+		if c.Fallthrough && i == len(s.Cases)-1 {
+			tc.error("Cannot fallthrough final case in switch at %s\n", c.Pos)
+		}
+		for _, l := range c.Labels {
+			ok := true
+			for _, x := range []Expr{l.Lo, l.Hi} {
+				if x != nil && tc.tm.IsTypeCorrect(x) && tc.tm.typeOf(x) != t {
+					tc.error("Bad typeing for case %s at %s: %s, want %s\n", print.String(x), c.Pos, tc.tm.typeOf(x), t)
+					ok = false
+				}
+			}
+			if !ok {
+				continue
+			}
+			if !tc.constant(l) {
+				tc.error("Case %s at %s is not constant\n", print.String(l), c.Pos)
+				continue
+			}
+			lo, hi, err := eval.Label(l, tc.consts.Lookup)
+			if err != nil {
+				tc.error("Case %s at %s: %v\n", print.String(l), c.Pos, err)
+				continue
+			}
+			if hi < lo {
+				tc.error("Empty case range %s at %s\n", print.String(l), c.Pos)
+				continue
+			}
+			for _, r := range seen {
+				if lo <= r.hi && r.lo <= hi {
+					tc.error("Duplicate case %s in switch at %s\n", print.String(l), c.Pos)
+					break
+				}
+			}
+			seen = append(seen, span{lo, hi})
+		}
+	}
+}
+
+// constant reports whether node refers to no variable or function,
+// and to no constant whose value is unknown.
+func (tc *TypeChecker) constant(node Node) bool {
+	ok := true
+	Inspect(node, func(n Node) bool {
+		switch n := n.(type) {
+		case Variable:
+			_, ok = tc.consts.Lookup(n)
+		case *Call:
+			ok = false
+		}
+		return ok
+	})
+	return ok
+}
+
 func (tc *TypeChecker) error(msg string, args ...interface{}) {
 	tc.ErrCount = tc.ErrCount + 1
 	tc.err(msg, args...)
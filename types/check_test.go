@@ -1,10 +1,13 @@
 package types
 
 import (
+	"fmt"
+	"strings"
 	"testing"
 
 	. "github.com/mentalpumkins/clite-go/ast"
 	"github.com/mentalpumkins/clite-go/ast/operators"
+	"github.com/mentalpumkins/clite-go/parser"
 )
 
 var staticCheckTestCases = [...]struct {
@@ -15,26 +18,26 @@ var staticCheckTestCases = [...]struct {
 		&Program{
 			[]Decl{
 				&VariableDecl{
-					Variable("a"),
-					INT_TYPE,
+					Var: Variable("a"),
+					T:   INT_TYPE,
 				},
 				&VariableDecl{
-					Variable("b"),
-					FLOAT_TYPE,
+					Var: Variable("b"),
+					T:   FLOAT_TYPE,
 				},
 			},
 			[]Stmt{
 				&Assignment{
-					Variable("a"),
-					IntVal(1),
+					Target: Variable("a"),
+					Source: IntVal(1),
 				},
 				&Assignment{
-					Variable("b"),
-					FloatVal(2.0),
+					Target: Variable("b"),
+					Source: FloatVal(2.0),
 				},
 				&Assignment{
-					Variable("b"),
-					&Binary{
+					Target: Variable("b"),
+					Source: &Binary{
 						operators.Operator("*"),
 						Variable("b"),
 						Variable("a"),
@@ -48,14 +51,14 @@ var staticCheckTestCases = [...]struct {
 		&Program{
 			[]Decl{
 				&VariableDecl{
-					Variable("a"),
-					INT_TYPE,
+					Var: Variable("a"),
+					T:   INT_TYPE,
 				},
 			},
 			[]Stmt{
 				&Assignment{
-					Variable("a"),
-					CharVal('a'),
+					Target: Variable("a"),
+					Source: CharVal('a'),
 				},
 			},
 		},
@@ -80,3 +83,321 @@ func TestStaticTypeChecker(t *testing.T) {
 		}
 	}
 }
+
+func TestCheckFuncs(t *testing.T) {
+	funcs := Funcs{
+		"sqrt": {Params: []Type{FLOAT_TYPE}, Result: FLOAT_TYPE},
+		"ord":  {Params: []Type{CHAR_TYPE}, Result: INT_TYPE},
+		"rand": {Result: INT_TYPE},
+	}
+	x, y, c := Variable("x"), Variable("y"), Variable("c")
+	decls := []Decl{
+		&VariableDecl{Var: x, T: FLOAT_TYPE},
+		&VariableDecl{Var: y, T: INT_TYPE},
+		&VariableDecl{Var: c, T: CHAR_TYPE},
+	}
+	call := func(name string, args ...Expr) *Call {
+		return &Call{Name: name, Args: args}
+	}
+	for i, test := range []struct {
+		source Expr
+		target Variable
+		msgs   []string
+	}{
+		{call("sqrt", y), x, nil},
+		{&Binary{Op: "+", Term1: call("ord", c), Term2: call("rand")}, y, nil},
+		{call("sqrt", call("sqrt", x)), x, nil},
+		{call("sqrt", x), y, []string{"Bad typeing for"}},
+		{call("sqrt"), x, []string{"sqrt called with 0 arguments, want 1"}},
+		{call("ord", y), y, []string{"Bad typeing for argument 1 of ord: int, want char"}},
+		{call("abs", y), y, []string{"Undefined function abs"}},
+		{Variable("rand"), y, []string{"Function rand used as a variable"}},
+		{call("sqrt", call("ord", x)), x, []string{"Bad typeing for argument 1 of ord: float, want char"}},
+	} {
+		prog := &Program{DecPart: decls, Body: []Stmt{&Assignment{Target: test.target, Source: test.source}}}
+		tc := new(TypeChecker)
+		if err := tc.InitFuncs(prog, funcs); err != nil {
+			t.Fatal(err)
+		}
+		var msgs []string
+		tc.SetErrorHandler(func(s string, args ...interface{}) {
+			msgs = append(msgs, fmt.Sprintf(s, args...))
+		})
+		Walk(tc, prog)
+		if len(msgs) != len(test.msgs) {
+			t.Errorf("%d: got errors %q, want %q", i, msgs, test.msgs)
+			continue
+		}
+		for j, msg := range msgs {
+			if !strings.HasPrefix(msg, test.msgs[j]) {
+				t.Errorf("%d: got error %q, want %q", i, msg, test.msgs[j])
+			}
+		}
+	}
+
+	// functions and variables share a name space
+	prog := &Program{DecPart: decls}
+	if _, _, err := CheckFuncs(prog, Funcs{"x": {Result: INT_TYPE}}); err == nil {
+		t.Error("function named like a variable accepted")
+	}
+}
+
+func TestStrings(t *testing.T) {
+	s, c, i, b := Variable("s"), Variable("c"), Variable("i"), Variable("b")
+	decls := []Decl{
+		&VariableDecl{Var: s, T: STRING_TYPE},
+		&VariableDecl{Var: c, T: CHAR_TYPE},
+		&VariableDecl{Var: i, T: INT_TYPE},
+		&VariableDecl{Var: b, T: BOOL_TYPE},
+	}
+	for n, test := range []struct {
+		source Expr
+		target Variable
+		ok     bool
+	}{
+		{&Binary{Op: "+", Term1: s, Term2: StringVal("ab")}, s, true},
+		{&Unary{Op: "string", Term: c}, s, true},
+		{&Unary{Op: "len", Term: s}, i, true},
+		{&Index{X: s, Index: c}, c, true},
+		{&Binary{Op: "<", Term1: s, Term2: s}, b, true},
+		{&Binary{Op: "+", Term1: s, Term2: IntVal(1)}, s, false},
+		{&Binary{Op: "-", Term1: s, Term2: s}, s, false},
+		{&Unary{Op: "string", Term: i}, s, false},
+		{&Unary{Op: "len", Term: c}, i, false},
+		{&Unary{Op: "bool", Term: s}, b, false},
+		{&Index{X: s, Index: FloatVal(1)}, c, false},
+		{&Index{X: c, Index: i}, c, false},
+		{&Index{X: s, Index: i}, i, true},
+		{StringVal("a"), c, false},
+	} {
+		prog := &Program{DecPart: decls, Body: []Stmt{&Assignment{Target: test.target, Source: test.source}}}
+		var tc TypeChecker
+		tc.Init(prog)
+		tc.SetErrorHandler(func(string, ...interface{}) {})
+		Walk(&tc, prog)
+		if ok := tc.ErrCount == 0; ok != test.ok {
+			t.Errorf("%d: %s = %s type checks %v, want %v", n, test.target, test.source, ok, test.ok)
+		}
+	}
+}
+
+func TestBitwise(t *testing.T) {
+	i, c, x, b := Variable("i"), Variable("c"), Variable("x"), Variable("b")
+	decls := []Decl{
+		&VariableDecl{Var: i, T: INT_TYPE},
+		&VariableDecl{Var: c, T: CHAR_TYPE},
+		&VariableDecl{Var: x, T: FLOAT_TYPE},
+		&VariableDecl{Var: b, T: BOOL_TYPE},
+	}
+	for n, test := range []struct {
+		source Expr
+		target Variable
+		ok     bool
+	}{
+		{&Binary{Op: "%", Term1: i, Term2: IntVal(3)}, i, true},
+		{&Binary{Op: "&", Term1: c, Term2: i}, i, true},
+		{&Binary{Op: "^", Term1: c, Term2: c}, i, true},
+		{&Binary{Op: "<<", Term1: i, Term2: c}, x, true},
+		{&Unary{Op: "~", Term: c}, i, true},
+		{&Binary{Op: "%", Term1: c, Term2: IntVal(10)}, i, true},
+		{&Binary{Op: "%", Term1: x, Term2: i}, x, false},
+		{&Binary{Op: "|", Term1: i, Term2: x}, i, false},
+		{&Binary{Op: ">>", Term1: b, Term2: i}, i, false},
+		{&Binary{Op: "&", Term1: c, Term2: c}, c, false},
+		{&Unary{Op: "~", Term: b}, i, false},
+		{&Unary{Op: "~", Term: x}, i, false},
+	} {
+		prog := &Program{DecPart: decls, Body: []Stmt{&Assignment{Target: test.target, Source: test.source}}}
+		var tc TypeChecker
+		tc.Init(prog)
+		tc.SetErrorHandler(func(string, ...interface{}) {})
+		Walk(&tc, prog)
+		if ok := tc.ErrCount == 0; ok != test.ok {
+			t.Errorf("%d: %s = %s type checks %v, want %v", n, test.target, test.source, ok, test.ok)
+		}
+	}
+}
+
+func TestTernary(t *testing.T) {
+	i, x, b, s := Variable("i"), Variable("x"), Variable("b"), Variable("s")
+	decls := []Decl{
+		&VariableDecl{Var: i, T: INT_TYPE},
+		&VariableDecl{Var: x, T: FLOAT_TYPE},
+		&VariableDecl{Var: b, T: BOOL_TYPE},
+		&VariableDecl{Var: s, T: STRING_TYPE},
+	}
+	for n, test := range []struct {
+		source Expr
+		target Variable
+		typ    Type
+		ok     bool
+	}{
+		{&Ternary{Test: b, Then: i, Else: IntVal(1)}, i, INT_TYPE, true},
+		{&Ternary{Test: b, Then: i, Else: x}, x, FLOAT_TYPE, true},
+		{&Ternary{Test: b, Then: s, Else: StringVal("")}, s, STRING_TYPE, true},
+		{&Ternary{Test: b, Then: b, Else: &Ternary{Test: b, Then: b, Else: BoolVal(false)}}, b, BOOL_TYPE, true},
+		{&Ternary{Test: b, Then: x, Else: i}, i, nil, false},
+		{&Ternary{Test: i, Then: i, Else: i}, i, nil, false},
+		{&Ternary{Test: b, Then: i, Else: s}, i, nil, false},
+		{&Ternary{Test: b, Then: CharVal('a'), Else: i}, i, nil, false},
+	} {
+		prog := &Program{DecPart: decls, Body: []Stmt{&Assignment{Target: test.target, Source: test.source}}}
+		var tc TypeChecker
+		tc.Init(prog)
+		tc.SetErrorHandler(func(string, ...interface{}) {})
+		Walk(&tc, prog)
+		if ok := tc.ErrCount == 0; ok != test.ok {
+			t.Errorf("%d: %s = %s type checks %v, want %v", n, test.target, test.source, ok, test.ok)
+		}
+		tm, _ := Typing(prog)
+		if typ := tm.TypeOf(test.source); test.ok && typ != test.typ {
+			t.Errorf("%d: %s has type %s, want %s", n, test.source, typ, test.typ)
+		}
+	}
+}
+
+func TestSwitch(t *testing.T) {
+	i, c, x := Variable("i"), Variable("c"), Variable("x")
+	decls := []Decl{
+		&VariableDecl{Var: i, T: INT_TYPE},
+		&VariableDecl{Var: c, T: CHAR_TYPE},
+		&VariableDecl{Var: x, T: FLOAT_TYPE},
+	}
+	label := func(lo, hi Expr) *CaseLabel { return &CaseLabel{Lo: lo, Hi: hi} }
+	one := func(labels ...*CaseLabel) []*Case { return []*Case{{Labels: labels}} }
+	for n, test := range []struct {
+		tag   Expr
+		cases []*Case
+		msgs  []string
+	}{
+		{i, one(label(IntVal(1), nil), label(IntVal(2), IntVal(4))), nil},
+		{c, one(label(CharVal('a'), CharVal('z')), label(CharVal('0'), nil)), nil},
+		{&Binary{Op: "%", Term1: i, Term2: IntVal(3)}, one(label(&Unary{Op: "-", Term: IntVal(1)}, nil)), nil},
+		{x, one(label(FloatVal(1), nil)), []string{"Bad typeing for"}},
+		{c, one(label(IntVal(97), nil)), []string{"Bad typeing for case 97"}},
+		{i, one(label(i, nil)), []string{"Case i at - is not constant"}},
+		{i, one(label(&Binary{Op: "/", Term1: IntVal(1), Term2: IntVal(0)}, nil)), []string{"Case 1 / 0 at -: integer divide by zero"}},
+		{i, one(label(IntVal(4), IntVal(2))), []string{"Empty case range 4 ... 2"}},
+		{i, one(label(IntVal(1), IntVal(5)), label(IntVal(5), nil)), []string{"Duplicate case 5"}},
+		{i, []*Case{{}, {Labels: []*CaseLabel{label(IntVal(3), nil)}}, {}}, []string{"Multiple defaults"}},
+		{i, []*Case{{Fallthrough: true}, {Labels: []*CaseLabel{label(IntVal(3), nil)}, Fallthrough: true}}, []string{"Cannot fallthrough final case"}},
+	} {
+		prog := &Program{DecPart: decls, Body: []Stmt{&Switch{Tag: test.tag, Cases: test.cases}}}
+		var tc TypeChecker
+		tc.Init(prog)
+		var msgs []string
+		tc.SetErrorHandler(func(s string, args ...interface{}) {
+			msgs = append(msgs, fmt.Sprintf(s, args...))
+		})
+		Walk(&tc, prog)
+		if len(msgs) != len(test.msgs) {
+			t.Errorf("%d: got errors %q, want %q", n, msgs, test.msgs)
+			continue
+		}
+		for j, msg := range msgs {
+			if !strings.HasPrefix(msg, test.msgs[j]) {
+				t.Errorf("%d: got error %q, want %q", n, msg, test.msgs[j])
+			}
+		}
+	}
+}
+
+func TestConst(t *testing.T) {
+	for n, test := range []struct {
+		src  string
+		msgs []string
+	}{
+		{"const int N = 2, M = N * 3 + 1; const float H = M / 2; int i; i = N + M;", nil},
+		{"const char A = 'a'; char c; int i; switch (c) { case 'A' ... A: ; } i = A;", nil},
+		{"const int N = 1; N = 2;", []string{"Cannot assign to constant N at 1:30"}},
+		{"int i; const int N = i;", []string{"Value i of constant N at 1:30 is not constant"}},
+		{"const int N = M, M = 1;", []string{"Value M of constant N at 1:23 is not constant"}},
+		{"const int N = 1 / 0;", []string{"Constant N at 1:23: integer divide by zero"}},
+		{"const int N = 1.5;", []string{"Bad typeing for"}},
+		{"const bool B = true; int i; i = B;", []string{"Bad typeing for"}},
+		{"const int N = 1; int i; switch (i) { case N: ; case 1: ; }", []string{"Duplicate case 1"}},
+	} {
+		prog, err := parser.Parse([]byte("int main() { " + test.src + " }"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		var tc TypeChecker
+		if err := tc.Init(prog); err != nil {
+			t.Fatal(err)
+		}
+		var msgs []string
+		tc.SetErrorHandler(func(s string, args ...interface{}) {
+			msgs = append(msgs, fmt.Sprintf(s, args...))
+		})
+		Walk(&tc, prog)
+		if len(msgs) != len(test.msgs) {
+			t.Errorf("%d: got errors %q, want %q", n, msgs, test.msgs)
+			continue
+		}
+		for j, msg := range msgs {
+			if !strings.HasPrefix(msg, test.msgs[j]) {
+				t.Errorf("%d: got error %q, want %q", n, msg, test.msgs[j])
+			}
+		}
+	}
+
+	prog, _ := parser.Parse([]byte("int main() { const char A = 'a'; const int N = int(A) + 1; }"))
+	if ok, tc, err := Check(prog); !ok || err != nil {
+		t.Fatalf("got %v, %v", ok, err)
+	} else if got := tc.Consts(); len(got) != 2 || got["A"] != CharVal('a') || got["N"] != IntVal(98) {
+		t.Errorf("constants %v", got)
+	}
+}
+
+func TestStruct(t *testing.T) {
+	pt := &Struct{Name: "P", Fields: []*Field{{Name: "x", T: INT_TYPE}, {Name: "f", T: FLOAT_TYPE}}}
+	qt := &Struct{Name: "Q", Fields: []*Field{{Name: "x", T: INT_TYPE}}}
+	p, q, r, i, b := Variable("p"), Variable("q"), Variable("r"), Variable("i"), Variable("b")
+	decls := []Decl{
+		&StructDecl{T: pt},
+		&StructDecl{T: qt},
+		&VariableDecl{Var: p, T: pt},
+		&VariableDecl{Var: q, T: pt},
+		&VariableDecl{Var: r, T: qt},
+		&VariableDecl{Var: i, T: INT_TYPE},
+		&VariableDecl{Var: b, T: BOOL_TYPE},
+	}
+	for n, test := range []struct {
+		a   *Assignment
+		typ Type
+		ok  bool
+	}{
+		{&Assignment{Target: i, Source: &Selector{X: p, Field: "x"}}, INT_TYPE, true},
+		{&Assignment{Target: p, Fields: []string{"f"}, Source: &Selector{X: r, Field: "x"}}, INT_TYPE, true},
+		{&Assignment{Target: p, Source: q}, pt, true},
+		{&Assignment{Target: p, Source: &Ternary{Test: b, Then: p, Else: q}}, pt, true},
+		{&Assignment{Target: p, Source: r}, nil, false},
+		{&Assignment{Target: i, Source: &Selector{X: p, Field: "y"}}, nil, false},
+		{&Assignment{Target: i, Source: &Selector{X: i, Field: "x"}}, nil, false},
+		{&Assignment{Target: p, Fields: []string{"y"}, Source: i}, nil, false},
+		{&Assignment{Target: i, Fields: []string{"x"}, Source: i}, nil, false},
+		{&Assignment{Target: b, Source: &Binary{Op: "==", Term1: p, Term2: q}}, nil, false},
+	} {
+		prog := &Program{DecPart: decls, Body: []Stmt{test.a}}
+		var tc TypeChecker
+		tc.Init(prog)
+		tc.SetErrorHandler(func(string, ...interface{}) {})
+		Walk(&tc, prog)
+		if ok := tc.ErrCount == 0; ok != test.ok {
+			t.Errorf("%d: %s = %s type checks %v, want %v", n, test.a.Lvalue(), test.a.Source, ok, test.ok)
+		}
+		tm, _ := Typing(prog)
+		if typ := tm.TypeOf(test.a.Source); test.ok && typ != test.typ {
+			t.Errorf("%d: %s has type %s, want %s", n, test.a.Source, typ, test.typ)
+		}
+	}
+
+	for _, decls := range [][]Decl{
+		{&StructDecl{T: pt}, &StructDecl{T: &Struct{Name: "P"}}},
+		{&StructDecl{T: &Struct{Name: "P", Fields: []*Field{{Name: "x", T: INT_TYPE}, {Name: "x", T: BOOL_TYPE}}}}},
+	} {
+		if _, err := Typing(&Program{DecPart: decls}); err == nil {
+			t.Errorf("%v: no error", decls)
+		}
+	}
+}
@@ -14,16 +14,35 @@ type TypeMap map[Variable]Type
 // the suplied program.
 func Typing(p *Program) (*TypeMap, error) {
 	tm := TypeMap(make(map[Variable]Type))
+	structs := make(map[string]bool)
 	for _, decl := range p.DecPart {
 		// type switch not needed right now will
 		// be usefull for adding array declerations
 		switch d := decl.(type) {
 		case *VariableDecl:
-			if val, duplicate := tm[d.Var]; !duplicate {
+			if _, duplicate := tm[d.Var]; !duplicate {
 				tm[d.Var] = d.T
 			} else {
 				// You done screwed up.
-				return nil, DuplicateDeclerationError(string(val))
+				return nil, DuplicateDeclerationError(d.Var)
+			}
+		case *ConstDecl:
+			// constants share the name space of variables
+			if _, duplicate := tm[d.Var]; duplicate {
+				return nil, DuplicateDeclerationError(d.Var)
+			}
+			tm[d.Var] = d.T
+		case *StructDecl:
+			// struct types have a name space of their own, as do
+			// the fields of each
+			if structs[d.T.Name] {
+				return nil, DuplicateDeclerationError("struct " + d.T.Name)
+			}
+			structs[d.T.Name] = true
+			for i, f := range d.T.Fields {
+				if d.T.Field(f.Name) != i {
+					return nil, DuplicateDeclerationError(d.T.Name + "." + f.Name)
+				}
 			}
 		}
 	}
@@ -61,32 +80,30 @@ func (tm *TypeMap) IsTypeCorrect(node Node) bool {
 		if valid := tm.IsTypeCorrect(n.Body); !valid {
 			return false
 		}
+	case *Switch:
+		//A Switch is valid if its tag Expression is valid and has type int or char.
+		//Its cases are checked by the TypeChecker.
+		if valid := tm.IsTypeCorrect(n.Tag); !valid {
+			return false
+		}
+		t := tm.typeOf(n.Tag)
+		return t == INT_TYPE || t == CHAR_TYPE
 	case *Assignment:
 		//An Assignment is valid !fall the following are true:
-		//	(a) its target Variable is declared.
+		//	(a) its target Variable is declared, and the fields selected
+		//	    of it, if any, exist.
 		if _, ok := (*tm)[n.Target]; !ok {
 			return false
 		}
+		lv := n.Lvalue()
+		if valid := tm.IsTypeCorrect(lv); !valid {
+			return false
+		}
 		//	(b) Its source Expression is valid.
 		if valid := tm.IsTypeCorrect(n.Source); !valid {
 			return false
 		}
-		targetType := tm.typeOf(n.Target)
-		sourceType := tm.typeOf(n.Source)
-		switch targetType {
-		case FLOAT_TYPE:
-			//(c) If the type of its target Variable is float, then the type of its source
-			//    Expression must he either float or int
-			return sourceType == FLOAT_TYPE || sourceType == INT_TYPE
-		case INT_TYPE:
-			//(d) Otherwise, if the type of its target Variable is int, then the type of its
-			//    source Expression must be either int or char.
-			return sourceType == INT_TYPE || sourceType == CHAR_TYPE
-		default:
-			//(e) Otherwise, the type of its target Variable must be the same as the type of
-			//    its source Expression.
-			return targetType == sourceType
-		}
+		return assignable(tm.typeOf(lv), tm.typeOf(n.Source))
 	case *Binary:
 		//A Binary is valid if all the following are true:
 		//	(a) Its Expressions terml and term2 are valid.
@@ -95,6 +112,11 @@ func (tm *TypeMap) IsTypeCorrect(node Node) bool {
 		}
 		t1 := tm.typeOf(n.Term1)
 		t2 := tm.typeOf(n.Term2)
+		if n.Op == "+" && (t1 == STRING_TYPE || t2 == STRING_TYPE) {
+			//    If op is + and either Expression is a string, then both must be
+			//    strings, which + concatenates.
+			return t1 == t2
+		}
 		switch n.Op {
 		case "-", "+", "*", "/":
 			//(b) If its BinaryOp op is arithmetic ( +, - , *, /), then both its Expressions
@@ -105,10 +127,15 @@ func (tm *TypeMap) IsTypeCorrect(node Node) bool {
 			if t2 != FLOAT_TYPE && t2 != INT_TYPE {
 				return false
 			}
+		case "%", "&", "|", "^", "<<", ">>":
+			//    If op is %, bitwise (&, |, ^) or a shift (<<, >>), then both its
+			//    Expressions must be either int or char.
+			return (t1 == INT_TYPE || t1 == CHAR_TYPE) && (t2 == INT_TYPE || t2 == CHAR_TYPE)
 		case "==", "!=", "<", "<=", ">", ">=":
 			//(c) if op is relational(==, !=, <. <=. >, >=),then both its Expressions must
-			//    have the same type.
-			return t1 == t2
+			//    have the same type, which is not a struct.
+			_, isStruct := t1.(*Struct)
+			return t1 == t2 && !isStruct
 		case "&&", "||":
 			//(d) If op is boolean ( &&, || ), then both its Expressions must be bool.
 			return t1 == BOOL_TYPE && t2 == BOOL_TYPE
@@ -127,6 +154,10 @@ func (tm *TypeMap) IsTypeCorrect(node Node) bool {
 			// (c) If op is -, then term must be int or float.
 			t := tm.typeOf(n.Term)
 			return t == FLOAT_TYPE || t == INT_TYPE
+		case "~":
+			//     If op is ~, then term must be int or char.
+			t := tm.typeOf(n.Term)
+			return t == INT_TYPE || t == CHAR_TYPE
 		case "float", "char":
 			// (d) If op is the type conversion float() or char(), then term must be int.
 			return tm.typeOf(n.Term) == INT_TYPE
@@ -136,6 +167,51 @@ func (tm *TypeMap) IsTypeCorrect(node Node) bool {
 			if t != FLOAT_TYPE && t != CHAR_TYPE {
 				return false
 			}
+		case "string":
+			// (f) If op is the type conversion string(), then term must be char.
+			return tm.typeOf(n.Term) == CHAR_TYPE
+		case "bool":
+			// (g) If op is the type conversion bool(), then term must not be a string
+			//     or a struct.
+			t, ok := tm.typeOf(n.Term).(BasicType)
+			return ok && t != STRING_TYPE
+		case "len":
+			// (h) If op is len, then term must be a string.
+			return tm.typeOf(n.Term) == STRING_TYPE
+		}
+	case *Index:
+		//An Index is valid if its Expressions are valid, the indexed one is a string
+		//and the index is an int, or a char.
+		if !tm.IsTypeCorrect(n.X) || !tm.IsTypeCorrect(n.Index) {
+			return false
+		}
+		return tm.typeOf(n.X) == STRING_TYPE && assignable(INT_TYPE, tm.typeOf(n.Index))
+	case *Ternary:
+		//A Ternary is valid if its Expressions are valid, its test is a bool and
+		//its branches have the same type, or are an int and a float.
+		if !tm.IsTypeCorrect(n.Test) || !tm.IsTypeCorrect(n.Then) || !tm.IsTypeCorrect(n.Else) {
+			return false
+		}
+		if tm.typeOf(n.Test) != BOOL_TYPE {
+			return false
+		}
+		t1, t2 := tm.typeOf(n.Then), tm.typeOf(n.Else)
+		return t1 == t2 || (t1 == INT_TYPE || t1 == FLOAT_TYPE) && (t2 == INT_TYPE || t2 == FLOAT_TYPE)
+	case *Selector:
+		//A Selector is valid if its Expression is valid and is a struct having
+		//a field of the name selected.
+		if valid := tm.IsTypeCorrect(n.X); !valid {
+			return false
+		}
+		st, ok := tm.typeOf(n.X).(*Struct)
+		return ok && st.Field(n.Field) >= 0
+	case *Call:
+		//A Call is valid if its arguments are valid. Whether they suit the
+		//function is checked by the TypeChecker, which knows its signature.
+		for _, x := range n.Args {
+			if !tm.IsTypeCorrect(x) {
+				return false
+			}
 		}
 	case Variable:
 		//A Variable is valid if its id appears in the type map.
@@ -147,12 +223,44 @@ func (tm *TypeMap) IsTypeCorrect(node Node) bool {
 		// do nothing for now.
 		// if I add in decleration initilization
 		// this will be the place to check.
+	case *ConstDecl:
+		//A ConstDecl is valid if its value Expression is valid and may be
+		//assigned to a variable of the type of the constant.
+		if valid := tm.IsTypeCorrect(n.Value); !valid {
+			return false
+		}
+		return assignable(n.T, tm.typeOf(n.Value))
 	case Value:
 		//A Value is valid.
 	}
 	return true
 }
 
+// assignable reports whether a value of type source may be assigned to
+// a variable of type target, or passed for a parameter of that type.
+func assignable(target, source Type) bool {
+	switch target {
+	case FLOAT_TYPE:
+		//(c) If the type of its target Variable is float, then the type of its source
+		//    Expression must he either float or int
+		return source == FLOAT_TYPE || source == INT_TYPE
+	case INT_TYPE:
+		//(d) Otherwise, if the type of its target Variable is int, then the type of its
+		//    source Expression must be either int or char.
+		return source == INT_TYPE || source == CHAR_TYPE
+	default:
+		//(e) Otherwise, the type of its target Variable must be the same as the type of
+		//    its source Expression.
+		return target == source
+	}
+}
+
+// TypeOf returns the result type of the expression exp, which must
+// be type correct.
+func (tm *TypeMap) TypeOf(exp Expr) Type {
+	return tm.typeOf(exp)
+}
+
 func (tm *TypeMap) typeOf(exp Expr) (t Type) {
 	// Hooray for the go switch!
 	switch e := exp.(type) {
@@ -175,18 +283,24 @@ func (tm *TypeMap) typeOf(exp Expr) (t Type) {
 			t1 := tm.typeOf(e.Term1)
 			t2 := tm.typeOf(e.Term2)
 			// Promote in all cases
-			if t1 == FLOAT_TYPE || t2 == FLOAT_TYPE {
+			if t1 == STRING_TYPE {
+				// concatenation
+				t = STRING_TYPE
+			} else if t1 == FLOAT_TYPE || t2 == FLOAT_TYPE {
 				t = FLOAT_TYPE
 			} else {
 				t = INT_TYPE
 			}
+		case "%", "&", "|", "^", "<<", ">>":
+			//    If the Operator is % or bitwise, then its result type is int.
+			t = INT_TYPE
 		//	(b) If the Operator is relational ( <, <=, >, >=, ==, !=) or boolean
 		//	    (&&,II). then its result type is bool.
 		// Boolean Ops
 		case "&&", "||":
 			t = BOOL_TYPE
 		// Relational Ops
-		case "<=", ">=", ">", "<", "==":
+		case "<=", ">=", ">", "<", "==", "!=":
 			t = BOOL_TYPE
 		}
 	case *Unary:
@@ -198,6 +312,9 @@ func (tm *TypeMap) typeOf(exp Expr) (t Type) {
 		case "-":
 			//(b) If the Operator is - then its result type is the type of its operand.
 			t = tm.typeOf(e.Term)
+		case "~":
+			//    If the Operator is ~ then its result type is int.
+			t = INT_TYPE
 		//(c) if the Operator is a type conversion, then the result type is given by the
 		//    conversion.
 		case "float":
@@ -208,6 +325,38 @@ func (tm *TypeMap) typeOf(exp Expr) (t Type) {
 			t = CHAR_TYPE
 		case "bool":
 			t = BOOL_TYPE
+		case "string":
+			t = STRING_TYPE
+		case "len":
+			//(d) If the Operator is len, then its result type is int.
+			t = INT_TYPE
+		}
+	case *Index:
+		//if the Expression is an Index, then its result type is char.
+		t = CHAR_TYPE
+	case *Ternary:
+		//if the Expression is a Ternary, then its result type is that of its
+		//branches, promoted to float as for arithmetic if they differ.
+		t = tm.typeOf(e.Then)
+		if t != tm.typeOf(e.Else) {
+			t = FLOAT_TYPE
+		}
+	case *Selector:
+		//if the Expression is a Selector, then its result type is the type of the
+		//field selected.
+		if st, ok := tm.typeOf(e.X).(*Struct); ok {
+			if i := st.Field(e.Field); i >= 0 {
+				t = st.Fields[i].T
+			}
+		}
+	case *Call:
+		//if the Expression is a Call, then its result type is the result type of the
+		//function, which the TypeMap holds under the name of the function. An
+		//undefined function, reported by the TypeChecker, is taken to return an int
+		//so that its calls are not reported as badly typed too.
+		var ok bool
+		if t, ok = (*tm)[Variable(e.Name)]; !ok {
+			t = INT_TYPE
 		}
 	case Value:
 		//if the Expression is a Value, then its result type is the type of that Value.
@@ -220,6 +369,8 @@ func (tm *TypeMap) typeOf(exp Expr) (t Type) {
 			t = FLOAT_TYPE
 		case CharVal:
 			t = CHAR_TYPE
+		case StringVal:
+			t = STRING_TYPE
 		}
 	}
 	return